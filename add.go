@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func showAddHelp() {
+	fmt.Println("Usage: gomvc add <what> [arguments]")
+	fmt.Println("\nAdditions:")
+	fmt.Println("  route <METHOD> <path> <handler>\tRegister a route in InitializeRoutes, creating a stub of its handler")
+	fmt.Println("\nRun 'gomvc add <what> -h' for the options of an addition.")
+}
+
+func addCommand(args []string) {
+	if len(args) == 0 {
+		showAddHelp()
+		os.Exit(exitUsage)
+	}
+
+	what, args := args[0], args[1:]
+	switch what {
+	case "route":
+		addRouteCommand(args)
+	case "help", "-h", "-help", "--help":
+		showAddHelp()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown addition %q\n\n", what)
+		showAddHelp()
+		os.Exit(exitUsage)
+	}
+}
+
+// addRouteOptions holds the flags of 'gomvc add route'.
+type addRouteOptions struct {
+	group  string
+	dryRun bool
+}
+
+// addRouteFlags returns the flags of 'gomvc add route', parsed into opts.
+func addRouteFlags(opts *addRouteOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("add route", flag.ExitOnError)
+	fs.StringVar(&opts.group, "group", "", "Path of the group to register the route in, e.g. /api/v1")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files and the router diff without changing anything")
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc add route <METHOD> <path> <handler> [options]")
+		fmt.Fprintln(w, "\nRegisters handler, a function of package controller, for METHOD and path")
+		fmt.Fprintln(w, "in router/router.go, and creates a stub of it if the package does not")
+		fmt.Fprintln(w, "declare it yet. Parameters are written :id or {id}. Routes already")
+		fmt.Fprintln(w, "registered for the same method and path are rejected.")
+		fmt.Fprintln(w, "\nExample: gomvc add route GET /products/:id controller.ShowProduct -group /api/v1")
+		fmt.Fprintln(w, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func addRouteCommand(args []string) {
+	var opts addRouteOptions
+	fs := addRouteFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 3 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := openProject(false)
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		return project.AddRoute(context.Background(), positional[0], positional[1], positional[2], opts.group)
+	}()
+	if err != nil {
+		fail("Error adding route", err)
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+// completionShells are the shells 'gomvc completion' writes scripts for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionEntry is a command or generator the completion scripts know
+// about. The scripts are written from the flag sets the commands parse, so
+// they complete exactly the flags gomvc accepts.
+type completionEntry struct {
+	// name is the command, e.g. "new", or "generate" and the generator,
+	// e.g. "generate model".
+	name string
+	desc string
+	// flags is the flag set of the command, nil if it has none.
+	flags *flag.FlagSet
+	// dirs is set when the arguments of the command are directories, and
+	// words lists the arguments it accepts otherwise, if known.
+	dirs  bool
+	words []string
+}
+
+// completionCommands returns the commands and generators of gomvc, in the
+// order they are listed in the help.
+func completionCommands() []completionEntry {
+	return []completionEntry{
+		{name: "new", desc: "Create the MVC structure at the specified path", flags: newFlags(new(createOptions)), dirs: true},
+		{name: "destroy", desc: "Delete the MVC structure at the specified path", flags: destroyFlags(new(destroyOptions)), dirs: true},
+		{name: "generate", desc: "Add code to the project in the working directory"},
+		{name: "generate model", desc: "Create models/<name>.go", flags: generateModelFlags(new(generateOptions))},
+		{name: "generate models", desc: "Create a model per table of an existing database", flags: generateModelsFlags(new(generateOptions))},
+		{name: "generate controller", desc: "Create controller/<name>_controller.go", flags: generateControllerFlags(new(generateOptions))},
+		{name: "generate resource", desc: "Create a model and CRUD controller and register their routes", flags: generateResourceFlags(new(generateOptions))},
+		{name: "generate dto", desc: "Create request and response types mapped to a model", flags: generateDTOFlags(new(generateOptions))},
+		{name: "generate service", desc: "Create a service and repository and pass the service to the controller", flags: generateServiceFlags(new(generateOptions))},
+		{name: "generate middleware", desc: "Create middleware/<name>.go", flags: generateMiddlewareFlags(new(generateOptions))},
+		{name: "generate sse", desc: "Create a controller streaming Server-Sent Events", flags: generateSSEFlags(new(generateOptions))},
+		{name: "generate upload", desc: "Create a controller storing file uploads", flags: generateUploadFlags(new(generateOptions))},
+		{name: "generate cron", desc: "Create a task run on a cron schedule", flags: generateCronFlags(new(generateOptions))},
+		{name: "generate migration", desc: "Create an empty up/down SQL migration pair", flags: generateMigrationFlags(new(generateOptions))},
+		{name: "generate seeder", desc: "Create a seeder run by cmd/seed", flags: generateSeederFlags(new(generateOptions))},
+		{name: "add", desc: "Add a route to the project in the working directory"},
+		{name: "add route", desc: "Register a route, creating a stub of its handler", flags: addRouteFlags(new(addRouteOptions))},
+		{name: "remove", desc: "Remove a resource or feature from the project in the working directory"},
+		{name: "remove resource", desc: "Delete the files of a resource and unregister its routes", flags: removeResourceFlags(new(removeOptions))},
+		{name: "remove feature", desc: "Turn off a feature of new", flags: removeFeatureFlags(new(removeOptions)), words: scaffold.RemovableFeatures()},
+		{name: "restore", desc: "Unpack a backup written before files were deleted or overwritten", flags: restoreFlags(new(bool), new(backupOptions)), dirs: true},
+		{name: "routes", desc: "List the routes a project registers", flags: routesFlags(new(string)), dirs: true},
+		{name: "rename-module", desc: "Change the module path of a project and its imports", flags: renameModuleFlags(new(renameModuleOptions)), dirs: true},
+		{name: "upgrade", desc: "Update the generated files of a project to this version", flags: upgradeFlags(new(upgradeOptions)), dirs: true},
+		{name: "preset", desc: "Manage presets of the options of new"},
+		{name: "preset init", desc: "Write the defaults of new as a preset to start from", flags: presetInitFlags(new(bool))},
+		{name: "doctor", desc: "Check the environment has what new needs", flags: doctorFlags(new(doctorOptions)), dirs: true},
+		{name: "list", desc: "List the frameworks, layouts and features of new", flags: listFlags(new(string))},
+		{name: "version", desc: "Print the version of gomvc"},
+		{name: "completion", desc: "Print a shell completion script", flags: completionFlags(), words: completionShells},
+		{name: "help", desc: "Show the help message"},
+	}
+}
+
+// completionFlag is a flag of a completionEntry.
+type completionFlag struct {
+	name string
+	desc string
+	// value is set when the flag takes a value, which is one of values or
+	// a directory with dirs, if either is set.
+	value  bool
+	values []string
+	dirs   bool
+}
+
+// flagList returns the flags of c, sorted by name.
+func (c completionEntry) flagList() []completionFlag {
+	if c.flags == nil {
+		return nil
+	}
+	var flags []completionFlag
+	c.flags.VisitAll(func(f *flag.Flag) {
+		cf := completionFlag{name: f.Name, desc: flagSummary(f.Usage), value: !isBoolFlag(f)}
+		if cf.value {
+			cf.values, cf.dirs = flagValues(f.Name)
+		}
+		flags = append(flags, cf)
+	})
+	return flags
+}
+
+// flagValues returns the values the flag called name accepts, or dirs set
+// if it names a directory.
+func flagValues(name string) (values []string, dirs bool) {
+	for _, o := range scaffold.Options() {
+		if o.Name == name {
+			for _, c := range o.Choices {
+				values = append(values, c.Name)
+			}
+			return values, false
+		}
+	}
+	switch name {
+	case "output":
+		return []string{outputText, outputJSON}, false
+	case "mocks":
+		return scaffold.MockTools(), false
+	case "templates":
+		return nil, true
+	}
+	return nil, false
+}
+
+// flagSummary shortens the usage of a flag to its first clause, leaving
+// out the list of values in parentheses, for the descriptions shells show
+// next to the flags.
+func flagSummary(usage string) string {
+	for _, sep := range []string{" (", ", e.g.", "; "} {
+		if i := strings.Index(usage, sep); i > 0 {
+			usage = usage[:i]
+		}
+	}
+	return usage
+}
+
+// completionFlags returns the flags of 'gomvc completion', which has none
+// but prints how to install the scripts with -h.
+func completionFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("completion", flag.ExitOnError)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc completion bash|zsh|fish")
+		fmt.Fprintln(w, "\nPrints a script completing the commands, generators, flags and their")
+		fmt.Fprintln(w, "values of gomvc, and the paths given to new and destroy.")
+		fmt.Fprintln(w, "\nBash, for the current shell or, with bash-completion, for good:")
+		fmt.Fprintln(w, "  source <(gomvc completion bash)")
+		fmt.Fprintln(w, "  gomvc completion bash > ~/.local/share/bash-completion/completions/gomvc")
+		fmt.Fprintln(w, "\nZsh, into a directory of $fpath, with compinit enabled in ~/.zshrc:")
+		fmt.Fprintln(w, "  gomvc completion zsh > \"${fpath[1]}/_gomvc\"")
+		fmt.Fprintln(w, "\nFish:")
+		fmt.Fprintln(w, "  gomvc completion fish > ~/.config/fish/completions/gomvc.fish")
+		fmt.Fprintln(w, "\nStart a new shell for the completions to take effect.")
+	}
+	return fs
+}
+
+func completionCommand(args []string) {
+	fs := completionFlags()
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	var write func(io.Writer, []completionEntry)
+	switch positional[0] {
+	case "bash":
+		write = writeBashCompletion
+	case "zsh":
+		write = writeZshCompletion
+	case "fish":
+		write = writeFishCompletion
+	default:
+		fail("Error writing completion script", usageError(fmt.Sprintf("unknown shell %q; use one of %s", positional[0], strings.Join(completionShells, ", "))))
+	}
+	write(os.Stdout, completionCommands())
+}
+
+// commandNames returns the names of the commands in cmds below parent,
+// e.g. the generators for "generate", or the top-level commands for "".
+func commandNames(cmds []completionEntry, parent string) []string {
+	var names []string
+	for _, c := range cmds {
+		name, ok := subcommand(c.name, parent)
+		if ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parentCommands returns the top-level commands of cmds that have
+// subcommands, such as "generate".
+func parentCommands(cmds []completionEntry) []string {
+	var parents []string
+	for _, name := range commandNames(cmds, "") {
+		if commandNames(cmds, name) != nil {
+			parents = append(parents, name)
+		}
+	}
+	return parents
+}
+
+// subcommand returns the last word of name if name is a command directly
+// below parent.
+func subcommand(name, parent string) (string, bool) {
+	if parent != "" {
+		var ok bool
+		if name, ok = strings.CutPrefix(name, parent+" "); !ok {
+			return "", false
+		}
+	}
+	return name, !strings.Contains(name, " ")
+}
+
+func writeBashCompletion(w io.Writer, cmds []completionEntry) {
+	fmt.Fprintln(w, "# bash completion for gomvc, written by 'gomvc completion bash'.")
+	fmt.Fprintln(w, "_gomvc() {")
+	fmt.Fprintln(w, "\tlocal cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]} cmd")
+	fmt.Fprintln(w, "\tCOMPREPLY=()")
+	fmt.Fprintln(w, "\tif [[ $COMP_CWORD -eq 1 ]]; then")
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(commandNames(cmds, ""), " ")))
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, "\tcmd=${COMP_WORDS[1]}")
+	for _, parent := range parentCommands(cmds) {
+		fmt.Fprintf(w, "\tif [[ $cmd == %s ]]; then\n", parent)
+		fmt.Fprintln(w, "\t\tif [[ $COMP_CWORD -eq 2 ]]; then")
+		fmt.Fprintf(w, "\t\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(commandNames(cmds, parent), " ")))
+		fmt.Fprintln(w, "\t\t\treturn")
+		fmt.Fprintln(w, "\t\tfi")
+		fmt.Fprintf(w, "\t\tcmd=\"%s ${COMP_WORDS[2]}\"\n", parent)
+		fmt.Fprintln(w, "\tfi")
+	}
+	fmt.Fprintln(w, "\tcase $cmd in")
+	for _, c := range cmds {
+		flags := c.flagList()
+		if flags == nil && !c.dirs && c.words == nil {
+			continue
+		}
+		fmt.Fprintf(w, "\t%s)\n", shellQuote(c.name))
+		var names, plain []string
+		for _, f := range flags {
+			names = append(names, "-"+f.name)
+			switch {
+			case f.values != nil:
+				fmt.Fprintf(w, "\t\tif [[ $prev == -%s ]]; then COMPREPLY=($(compgen -W %s -- \"$cur\")); return; fi\n", f.name, shellQuote(strings.Join(f.values, " ")))
+			case f.dirs:
+				fmt.Fprintf(w, "\t\tif [[ $prev == -%s ]]; then COMPREPLY=($(compgen -d -- \"$cur\")); return; fi\n", f.name)
+			case f.value:
+				plain = append(plain, "-"+f.name)
+			}
+		}
+		if plain != nil {
+			// Nothing to suggest for these values, but they are not flags either
+			fmt.Fprintf(w, "\t\tcase $prev in %s) return ;; esac\n", strings.Join(plain, "|"))
+		}
+		if names != nil {
+			fmt.Fprintf(w, "\t\tif [[ $cur == -* ]]; then COMPREPLY=($(compgen -W %s -- \"$cur\")); return; fi\n", shellQuote(strings.Join(names, " ")))
+		}
+		switch {
+		case c.dirs:
+			fmt.Fprintln(w, "\t\tCOMPREPLY=($(compgen -d -- \"$cur\"))")
+		case c.words != nil:
+			fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(c.words, " ")))
+		}
+		fmt.Fprintln(w, "\t\t;;")
+	}
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o filenames -F _gomvc gomvc")
+}
+
+func writeZshCompletion(w io.Writer, cmds []completionEntry) {
+	describe := func(indent, parent string) {
+		for _, c := range cmds {
+			if name, ok := subcommand(c.name, parent); ok {
+				fmt.Fprintf(w, "%s\t%s\n", indent, shellQuote(name+":"+c.desc))
+			}
+		}
+	}
+	fmt.Fprintln(w, "#compdef gomvc")
+	fmt.Fprintln(w, "# zsh completion for gomvc, written by 'gomvc completion zsh'.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "_gomvc() {")
+	fmt.Fprintln(w, "\tlocal -a commands subcommands")
+	fmt.Fprintln(w, "\tif (( CURRENT == 2 )); then")
+	fmt.Fprintln(w, "\t\tcommands=(")
+	describe("\t\t", "")
+	fmt.Fprintln(w, "\t\t)")
+	fmt.Fprintln(w, "\t\t_describe -t commands 'gomvc command' commands")
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, "\tlocal cmd=$words[2]")
+	for _, parent := range parentCommands(cmds) {
+		fmt.Fprintf(w, "\tif [[ $cmd == %s ]]; then\n", parent)
+		fmt.Fprintln(w, "\t\tif (( CURRENT == 3 )); then")
+		fmt.Fprintln(w, "\t\t\tsubcommands=(")
+		describe("\t\t\t", parent)
+		fmt.Fprintln(w, "\t\t\t)")
+		fmt.Fprintf(w, "\t\t\t_describe -t subcommands %s subcommands\n", shellQuote(parent+" command"))
+		fmt.Fprintln(w, "\t\t\treturn")
+		fmt.Fprintln(w, "\t\tfi")
+		fmt.Fprintf(w, "\t\tcmd=\"%s $words[3]\"\n", parent)
+		fmt.Fprintln(w, "\t\tshift words")
+		fmt.Fprintln(w, "\t\t(( CURRENT-- ))")
+		fmt.Fprintln(w, "\tfi")
+	}
+	fmt.Fprintln(w, "\tshift words")
+	fmt.Fprintln(w, "\t(( CURRENT-- ))")
+	fmt.Fprintln(w, "\tcase $cmd in")
+	for _, c := range cmds {
+		flags := c.flagList()
+		if flags == nil && !c.dirs && c.words == nil {
+			continue
+		}
+		fmt.Fprintf(w, "\t%s)\n", shellQuote(c.name))
+		fmt.Fprint(w, "\t\t_arguments")
+		for _, f := range flags {
+			spec := "-" + f.name + "[" + zshEscape(f.desc) + "]"
+			switch {
+			case f.values != nil:
+				spec += ":" + f.name + ":(" + strings.Join(f.values, " ") + ")"
+			case f.dirs:
+				spec += ":" + f.name + ":_files -/"
+			case f.value:
+				spec += ":" + f.name + ": "
+			}
+			fmt.Fprintf(w, " \\\n\t\t\t%s", shellQuote(spec))
+		}
+		switch {
+		case c.dirs:
+			fmt.Fprintf(w, " \\\n\t\t\t%s", shellQuote("1:path:_files -/"))
+		case c.words != nil:
+			fmt.Fprintf(w, " \\\n\t\t\t%s", shellQuote("1:"+strings.Fields(c.name)[0]+":("+strings.Join(c.words, " ")+")"))
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "\t\t;;")
+	}
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "if [[ $funcstack[1] == _gomvc ]]; then")
+	fmt.Fprintln(w, "\t_gomvc \"$@\"")
+	fmt.Fprintln(w, "else")
+	fmt.Fprintln(w, "\tcompdef _gomvc gomvc")
+	fmt.Fprintln(w, "fi")
+}
+
+func writeFishCompletion(w io.Writer, cmds []completionEntry) {
+	fmt.Fprintln(w, "# fish completion for gomvc, written by 'gomvc completion fish'.")
+	fmt.Fprintln(w, "complete -c gomvc -f")
+	for _, c := range cmds {
+		// condition holds once the command has been typed
+		var condition string
+		if parent, name, ok := strings.Cut(c.name, " "); ok {
+			fmt.Fprintf(w, "complete -c gomvc -n %s -a %s -d %s\n",
+				shellQuote("__fish_seen_subcommand_from "+parent+"; and not __fish_seen_subcommand_from "+strings.Join(commandNames(cmds, parent), " ")),
+				shellQuote(name), shellQuote(c.desc))
+			condition = "__fish_seen_subcommand_from " + parent + "; and __fish_seen_subcommand_from " + name
+		} else {
+			fmt.Fprintf(w, "complete -c gomvc -n __fish_use_subcommand -a %s -d %s\n", shellQuote(c.name), shellQuote(c.desc))
+			condition = "__fish_seen_subcommand_from " + c.name
+		}
+		for _, f := range c.flagList() {
+			fmt.Fprintf(w, "complete -c gomvc -n %s -o %s", shellQuote(condition), f.name)
+			switch {
+			case f.values != nil:
+				fmt.Fprintf(w, " -x -a %s", shellQuote(strings.Join(f.values, " ")))
+			case f.dirs:
+				fmt.Fprint(w, " -x -a '(__fish_complete_directories)'")
+			case f.value:
+				fmt.Fprint(w, " -x")
+			}
+			fmt.Fprintf(w, " -d %s\n", shellQuote(f.desc))
+		}
+		switch {
+		case c.dirs:
+			fmt.Fprintf(w, "complete -c gomvc -n %s -a '(__fish_complete_directories)'\n", shellQuote(condition))
+		case c.words != nil:
+			fmt.Fprintf(w, "complete -c gomvc -n %s -a %s\n", shellQuote(condition), shellQuote(strings.Join(c.words, " ")))
+		}
+	}
+}
+
+// shellQuote quotes s in single quotes for bash, zsh and fish. Fish
+// escapes a quote inside them with a backslash, which the others do not
+// allow, so quotes are closed, escaped and reopened instead, which all
+// three understand.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// zshEscape escapes the characters _arguments gives a meaning to in the
+// description of an option.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+// doctorOptions holds the flags of 'gomvc doctor'.
+type doctorOptions struct {
+	git    bool
+	docker bool
+	output string
+}
+
+// doctorFlags returns the flags of 'gomvc doctor', parsed into opts.
+func doctorFlags(opts *doctorOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fs.BoolVar(&opts.git, "git", false, "Require git, as 'gomvc new -template' does")
+	fs.BoolVar(&opts.docker, "docker", false, "Require docker, as 'gomvc new -docker' does")
+	fs.StringVar(&opts.output, "output", outputText, "Output format: text, or json for editors and scripts ("+outputText+", "+outputJSON+")")
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc doctor [path] [options]")
+		fmt.Fprintln(w, "\nChecks the environment before creating a project at path, by default the")
+		fmt.Fprintln(w, "working directory: the go command, Go modules, git, docker, write")
+		fmt.Fprintln(w, "permission on path and the module proxy. It exits with 1 if a required")
+		fmt.Fprintln(w, "check fails. 'gomvc new' runs the checks it needs itself.")
+		fmt.Fprintln(w, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func doctorCommand(args []string) {
+	var opts doctorOptions
+	fs := doctorFlags(&opts)
+	paths := parseArgs(fs, args)
+	if len(paths) > 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	if opts.output != outputText && opts.output != outputJSON {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q (supported: %s, %s)\n", opts.output, outputText, outputJSON)
+		os.Exit(exitUsage)
+	}
+	root := "."
+	if len(paths) == 1 {
+		root = paths[0]
+	}
+	d := &scaffold.Doctor{Root: root, Git: opts.git, Docker: opts.docker}
+	checks := d.Run(context.Background())
+	failed := scaffold.CheckFailures(checks)
+	if opts.output == outputJSON {
+		newPrinter(opts.output, false).JSON(struct {
+			OK     bool             `json:"ok"`
+			Checks []scaffold.Check `json:"checks"`
+		}{failed == nil, checks})
+	} else {
+		printChecks(os.Stdout, checks)
+		if failed == nil {
+			fmt.Println("\nEverything gomvc needs is in place.")
+		} else {
+			fmt.Println("\nFix the failed checks above before creating a project.")
+		}
+	}
+	if failed != nil {
+		os.Exit(exitError)
+	}
+}
+
+// printChecks prints the result of each check, with the hint of those
+// that failed.
+func printChecks(w io.Writer, checks []scaffold.Check) {
+	for _, c := range checks {
+		status := "ok"
+		switch {
+		case !c.OK && c.Required:
+			status = "FAIL"
+		case !c.OK:
+			status = "warn"
+		}
+		fmt.Fprintf(w, "  %-4s  %-11s  %s\n", status, c.Name, c.Detail)
+		if !c.OK && c.Hint != "" {
+			fmt.Fprintf(w, "  %-4s  %-11s  %s\n", "", "", c.Hint)
+		}
+	}
+}
+
+// checkEnvironment runs the checks of Doctor that creating the project at
+// root with opts needs, and prints the failed ones if a required check
+// fails. Git is only required to fetch -template: without it, Create skips
+// git init with a warning.
+func checkEnvironment(root string, opts createOptions, out *printer) error {
+	d := &scaffold.Doctor{Root: root, Git: opts.template != "", Docker: opts.docker, SkipDocker: !opts.docker, SkipNetwork: true}
+	checks := d.Run(context.Background())
+	err := scaffold.CheckFailures(checks)
+	if err == nil {
+		return nil
+	}
+	out.Errorf("The environment is missing what the project needs:\n")
+	printChecks(out.err, err.(*scaffold.DoctorError).Checks)
+	return err
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestCheckEnvironmentGit checks that creating a project only requires
+// git to fetch -template.
+func TestCheckEnvironmentGit(t *testing.T) {
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	// A PATH with the go command and without git
+	bin := filepath.Join(t.TempDir(), "bin")
+	if err := os.Mkdir(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(goPath, filepath.Join(bin, filepath.Base(goPath))); err != nil {
+		t.Skipf("cannot link the go command: %v", err)
+	}
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		name    string
+		opts    createOptions
+		wantErr bool
+	}{
+		{"git", createOptions{git: true}, false},
+		{"no git", createOptions{}, false},
+		{"template", createOptions{git: true, template: "github.com/org/templates"}, true},
+		{"template without git", createOptions{template: "github.com/org/templates"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &printer{out: io.Discard, err: io.Discard, doc: io.Discard}
+			err := checkEnvironment(filepath.Join(t.TempDir(), "app"), tt.opts, out)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkEnvironment = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
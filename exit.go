@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+// Exit codes of gomvc.
+const (
+	exitError      = 1 // any other failure
+	exitUsage      = 2 // an unknown command, bad flags or missing arguments
+	exitFilesystem = 3 // a file or directory could not be created, read or written
+	exitCommand    = 4 // an external command such as go or git failed or is missing
+	exitValidation = 5 // an option or argument is invalid or conflicts with the project
+
+	exitInterrupted = 130 // the wizard was interrupted with Ctrl+C
+)
+
+// usageError is an error in the way gomvc was invoked, such as flags that
+// cannot be combined.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// exitCode returns the exit status gomvc ends with after err.
+func exitCode(err error) int {
+	var usage usageError
+	var validation *scaffold.ValidationError
+	var command *scaffold.CommandError
+	var path *fs.PathError
+	switch {
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.As(err, &validation):
+		return exitValidation
+	case errors.As(err, &command), errors.Is(err, scaffold.ErrVerify), errors.Is(err, exec.ErrNotFound):
+		return exitCommand
+	case errors.As(err, &path), errors.Is(err, fs.ErrPermission):
+		return exitFilesystem
+	}
+	return exitError
+}
+
+// fail prints err after prefix on standard error and exits with the exit
+// code of err.
+func fail(prefix string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", prefix, err)
+	os.Exit(exitCode(err))
+}
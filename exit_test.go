@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("boom"), exitError},
+		{usageError("-output json needs -module"), exitUsage},
+		{fmt.Errorf("new: %w", usageError("missing path")), exitUsage},
+		{&scaffold.ValidationError{Err: errors.New("unknown framework")}, exitValidation},
+		{&scaffold.CommandError{Command: "go build", Err: errors.New("exit status 1")}, exitCommand},
+		{fmt.Errorf("go: %w", exec.ErrNotFound), exitCommand},
+		{scaffold.ErrVerify, exitCommand},
+		{&fs.PathError{Op: "open", Path: "go.mod", Err: fs.ErrNotExist}, exitFilesystem},
+		{fs.ErrPermission, exitFilesystem},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestExitCodes builds gomvc and checks the exit status of failing runs of
+// it.
+func TestExitCodes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the gomvc binary")
+	}
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "gomvc")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	if out, err := exec.Command(goPath, "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// stdlib projects need no module to be downloaded
+	project := filepath.Join(dir, "app")
+	if out, err := exec.Command(bin, "new", project, "-module", "example.com/app", "-framework", "stdlib", "-git=false", "-skip-verify").CombinedOutput(); err != nil {
+		t.Fatalf("gomvc new: %v\n%s", err, out)
+	}
+	// A PATH without the go command
+	noGo := []string{"PATH=" + filepath.Join(dir, "empty")}
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		env  []string
+		args []string
+		want int
+	}{
+		{"unknown command", dir, nil, []string{"frobnicate"}, exitUsage},
+		{"new without a path", dir, nil, []string{"new", "-module", "example.com/x", "-output", "json"}, exitUsage},
+		{"generate without a generator", project, nil, []string{"generate"}, exitUsage},
+		{"path is a file", dir, nil, []string{"new", file, "-module", "example.com/x", "-git=false"}, exitValidation},
+		{"unknown framework", dir, nil, []string{"new", "x", "-module", "example.com/x", "-framework", "rails"}, exitValidation},
+		{"invalid controller name", project, nil, []string{"generate", "controller", "9bad"}, exitValidation},
+		{"unsupported field type", project, nil, []string{"generate", "model", "X", "a:foo.Bar"}, exitValidation},
+		{"invalid cron schedule", project, nil, []string{"generate", "cron", "Job", "every day"}, exitValidation},
+		{"controller exists", project, nil, []string{"generate", "controller", "Home"}, exitValidation},
+		{"duplicate route", project, nil, []string{"add", "route", "GET", "/healthz", "controller.HomeController"}, exitValidation},
+		{"go is missing", dir, noGo, []string{"new", "nogo", "-module", "example.com/nogo", "-git=false"}, exitCommand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(bin, tt.args...)
+			cmd.Dir = tt.dir
+			cmd.Env = append(os.Environ(), tt.env...)
+			out, err := cmd.CombinedOutput()
+			var exit *exec.ExitError
+			if !errors.As(err, &exit) {
+				t.Fatalf("gomvc %s: %v, want exit status %d\n%s", strings.Join(tt.args, " "), err, tt.want, out)
+			}
+			if got := exit.ExitCode(); got != tt.want {
+				t.Errorf("gomvc %s exited with %d, want %d\n%s", strings.Join(tt.args, " "), got, tt.want, out)
+			}
+		})
+	}
+}
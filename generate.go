@@ -0,0 +1,658 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+func showGenerateHelp() {
+	fmt.Println("Usage: gomvc generate <generator> [arguments]")
+	fmt.Println("\nGenerators:")
+	fmt.Println("  model <Name> [field:type ...]\tCreate models/<name>.go")
+	fmt.Println("  models -from-db <url>\t\tCreate a model per table of an existing database")
+	fmt.Println("  controller <Name> [-crud]\t\tCreate controller/<name>_controller.go")
+	fmt.Println("  resource <Name> [field:type ...]\tCreate a model and CRUD controller and register their routes")
+	fmt.Println("  dto <Name> [field:type ...]\tCreate request and response types in dto/, mapped to the model")
+	fmt.Println("  service <Name>\t\t\t\tCreate a service and repository in internal/ and pass the service to the controller")
+	fmt.Println("  middleware <Name> [-register]\t\tCreate middleware/<name>.go")
+	fmt.Println("  sse <Name> [-path /events]\t\tCreate a controller streaming Server-Sent Events and register its route")
+	fmt.Println("  upload <Name> [-max-size 10MB]\tCreate a controller storing file uploads in pkg/storage and register its route")
+	fmt.Println("  cron <Name> <schedule>\t\tCreate a task in internal/cron run on a cron schedule")
+	fmt.Println("  migration <name>\t\t\tCreate an empty up/down SQL migration pair in migrations/")
+	fmt.Println("  seeder <Name>\t\t\t\tCreate a seeder in internal/seed run by cmd/seed")
+	fmt.Println("\nRun 'gomvc generate <generator> -h' for the options of a generator.")
+}
+
+func generateCommand(args []string) {
+	if len(args) == 0 {
+		showGenerateHelp()
+		os.Exit(exitUsage)
+	}
+
+	generator, args := args[0], args[1:]
+	switch generator {
+	case "model":
+		generateModelCommand(args)
+	case "models":
+		generateModelsCommand(args)
+	case "controller":
+		generateControllerCommand(args)
+	case "resource":
+		generateResourceCommand(args)
+	case "service":
+		generateServiceCommand(args)
+	case "dto":
+		generateDTOCommand(args)
+	case "middleware":
+		generateMiddlewareCommand(args)
+	case "sse":
+		generateSSECommand(args)
+	case "upload":
+		generateUploadCommand(args)
+	case "cron":
+		generateCronCommand(args)
+	case "migration":
+		generateMigrationCommand(args)
+	case "seeder":
+		generateSeederCommand(args)
+	case "help", "-h", "-help", "--help":
+		showGenerateHelp()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown generator %q\n\n", generator)
+		showGenerateHelp()
+		os.Exit(exitUsage)
+	}
+}
+
+// openProject returns the project containing the working directory.
+func openProject(force bool) (*scaffold.Project, error) {
+	project, err := scaffold.Open(".")
+	if err != nil {
+		return nil, err
+	}
+	project.Force = force
+	project.Out = os.Stdout
+	return project, nil
+}
+
+// generateOptions holds the options of the generators, each of which uses
+// some of them.
+type generateOptions struct {
+	force, dryRun, crud, withTests, register, dto bool
+	path, maxSize, types, mocks                   string
+	fromDB, null, names, fromJSON                 string
+	plural, table, model                          string
+	backup                                        backupOptions
+}
+
+// openProject opens the project in the working directory with the -force
+// and backup flags of opts.
+func (opts *generateOptions) openProject() (*scaffold.Project, error) {
+	project, err := openProject(opts.force)
+	if err != nil {
+		return nil, err
+	}
+	project.BackupDir = opts.backup.backupDir()
+	return project, nil
+}
+
+// addNamingFlags adds -plural, and -table unless it is irrelevant to the
+// generator, to fs.
+func addNamingFlags(fs *flag.FlagSet, opts *generateOptions, table bool) {
+	fs.StringVar(&opts.plural, "plural", "", "Plural of the name, which routes are named after, if the derived one is wrong, e.g. people")
+	if table {
+		fs.StringVar(&opts.table, "table", "", "Table of the model, if not the plural, which adds a TableName method to new models")
+	}
+}
+
+// naming applies the -plural and -table overrides to project.
+func (opts *generateOptions) naming(project *scaffold.Project) {
+	project.Plural, project.Table = opts.plural, opts.table
+}
+
+// generateModelFlags returns the flags of 'gomvc generate model'.
+func generateModelFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate model", flag.ExitOnError)
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the model file if it already exists")
+	addBackupFlags(fs, &opts.backup)
+	fs.StringVar(&opts.fromJSON, "from-json", "", "JSON sample, or - for standard input, to infer the fields from instead of the arguments")
+	fs.BoolVar(&opts.crud, "crud", false, "Also generate a CRUD controller and register its routes, as 'generate resource' does")
+	addNamingFlags(fs, opts, true)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate model <Name> [field:type ...] [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate model Product name:string price:float64 created_at:time.Time")
+		fmt.Fprintln(fs.Output(), "         gomvc generate model Webhook -from-json payload.json")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateModelCommand(args []string) {
+	var opts generateOptions
+	fs := generateModelFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) < 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		if opts.fromJSON != "" {
+			if len(positional) > 1 {
+				return errors.New("fields cannot be given along with -from-json, which infers them")
+			}
+			if opts.table != "" {
+				return errors.New("-table is not supported with -from-json")
+			}
+			var sample []byte
+			var err error
+			if opts.fromJSON == "-" {
+				sample, err = io.ReadAll(os.Stdin)
+			} else {
+				sample, err = os.ReadFile(opts.fromJSON)
+			}
+			if err != nil {
+				return err
+			}
+			project, err := opts.openProject()
+			if err != nil {
+				return err
+			}
+			opts.naming(project)
+			return project.GenerateModelFromJSON(context.Background(), positional[0], sample, opts.crud)
+		}
+
+		fields, err := scaffold.ParseFields(positional[1:])
+		if err != nil {
+			return err
+		}
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		opts.naming(project)
+		if opts.crud {
+			return project.GenerateResource(context.Background(), positional[0], fields)
+		}
+		return project.GenerateModel(context.Background(), positional[0], fields)
+	}()
+	if err != nil {
+		fail("Error generating model", err)
+	}
+}
+
+// generateModelsFlags returns the flags of 'gomvc generate models'.
+func generateModelsFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate models", flag.ExitOnError)
+	fs.StringVar(&opts.fromDB, "from-db", "", "URL of the Postgres, MySQL or SQLite database to read the tables of")
+	fs.StringVar(&opts.null, "null", "pointer", "Type of nullable columns: pointer or sql (sql.NullString, ...)")
+	fs.StringVar(&opts.names, "names", "", "Comma-separated table=Name or table.column=Name overrides of the derived Go names")
+	fs.BoolVar(&opts.force, "force", false, "Also overwrite model files that were not generated from the database")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files without changing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate models -from-db <url> [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate models -from-db \"$DATABASE_URL\" -names people=Person")
+		fmt.Fprintln(fs.Output(), "\nWrites a struct per table to models/<name>.go, reading the schema with psql,")
+		fmt.Fprintln(fs.Output(), "mysql or sqlite3. Running it again regenerates the files it wrote.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateModelsCommand(args []string) {
+	var opts generateOptions
+	fs := generateModelsFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 0 || opts.fromDB == "" {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		names := make(map[string]string)
+		for _, pair := range strings.Split(opts.names, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			key, name, ok := strings.Cut(pair, "=")
+			if !ok || key == "" {
+				return &scaffold.ValidationError{Err: fmt.Errorf("invalid -names entry %q: expected table=Name or table.column=Name", pair)}
+			}
+			names[key] = name
+		}
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		return project.GenerateModelsFromDB(context.Background(), opts.fromDB, opts.null, names)
+	}()
+	if err != nil {
+		fail("Error generating models", err)
+	}
+}
+
+// generateControllerFlags returns the flags of 'gomvc generate controller'.
+func generateControllerFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate controller", flag.ExitOnError)
+	fs.BoolVar(&opts.crud, "crud", false, "Generate Index, Show, Create, Update and Delete handlers")
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the controller file if it already exists")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.withTests, "with-tests", false, "Also write a table-driven test for the controller")
+	addNamingFlags(fs, opts, false)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate controller <Name> [options]")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateControllerCommand(args []string) {
+	var opts generateOptions
+	fs := generateControllerFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		project.WithTests = opts.withTests
+		opts.naming(project)
+		return project.GenerateController(context.Background(), positional[0], opts.crud)
+	}()
+	if err != nil {
+		fail("Error generating controller", err)
+	}
+}
+
+// generateResourceFlags returns the flags of 'gomvc generate resource'.
+func generateResourceFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate resource", flag.ExitOnError)
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the model and controller files if they already exist")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files and the router diff without changing anything")
+	fs.BoolVar(&opts.dto, "dto", false, "Also write request and response types in dto/, which Create and Update bind and return instead of the model")
+	addNamingFlags(fs, opts, true)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate resource <Name> [field:type ...] [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate resource Post title:string body:string")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateResourceCommand(args []string) {
+	var opts generateOptions
+	fs := generateResourceFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) < 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		fields, err := scaffold.ParseFields(positional[1:])
+		if err != nil {
+			return err
+		}
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		project.DTO = opts.dto
+		opts.naming(project)
+		return project.GenerateResource(context.Background(), positional[0], fields)
+	}()
+	if err != nil {
+		fail("Error generating resource", err)
+	}
+}
+
+// generateDTOFlags returns the flags of 'gomvc generate dto'.
+func generateDTOFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate dto", flag.ExitOnError)
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the DTO file if it already exists")
+	addBackupFlags(fs, &opts.backup)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate dto <Name> [field:type ...] [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate dto CreateProduct name:string price:float64")
+		fmt.Fprintln(fs.Output(), "\nWrites a CreateProductRequest in dto/create_product.go with json and validate")
+		fmt.Fprintln(fs.Output(), "tags, and a ProductResponse unless dto/ declares it. When models.Product")
+		fmt.Fprintln(fs.Output(), "exists, ToModel and ProductResponseFromModel map the fields it has.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateDTOCommand(args []string) {
+	var opts generateOptions
+	fs := generateDTOFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) < 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		fields, err := scaffold.ParseFields(positional[1:])
+		if err != nil {
+			return err
+		}
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		return project.GenerateDTO(context.Background(), positional[0], fields)
+	}()
+	if err != nil {
+		fail("Error generating DTO", err)
+	}
+}
+
+// generateMiddlewareFlags returns the flags of 'gomvc generate middleware'.
+func generateMiddlewareFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate middleware", flag.ExitOnError)
+	fs.BoolVar(&opts.register, "register", false, "Register the middleware in router/router.go with a Use call")
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the middleware file if it already exists")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the file and the router diff without changing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate middleware <Name> [options]")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateMiddlewareCommand(args []string) {
+	var opts generateOptions
+	fs := generateMiddlewareFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		return project.GenerateMiddleware(context.Background(), positional[0], opts.register)
+	}()
+	if err != nil {
+		fail("Error generating middleware", err)
+	}
+}
+
+// generateSSEFlags returns the flags of 'gomvc generate sse'.
+func generateSSEFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate sse", flag.ExitOnError)
+	fs.StringVar(&opts.path, "path", "/events", "Path the events are streamed at")
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the controller and pkg/sse if they already exist")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files and the router diff without changing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate sse <Name> [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate sse Notifications")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateSSECommand(args []string) {
+	var opts generateOptions
+	fs := generateSSEFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		return project.GenerateSSE(context.Background(), positional[0], opts.path)
+	}()
+	if err != nil {
+		fail("Error generating SSE controller", err)
+	}
+}
+
+// generateUploadFlags returns the flags of 'gomvc generate upload'.
+func generateUploadFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate upload", flag.ExitOnError)
+	fs.StringVar(&opts.path, "path", "", "Path files are uploaded to (default /uploads/<name>)")
+	fs.StringVar(&opts.maxSize, "max-size", "10MB", "Size of the largest file accepted, in bytes or with a KB, MB or GB suffix")
+	fs.StringVar(&opts.types, "types", strings.Join(scaffold.DefaultUploadTypes, ","), "Comma-separated content types accepted, as sniffed from the file")
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the controller and pkg/storage if they already exist")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files and the diffs without changing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate upload <Name> [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate upload Avatar -max-size 2MB")
+		fmt.Fprintln(fs.Output(), "\nFiles are stored in uploads/, or in S3 with STORAGE_DRIVER=s3 and S3_BUCKET.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateUploadCommand(args []string) {
+	var opts generateOptions
+	fs := generateUploadFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		size, err := scaffold.ParseSize(opts.maxSize)
+		if err != nil {
+			return err
+		}
+		upload := scaffold.UploadOptions{Path: opts.path, MaxSize: size}
+		upload.Types = splitList(opts.types)
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		return project.GenerateUpload(context.Background(), positional[0], upload)
+	}()
+	if err != nil {
+		fail("Error generating upload controller", err)
+	}
+}
+
+// generateCronFlags returns the flags of 'gomvc generate cron'.
+func generateCronFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate cron", flag.ExitOnError)
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the task file if it already exists")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files and the diffs of tasks.go and main.go without changing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate cron <Name> <schedule> [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate cron CleanupSessions \"0 3 * * *\"")
+		fmt.Fprintln(fs.Output(), "\nThe schedule has five fields, minute, hour, day of month, month and day of")
+		fmt.Fprintln(fs.Output(), "week, or is a descriptor such as @daily or \"@every 15m\".")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateCronCommand(args []string) {
+	var opts generateOptions
+	fs := generateCronFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 2 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		return project.GenerateCron(context.Background(), positional[0], positional[1])
+	}()
+	if err != nil {
+		fail("Error generating cron task", err)
+	}
+}
+
+// generateMigrationFlags returns the flags of 'gomvc generate migration'.
+func generateMigrationFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate migration", flag.ExitOnError)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files without creating them")
+	fs.StringVar(&opts.model, "model", "", "Model whose table the migration creates, naming it create_<table> instead of <name>")
+	addNamingFlags(fs, opts, true)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate migration <name> [options]")
+		fmt.Fprintln(fs.Output(), "       gomvc generate migration -model <Name> [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate migration add_index_to_users")
+		fmt.Fprintln(fs.Output(), "         gomvc generate migration -model Person   # create_people")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateMigrationCommand(args []string) {
+	var opts generateOptions
+	fs := generateMigrationFlags(&opts)
+	positional := parseArgs(fs, args)
+	if opts.model == "" && len(positional) != 1 || opts.model != "" && len(positional) != 0 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := openProject(false)
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		opts.naming(project)
+		if opts.model != "" {
+			return project.GenerateTableMigration(context.Background(), opts.model)
+		}
+		return project.GenerateMigration(context.Background(), positional[0])
+	}()
+	if err != nil {
+		fail("Error generating migration", err)
+	}
+}
+
+// generateServiceFlags returns the flags of 'gomvc generate service'.
+func generateServiceFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate service", flag.ExitOnError)
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the service and repository files if they already exist")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files and the diffs of the controller and its callers without changing anything")
+	fs.StringVar(&opts.mocks, "mocks", scaffold.DefaultMockTool, "Tool generating the mocks of the interfaces into mocks/ ("+strings.Join(scaffold.MockTools(), ", ")+")")
+	addNamingFlags(fs, opts, true)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate service <Name> [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate service User")
+		fmt.Fprintln(fs.Output(), "\nWrites a <Name>Service in internal/service and a <Name>Repository with an")
+		fmt.Fprintln(fs.Output(), "in-memory implementation in internal/repository, and passes the service to")
+		fmt.Fprintln(fs.Output(), "the constructor of the controller, or of the handler in the clean layout.")
+		fmt.Fprintln(fs.Output(), "Mocks of both interfaces are generated into mocks/ and, with a new")
+		fmt.Fprintln(fs.Output(), "controller, used by its test of Show; \"make mocks\" regenerates them.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateServiceCommand(args []string) {
+	var opts generateOptions
+	fs := generateServiceFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		project.Mocks = opts.mocks
+		opts.naming(project)
+		return project.GenerateService(context.Background(), positional[0])
+	}()
+	if err != nil {
+		fail("Error generating service", err)
+	}
+}
+
+// generateSeederFlags returns the flags of 'gomvc generate seeder'.
+func generateSeederFlags(opts *generateOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("generate seeder", flag.ExitOnError)
+	fs.BoolVar(&opts.force, "force", false, "Overwrite the seeder file if it already exists")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the file and the diff of seeders.go without changing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc generate seeder <Name> [options]")
+		fmt.Fprintln(fs.Output(), "\nExample: gomvc generate seeder Product")
+		fmt.Fprintln(fs.Output(), "\nWrites a ProductSeeder in internal/seed/product_seeder.go and lists it in")
+		fmt.Fprintln(fs.Output(), "internal/seed/seeders.go, so that \"make seed\" runs it after the others.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func generateSeederCommand(args []string) {
+	var opts generateOptions
+	fs := generateSeederFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := opts.openProject()
+		if err != nil {
+			return err
+		}
+		project.DryRun = opts.dryRun
+		return project.GenerateSeeder(context.Background(), positional[0])
+	}()
+	if err != nil {
+		fail("Error generating seeder", err)
+	}
+}
@@ -1,3 +1,12 @@
 module github.com/AlexCrominus/gomvc
 
 go 1.22.2
+
+require (
+	golang.org/x/mod v0.17.0
+	golang.org/x/term v0.20.0
+	golang.org/x/tools v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.20.0 // indirect
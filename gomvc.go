@@ -2,240 +2,735 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+	"golang.org/x/term"
 )
 
 var (
-	createFlag = flag.String("create", "", "Create the MVC structure at the specified path")
-	deleteFlag = flag.String("delete", "", "Delete the MVC structure at the specified path")
-	helpFlag   = flag.Bool("h", false, "Show help")
+	createFlag  = flag.String("create", "", "Create the MVC structure at the specified path")
+	deleteFlag  = flag.String("delete", "", "Delete the MVC structure at the specified path")
+	moduleFlag  = flag.String("module", "", "Go module path for the new project (skips the interactive prompt)")
+	helpFlag    = flag.Bool("h", false, "Show help")
+	versionFlag = flag.Bool("version", false, "Print the version of gomvc")
 )
 
-func createDir(path string) error {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return os.MkdirAll(path, os.ModePerm)
-	}
-	return nil
+// stdinIsTerminal reports whether standard input is attached to a terminal.
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
 }
 
-func createFile(path, content string) error {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		file, err := os.Create(path)
+// promptModulePath asks the user for the module path on standard input,
+// until they enter a valid one. Pressing Enter picks defaultPath.
+func promptModulePath(defaultPath string) (string, error) {
+	if !stdinIsTerminal() {
+		return "", usageError("no module path given: use -module when stdin is not a terminal")
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Enter the project name for Go module initialization (e.g., github.com/username/project) [%s]: ", defaultPath)
+		line, err := reader.ReadString('\n')
 		if err != nil {
-			return err
+			return "", err
 		}
-		defer file.Close()
-
-		_, err = file.WriteString(content)
-		return err
+		projectName := scaffold.CleanModulePath(line)
+		if projectName == "" {
+			return defaultPath, nil
+		}
+		if err := scaffold.ValidateModulePath(projectName); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid module path: %v\n", err)
+			continue
+		}
+		return projectName, nil
 	}
-	return nil
 }
 
-func setupMVC(rootPath string) error {
-	// Prompt for project name for go mod init
-	fmt.Print("Enter the project name for Go module initialization (e.g., github.com/username/project): ")
-	reader := bufio.NewReader(os.Stdin)
-	projectName, err := reader.ReadString('\n')
-	if err != nil {
-		return err
-	}
-	projectName = strings.TrimSpace(projectName)
+// createOptions holds the options of 'gomvc new'.
+type createOptions struct {
+	module         string
+	framework      string
+	layout         string
+	mode           string
+	api            string
+	css            string
+	database       string
+	orm            string
+	auth           string
+	config         string
+	apiPrefix      string
+	port           string
+	swagger        bool
+	metrics        bool
+	otel           bool
+	ws             bool
+	worker         bool
+	cache          string
+	messaging      string
+	mailer         bool
+	validation     bool
+	rateLimit      bool
+	rateLimitScope string
+	tls            bool
+	versionPkg     bool
+	middleware     string
+	grpc           bool
+	grpcIgnoreGen  bool
+	di             string
+	docker         bool
+	ci             string
+	noDevTools     bool
+	lineEndings    string
+	git            bool
+	templatesDir   string
+	template       string
+	templateUpdate bool
+	withTests      bool
+	integration    bool
+	openAPI        string
+	dryRun         bool
+	verbose        bool
+	skipVerify     bool
+	offline        bool
+	keepOnFailure  bool
+	intoExisting   bool
+	force          bool
+	interactive    bool
+	quiet          bool
+	output         string
+	preset         string
+	backup         backupOptions
+}
 
-	// Initialize Go module
-	cmd := exec.Command("go", "mod", "init", projectName)
-	cmd.Dir = rootPath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to initialize go module: %v", err)
-	}
-	fmt.Printf("Initialized Go module: %s\n", projectName)
+// backupOptions holds -backup-dir and -no-backup, of the commands that
+// delete or overwrite files.
+type backupOptions struct {
+	dir  string
+	none bool
+}
+
+// addBackupFlags adds -backup-dir and -no-backup to fs, parsed into opts.
+func addBackupFlags(fs *flag.FlagSet, opts *backupOptions) {
+	fs.StringVar(&opts.dir, "backup-dir", "", "Directory to write the tar.gz backup of the files deleted or overwritten to (default the system temp dir)")
+	fs.BoolVar(&opts.none, "no-backup", false, "Delete and overwrite files without backing them up first, e.g. in CI")
+}
 
-	dirs := []string{
-		filepath.Join(rootPath, "cmd/api"),
-		filepath.Join(rootPath, "controller"),
-		filepath.Join(rootPath, "models"),
-		filepath.Join(rootPath, "pkg"),
-		filepath.Join(rootPath, "config"),
-		filepath.Join(rootPath, "views"),
-		filepath.Join(rootPath, "router"),
-		filepath.Join(rootPath, "middleware"),
+// backupDir returns the directory backups go to, or "" for none.
+func (opts backupOptions) backupDir() string {
+	switch {
+	case opts.none:
+		return ""
+	case opts.dir != "":
+		return opts.dir
 	}
+	return os.TempDir()
+}
 
-	for _, dir := range dirs {
-		if err := createDir(dir); err != nil {
+// validateCreateOptions checks the values of the options of 'gomvc new'
+// before the module path is asked for.
+func validateCreateOptions(opts createOptions) error {
+	if opts.apiPrefix != "" {
+		if err := scaffold.ValidateAPIPrefix(opts.apiPrefix); err != nil {
 			return err
 		}
 	}
-
-	// Generate main.go with dynamic import path
-	mainGoContent := fmt.Sprintf(`package main
-
-import (
-	"fmt"
-	"github.com/gin-gonic/gin"
-	"%s/router"
-)
-
-func main() {
-	fmt.Println("Starting the Gin server...")
-	r := gin.Default()
-	router.InitializeRoutes(r)
-	r.Run(":8080")
+	if opts.port != "" {
+		if err := scaffold.ValidatePort(opts.port); err != nil {
+			return err
+		}
+	}
+	return projectOptions(opts).CheckOptions()
 }
-`, projectName)
-	if err := createFile(filepath.Join(rootPath, "cmd/api", "main.go"), mainGoContent); err != nil {
-		return err
+
+// projectOptions returns a project with the options of opts, which the
+// caller completes with its path and module.
+func projectOptions(opts createOptions) *scaffold.Project {
+	return &scaffold.Project{
+		Framework:        opts.framework,
+		Layout:           opts.layout,
+		Mode:             opts.mode,
+		API:              opts.api,
+		CSS:              opts.css,
+		Database:         opts.database,
+		ORM:              opts.orm,
+		Auth:             opts.auth,
+		Config:           opts.config,
+		APIPrefix:        opts.apiPrefix,
+		Port:             opts.port,
+		Swagger:          opts.swagger,
+		Metrics:          opts.metrics,
+		Tracing:          opts.otel,
+		WebSocket:        opts.ws,
+		Worker:           opts.worker,
+		Cache:            opts.cache,
+		Messaging:        opts.messaging,
+		Mailer:           opts.mailer,
+		Validation:       opts.validation,
+		RateLimit:        opts.rateLimit,
+		RateLimitScope:   opts.rateLimitScope,
+		TLS:              opts.tls,
+		VersionPkg:       opts.versionPkg,
+		Middleware:       splitList(opts.middleware),
+		GRPC:             opts.grpc,
+		GRPCIgnoreGen:    opts.grpcIgnoreGen,
+		DI:               opts.di,
+		Docker:           opts.docker,
+		CI:               opts.ci,
+		DevTools:         !opts.noDevTools,
+		LineEndings:      opts.lineEndings,
+		Git:              opts.git,
+		WithTests:        opts.withTests,
+		IntegrationTests: opts.integration,
+		OpenAPI:          opts.openAPI,
+		DryRun:           opts.dryRun,
+		Verbose:          opts.verbose,
+		SkipVerify:       opts.skipVerify,
+		Offline:          opts.offline,
+		KeepOnFailure:    opts.keepOnFailure,
+		IntoExisting:     opts.intoExisting,
+		Force:            opts.force,
+		BackupDir:        opts.backup.backupDir(),
 	}
+}
 
-	// Controller: sample controller with a function that handles a request
-	controllerContent := `package controller
+func setupMVC(rootPath string, opts createOptions, out *printer, report *scaffold.Report) error {
+	if opts.verbose && opts.quiet {
+		return usageError("-v and -q cannot be used together")
+	}
+	if opts.template != "" && opts.templatesDir != "" {
+		return usageError("-template and -templates cannot be used together")
+	}
+	if opts.templateUpdate && opts.template == "" {
+		return usageError("-template-update needs -template")
+	}
+	switch opts.output {
+	case "", outputText:
+	case outputJSON:
+		// Nothing may be asked on standard output
+		if opts.module == "" && !opts.intoExisting {
+			return usageError("-output json needs -module or -into-existing")
+		}
+		if opts.interactive {
+			return usageError("-output json cannot be used with -interactive")
+		}
+	default:
+		return &scaffold.ValidationError{Err: fmt.Errorf("unknown output format %q (supported: %s, %s)", opts.output, outputText, outputJSON)}
+	}
+	if err := validateCreateOptions(opts); err != nil {
+		return &scaffold.ValidationError{Err: err}
+	}
+	// A file in the way would otherwise be reported as an unwritable
+	// destination by the environment checks
+	if info, err := os.Stat(rootPath); err == nil && !info.IsDir() {
+		return &scaffold.ValidationError{Err: fmt.Errorf("%s exists and is not a directory", rootPath)}
+	}
+	if !opts.dryRun {
+		if err := checkEnvironment(rootPath, opts, out); err != nil {
+			return err
+		}
+	}
 
-import (
-	"net/http"
-	"github.com/gin-gonic/gin"
-)
+	// Prompt for project name for go mod init unless it was given with
+	// -module, or comes from the existing go.mod with -into-existing
+	projectName := scaffold.CleanModulePath(opts.module)
+	if projectName != "" {
+		if err := scaffold.ValidateModulePath(projectName); err != nil {
+			return &scaffold.ValidationError{Err: err}
+		}
+	} else if !opts.intoExisting {
+		// Refuse before asking for a module path that cannot be used
+		if goMod := scaffold.FindGoMod(rootPath); goMod != "" {
+			return &scaffold.ValidationError{Err: fmt.Errorf("%w declared in %s", scaffold.ErrExistingModule, goMod)}
+		}
+		var err error
+		if projectName, err = promptModulePath(scaffold.DefaultModulePath(rootPath)); err != nil {
+			return err
+		}
+	}
 
-// HomeController handles requests for the home route
-func HomeController(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Hello from HomeController!"})
-}
-`
-	if err := createFile(filepath.Join(rootPath, "controller", "home_controller.go"), controllerContent); err != nil {
-		return err
+	gomvcVersion, _, _ := buildVersion()
+	project := projectOptions(opts)
+	project.Root, project.Module, project.GomvcVersion = rootPath, projectName, gomvcVersion
+	project.Report, project.Out = report, out.Out()
+	if opts.interactive {
+		if !stdinIsTerminal() {
+			return usageError("-interactive needs a terminal to ask on")
+		}
+		project.ResolveConflict = conflictPrompter()
+	}
+	if opts.templatesDir != "" {
+		info, err := os.Stat(opts.templatesDir)
+		if err != nil {
+			return fmt.Errorf("invalid templates directory: %v", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid templates directory: %s is not a directory", opts.templatesDir)
+		}
+		project.Templates = os.DirFS(opts.templatesDir)
 	}
+	if opts.template != "" {
+		remote := &scaffold.RemoteTemplates{Source: opts.template, Update: opts.templateUpdate, Out: out.Out()}
+		templates, m, err := remote.Fetch(context.Background())
+		if err != nil {
+			return err
+		}
+		out.Printf("Using the templates %s\n", m.Name)
+		project.Templates = templates
+	}
+	return project.Create(context.Background())
+}
 
-	// Models: defining a sample struct for data
-	modelContent := `package models
+// deleteMVC removes the MVC structure at rootPath. Unless yes is set the
+// user has to confirm the deletion first. layout picks the directories and
+// files removed by force when the project has no manifest. What is removed
+// is backed up into backupDir first, unless it is empty.
+func deleteMVC(rootPath, layout, backupDir string, force, yes, dryRun bool) error {
+	if layout != "" {
+		if err := scaffold.ValidateLayout(layout); err != nil {
+			return err
+		}
+	}
+	project := &scaffold.Project{
+		Root:      rootPath,
+		Layout:    layout,
+		DryRun:    dryRun,
+		Force:     force,
+		BackupDir: backupDir,
+		Out:       os.Stdout,
+	}
+	if !yes {
+		project.ConfirmDestroy = confirmDelete
+	}
 
-// User represents a sample user model
-type User struct {
-	ID    int    ` + "`json:\"id\"`" + `
-	Name  string ` + "`json:\"name\"`" + `
-	Email string ` + "`json:\"email\"`" + `
+	err := project.Destroy(context.Background())
+	if errors.Is(err, scaffold.ErrNoManifest) {
+		return fmt.Errorf("%v: refusing to delete files gomvc may not have created (use 'gomvc destroy -force' to remove the standard directories anyway)", err)
+	}
+	return err
 }
-`
-	if err := createFile(filepath.Join(rootPath, "models", "user.go"), modelContent); err != nil {
+
+// confirmDelete lists what is about to be removed and asks the user to type
+// "yes". It fails when stdin is not a terminal.
+func confirmDelete(plan scaffold.DestroyPlan) error {
+	rootPath := plan.Root
+	absPath, err := filepath.Abs(rootPath)
+	if err != nil {
 		return err
 	}
 
-	// Utility: generic utility function
-	pkgContent := `package pkg
+	fmt.Printf("The following will be removed from %s:\n", absPath)
+	deletesGoMod := false
+	for _, file := range plan.Files {
+		if _, err := os.Stat(filepath.Join(rootPath, filepath.FromSlash(file))); err != nil {
+			continue
+		}
+		fmt.Printf("  %s\n", file)
+		deletesGoMod = deletesGoMod || file == "go.mod"
+	}
+	for _, dir := range plan.Dirs {
+		if _, err := os.Stat(filepath.Join(rootPath, filepath.FromSlash(dir))); err != nil {
+			continue
+		}
+		if plan.FromManifest {
+			fmt.Printf("  %s/ (if empty)\n", dir)
+		} else {
+			fmt.Printf("  %s/ (and everything in it)\n", dir)
+		}
+	}
+	if deletesGoMod {
+		fmt.Println("WARNING: go.mod will be deleted.")
+	}
 
-import "fmt"
+	if !stdinIsTerminal() {
+		return fmt.Errorf("refusing to delete without confirmation: stdin is not a terminal (use -yes to skip the prompt)")
+	}
 
-// PrintMessage prints a message to the console
-func PrintMessage(msg string) {
-	fmt.Println(msg)
-}
-`
-	if err := createFile(filepath.Join(rootPath, "pkg", "utility.go"), pkgContent); err != nil {
+	fmt.Print("Type \"yes\" to continue: ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
 		return err
 	}
+	if strings.TrimSpace(answer) != "yes" {
+		return fmt.Errorf("aborted")
+	}
+	return nil
+}
 
-	// Router: sets up routes and includes middleware
-	routerContent := fmt.Sprintf(`package router
-
-import (
-	"github.com/gin-gonic/gin"
-	"%s/controller"
-	"%s/middleware"
-)
-
-// InitializeRoutes sets up the application's routes
-func InitializeRoutes(r *gin.Engine) {
-	r.Use(middleware.RequestLogger())
+// conflictPrompter returns a scaffold.Project.ResolveConflict that asks
+// whether to overwrite each file, showing its diff on request, until the
+// user answers "a" to overwrite all the rest.
+func conflictPrompter() func(c scaffold.Conflict) (bool, error) {
+	reader := bufio.NewReader(os.Stdin)
+	all := false
+	return func(c scaffold.Conflict) (bool, error) {
+		if all {
+			return true, nil
+		}
+		for {
+			fmt.Printf("%s exists and differs from the generated file. Overwrite? [y/N/a(ll)/d(iff)] ", c.Path)
+			answer, err := reader.ReadString('\n')
+			if err != nil {
+				return false, err
+			}
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "y", "yes":
+				return true, nil
+			case "a", "all":
+				all = true
+				return true, nil
+			case "d", "diff":
+				fmt.Print(c.Diff())
+			case "", "n", "no":
+				return false, nil
+			}
+		}
+	}
+}
 
-	r.GET("/", controller.HomeController)
+func showHelp() {
+	fmt.Println("Usage: gomvc <command> [arguments]")
+	fmt.Println("\nRun without arguments, or 'gomvc new' without a path, to answer questions")
+	fmt.Println("about the project to create instead.")
+	fmt.Println("\nCommands:")
+	fmt.Println("  new <path>\t\tCreate the MVC structure at the specified path")
+	fmt.Println("  destroy <path>\tDelete the MVC structure at the specified path")
+	fmt.Println("  generate <generator>\tAdd code to the project in the working directory")
+	fmt.Println("  add route <args>\tRegister a route and a stub of its handler in the project")
+	fmt.Println("  remove <what> <name>\tRemove a resource or feature gomvc generated from the project")
+	fmt.Println("  restore <args>\t\tUnpack a backup written before files were deleted or overwritten")
+	fmt.Println("  routes [path]\t\tList the routes a project registers, with their middleware")
+	fmt.Println("  rename-module <args>\tChange the module path of a project and its imports")
+	fmt.Println("  upgrade <path>\t\tUpdate the generated files of a project to this version")
+	fmt.Println("  preset init [file]\tWrite the defaults of 'gomvc new' as a preset to start from")
+	fmt.Println("  doctor [path]\t\tCheck the environment has what new needs")
+	fmt.Println("  list\t\t\tList the frameworks, layouts and features of new")
+	fmt.Println("  version\t\tPrint the version of gomvc")
+	fmt.Println("  completion <shell>\tPrint a completion script for bash, zsh or fish")
+	fmt.Println("  help\t\t\tShow this help message")
+	fmt.Println("\nRun 'gomvc <command> -h' for the options of a command, and")
+	fmt.Println("'gomvc completion -h' for how to install the completion scripts.")
+	fmt.Println("\nDeprecated options:")
+	fmt.Println("  -create <path>\tSame as 'gomvc new <path>'")
+	fmt.Println("  -delete <path>\tSame as 'gomvc destroy <path>'")
+	fmt.Println("  -module <path>\tGo module path for -create (skips the interactive prompt)")
+	fmt.Println("  -h\t\t\tShow this help message")
+	fmt.Println("  -version\t\tSame as 'gomvc version'")
 }
-`, projectName, projectName)
-	if err := createFile(filepath.Join(rootPath, "router", "router.go"), routerContent); err != nil {
-		return err
+
+// parseArgs parses args with fs, allowing flags to appear before or after
+// positional arguments, and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		// Errors are handled by fs itself, which is created with flag.ExitOnError
+		_ = fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
 	}
+}
 
-	// Middleware: request logger as an example
-	middlewareContent := `package middleware
+// createResult is the document 'gomvc new -output json' prints.
+type createResult struct {
+	// Status is "ok" or "error".
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	DryRun bool   `json:"dry_run,omitempty"`
+	*scaffold.Report
+}
 
-import (
-	"fmt"
-	"time"
+func runCreate(rootPath string, opts createOptions) {
+	out := newPrinter(opts.output, opts.quiet)
+	report := &scaffold.Report{Files: []scaffold.ReportFile{}, Commands: []string{}, Warnings: []string{}}
+	out.Println("Creating MVC structure...")
+	if opts.preset != "" {
+		out.Printf("Using the options of the preset %s\n", opts.preset)
+	}
+	err := setupMVC(rootPath, opts, out, report)
+	if opts.output == outputJSON {
+		result := createResult{Status: "ok", DryRun: opts.dryRun, Report: report}
+		if err != nil {
+			result.Status, result.Error = "error", err.Error()
+		}
+		out.JSON(result)
+	}
+	if err != nil {
+		out.Errorf("Error setting up MVC structure: %v\n", err)
+		if errors.Is(err, scaffold.ErrExistingModule) {
+			out.Errorf("Pass -into-existing to add the gomvc layout to that module instead of creating a new one.\n")
+		}
+		if errors.Is(err, scaffold.ErrVerify) {
+			out.Errorf("Pass -keep-on-failure to inspect the generated files, -skip-verify to skip this check, or -offline to generate without the network.\n")
+		}
+		os.Exit(exitCode(err))
+	} else if opts.dryRun {
+		out.Println("Dry run complete, nothing was created.")
+	} else {
+		out.Println("MVC structure created successfully!")
+		printNextSteps(out, rootPath, opts.layout)
+	}
+}
 
-	"github.com/gin-gonic/gin"
-)
+// printNextSteps tells the user how to run the new project at rootPath.
+func printNextSteps(out *printer, rootPath, layout string) {
+	out.Println("\nNext steps:")
+	if abs, err := filepath.Abs(rootPath); err == nil {
+		if wd, err := os.Getwd(); err != nil || wd != abs {
+			dir := rootPath
+			if strings.ContainsAny(dir, " \t'\"") {
+				dir = strconv.Quote(dir)
+			}
+			out.Printf("  cd %s\n", dir)
+		}
+	}
+	if layout == "minimal" {
+		out.Println("  go run .")
+	} else {
+		out.Println("  make run")
+	}
+}
 
-// RequestLogger logs each request with method, path, and duration
-func RequestLogger() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		startTime := time.Now()
-		c.Next()
-		duration := time.Since(startTime)
-		fmt.Printf("[%s] %s %s %v\n", time.Now().Format(time.RFC3339), c.Request.Method, c.Request.URL.Path, duration)
+func runDelete(rootPath, layout, backupDir string, force, yes, dryRun bool) {
+	fmt.Println("Deleting MVC structure...")
+	if err := deleteMVC(rootPath, layout, backupDir, force, yes, dryRun); err != nil {
+		fail("Error deleting MVC structure", err)
+	} else if dryRun {
+		fmt.Println("Dry run complete, nothing was deleted.")
+	} else {
+		fmt.Println("MVC structure deleted successfully!")
 	}
 }
-`
-	if err := createFile(filepath.Join(rootPath, "middleware", "request_logger.go"), middlewareContent); err != nil {
-		return err
+
+// splitList returns the items of a comma-separated list, without spaces
+// and empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
 
-	return nil
+// ormUsage lists the ORMs supported with each database for the -orm flag.
+func ormUsage() string {
+	var parts []string
+	for _, db := range scaffold.Databases() {
+		parts = append(parts, db+": "+strings.Join(scaffold.ORMs(db), ", "))
+	}
+	return strings.Join(parts, "; ")
 }
 
-func deleteMVC(rootPath string) error {
-	dirs := []string{"cmd", "controller", "models", "pkg", "config", "views", "router", "middleware"}
+// newFlags returns the flags of 'gomvc new', parsed into opts.
+func newFlags(opts *createOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	fs.StringVar(&opts.module, "module", "", "Go module path for the new project (skips the interactive prompt)")
+	fs.StringVar(&opts.framework, "framework", "gin", "Web framework to generate the project for ("+strings.Join(scaffold.Frameworks(), ", ")+")")
+	fs.StringVar(&opts.layout, "layout", scaffold.DefaultLayout, "How the project's packages are arranged ("+strings.Join(scaffold.Layouts(), ", ")+")")
+	fs.StringVar(&opts.mode, "mode", scaffold.DefaultMode, "What the project serves: a JSON API, or HTML pages and static files too, optionally with htmx ("+strings.Join(scaffold.Modes(), ", ")+")")
+	fs.StringVar(&opts.api, "api", scaffold.DefaultAPI, "Style of the API: REST routes only, or a GraphQL API generated by gqlgen as well ("+strings.Join(scaffold.APIStyles(), ", ")+")")
+	fs.StringVar(&opts.css, "css", scaffold.DefaultCSS, "How the pages of -mode web or htmx are styled: a plain stylesheet, or one built with Tailwind CSS ("+strings.Join(scaffold.CSSSetups(), ", ")+")")
+	fs.StringVar(&opts.database, "db", "", "Database to wire into the project ("+strings.Join(scaffold.Databases(), ", ")+")")
+	fs.StringVar(&opts.orm, "orm", "", "Library used to access the -db database ("+ormUsage()+")")
+	fs.StringVar(&opts.auth, "auth", "", "Authentication to generate, with register and login routes ("+strings.Join(scaffold.AuthSchemes(), ", ")+")")
+	fs.StringVar(&opts.config, "config", "env", "How the project reads its settings ("+strings.Join(scaffold.Configs(), ", ")+")")
+	fs.StringVar(&opts.apiPrefix, "api-prefix", scaffold.DefaultAPIPrefix, "Path the versioned API is served below, e.g. /api for /api/v1")
+	fs.StringVar(&opts.port, "port", scaffold.DefaultPort, "Port the server listens on unless PORT says otherwise, also used by the Dockerfile and docker-compose.yml")
+	fs.BoolVar(&opts.swagger, "swagger", false, "Annotate the controllers for swag and serve the Swagger UI at /swagger/")
+	fs.BoolVar(&opts.metrics, "metrics", false, "Record Prometheus request metrics and serve them at /metrics")
+	fs.BoolVar(&opts.otel, "otel", false, "Trace requests with OpenTelemetry and export the spans over OTLP")
+	fs.BoolVar(&opts.ws, "ws", false, "Serve WebSocket clients at /ws with a hub broadcasting their messages")
+	fs.BoolVar(&opts.worker, "worker", false, "Run background jobs queued in memory or Redis, with cmd/worker and a sample email job")
+	fs.StringVar(&opts.cache, "cache", "", "Cache responses in a shared cache, or in memory in development ("+strings.Join(scaffold.Caches(), ", ")+")")
+	fs.StringVar(&opts.messaging, "messaging", "", "Publish events to a message broker, with a sample consumer and a controller publishing them ("+strings.Join(scaffold.MessagingBrokers(), ", ")+")")
+	fs.BoolVar(&opts.mailer, "mailer", false, "Send emails over SMTP, or log them in development, with pkg/mailer and a welcome email on register with -auth")
+	fs.BoolVar(&opts.validation, "validation", false, "Validate requests with go-playground/validator in pkg/validate, with pkg/apierror and a sample POST /users")
+	fs.BoolVar(&opts.rateLimit, "ratelimit", false, "Limit the requests of each client IP with a token bucket, kept in Redis with -cache")
+	fs.StringVar(&opts.rateLimitScope, "ratelimit-scope", scaffold.DefaultRateLimitScope, "Routes -ratelimit limits: every route, or only the versioned API ("+strings.Join(scaffold.RateLimitScopes(), ", ")+")")
+	fs.BoolVar(&opts.tls, "tls", false, "Serve HTTPS when the config names a certificate, or gets one from Let's Encrypt with autocert")
+	fs.StringVar(&opts.middleware, "middleware", "", "Comma-separated middleware to generate into middleware/ and register for every route, e.g. Audit,RequestTimer")
+	fs.BoolVar(&opts.versionPkg, "version-pkg", false, "Add pkg/version, whose version, commit and date \"make build\" stamps")
+	fs.BoolVar(&opts.grpc, "grpc", false, "Serve a sample gRPC service defined in proto/ on a second port")
+	fs.BoolVar(&opts.grpcIgnoreGen, "grpc-ignore-gen", false, "Keep the generated gRPC code in gen/ out of git; make proto regenerates it")
+	fs.StringVar(&opts.di, "di", "", "Compose the server in internal/app instead of main.go, by hand or with Wire ("+strings.Join(scaffold.DIModes(), ", ")+")")
+	fs.BoolVar(&opts.docker, "docker", false, "Add a Dockerfile and a docker-compose.yml running the project with its database")
+	fs.StringVar(&opts.ci, "ci", "", "CI service to add a pipeline for ("+strings.Join(scaffold.CIProviders(), ", ")+")")
+	fs.BoolVar(&opts.noDevTools, "no-dev-tools", false, "Skip the .air.toml and make dev target for live reloading")
+	fs.StringVar(&opts.lineEndings, "line-endings", scaffold.DefaultLineEndings, "Line endings of the files written, those of the OS by default; shell scripts always get LF ("+strings.Join(scaffold.LineEndings(), ", ")+")")
+	fs.BoolVar(&opts.git, "git", true, "Run git init and commit the generated files (-git=false to skip)")
+	fs.StringVar(&opts.templatesDir, "templates", "", "Directory of templates that override or extend the built-in ones")
+	fs.StringVar(&opts.template, "template", "", "Git repository of templates like -templates, optionally with @ and a tag, branch or commit, e.g. github.com/org/templates@v1.2.0")
+	fs.BoolVar(&opts.templateUpdate, "template-update", false, "Fetch the -template repository again instead of using the cached copy")
+	fs.BoolVar(&opts.withTests, "with-tests", false, "Also write a test for the home controller")
+	fs.BoolVar(&opts.integration, "with-integration-tests", false, "Add integration tests in tests/ running the whole application, and a make test-integration target")
+	fs.StringVar(&opts.openAPI, "from-openapi", "", "OpenAPI 3 document, in JSON or YAML, to generate the models, controllers and routes of the API from")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print what would be created without touching the filesystem")
+	fs.BoolVar(&opts.verbose, "v", false, "Print every directory created, file written and command run, such as go mod init, with its output")
+	fs.BoolVar(&opts.quiet, "q", false, "Print nothing but errors")
+	fs.StringVar(&opts.output, "output", outputText, "Format of the result: text, or a JSON document on stdout describing the project, with the messages on stderr (text, json)")
+	fs.BoolVar(&opts.skipVerify, "skip-verify", false, "Skip the go mod tidy and go build ./... checking that the project builds")
+	fs.BoolVar(&opts.offline, "offline", false, "Generate without the network: skip adding the dependencies to go.mod, and -skip-verify; run go mod tidy once online")
+	fs.BoolVar(&opts.force, "force", false, "Overwrite existing files that differ from the generated ones instead of skipping them")
+	addBackupFlags(fs, &opts.backup)
+	fs.BoolVar(&opts.interactive, "interactive", false, "Ask whether to overwrite each existing file that differs from the generated one, showing its diff on request")
+	fs.BoolVar(&opts.intoExisting, "into-existing", false, "Generate into the Go module the path is in, importing the packages with the path of its go.mod, instead of running go mod init")
+	fs.StringVar(&opts.preset, "preset", "", "YAML file of options to create the project with, overridden by flags (default "+defaultPresetPath()+" if it exists)")
+	fs.BoolVar(&opts.keepOnFailure, "keep-on-failure", false, "Keep the files created so far when generation fails instead of removing them, e.g. to debug templates")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc new <path> [options]")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
 
-	for _, dir := range dirs {
-		if err := os.RemoveAll(filepath.Join(rootPath, dir)); err != nil {
-			return err
+func newCommand(args []string) {
+	var opts createOptions
+	fs := newFlags(&opts)
+	paths := parseArgs(fs, args)
+	if opts.preset = findPreset(opts.preset); opts.preset != "" {
+		if err := applyPreset(fs, opts.preset); err != nil {
+			fail("Error applying preset", &scaffold.ValidationError{Err: err})
 		}
 	}
-
-	// Remove go.mod if it exists
-	goModPath := filepath.Join(rootPath, "go.mod")
-	if _, err := os.Stat(goModPath); err == nil {
-		if err := os.Remove(goModPath); err != nil {
-			return fmt.Errorf("failed to delete go.mod: %v", err)
+	if len(paths) == 0 && opts.output != outputJSON && stdinIsTerminal() {
+		path, err := runWizard(fs, &opts)
+		if errors.Is(err, errAborted) {
+			fmt.Fprintln(os.Stderr, "Aborted; nothing was written.")
+			os.Exit(exitError)
+		}
+		if err != nil {
+			fail("Error", err)
 		}
-		fmt.Println("Deleted go.mod file.")
+		paths = []string{path}
 	}
+	if len(paths) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	runCreate(paths[0], opts)
+}
 
-	return nil
+// destroyOptions holds the options of 'gomvc destroy'.
+type destroyOptions struct {
+	force, yes, dryRun bool
+	layout             string
+	backup             backupOptions
 }
 
-func showHelp() {
-	fmt.Println("Usage: gomvc [OPTIONS]")
-	fmt.Println("\nOptions:")
-	fmt.Println("  -create <path>\tCreate the MVC structure at the specified path")
-	fmt.Println("  -delete <path>\tDelete the MVC structure at the specified path")
-	fmt.Println("  -h\t\t\tShow this help message")
+// destroyFlags returns the flags of 'gomvc destroy', parsed into opts.
+func destroyFlags(opts *destroyOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("destroy", flag.ExitOnError)
+	fs.BoolVar(&opts.force, "force", false, "Remove the standard gomvc directories even when no manifest is found")
+	fs.StringVar(&opts.layout, "layout", scaffold.DefaultLayout, "Layout whose directories and files -force removes ("+strings.Join(scaffold.Layouts(), ", ")+")")
+	fs.BoolVar(&opts.yes, "yes", false, "Delete without asking for confirmation")
+	fs.BoolVar(&opts.yes, "y", false, "Shorthand for -yes")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print what would be deleted without touching the filesystem")
+	addBackupFlags(fs, &opts.backup)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc destroy <path> [options]")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
 }
 
-func main() {
+func destroyCommand(args []string) {
+	var opts destroyOptions
+	fs := destroyFlags(&opts)
+	paths := parseArgs(fs, args)
+	if len(paths) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	runDelete(paths[0], opts.layout, opts.backup.backupDir(), opts.force, opts.yes, opts.dryRun)
+}
+
+// legacyMain handles the deprecated -create/-delete flag interface.
+func legacyMain() {
 	flag.Parse()
 
 	if *helpFlag {
 		showHelp()
 		return
 	}
+	if *versionFlag {
+		versionCommand()
+		return
+	}
 
 	if *createFlag != "" {
-		fmt.Println("Creating MVC structure...")
-		if err := setupMVC(*createFlag); err != nil {
-			fmt.Printf("Error setting up MVC structure: %v\n", err)
-		} else {
-			fmt.Println("MVC structure created successfully!")
-		}
+		fmt.Fprintln(os.Stderr, "Warning: -create is deprecated and will be removed in a future release; use 'gomvc new <path>' instead.")
+		// -create is 'gomvc new' with its defaults, for gin
+		var opts createOptions
+		newFlags(&opts)
+		opts.module, opts.framework = *moduleFlag, "gin"
+		runCreate(*createFlag, opts)
 	} else if *deleteFlag != "" {
-		fmt.Println("Deleting MVC structure...")
-		if err := deleteMVC(*deleteFlag); err != nil {
-			fmt.Printf("Error deleting MVC structure: %v\n", err)
-		} else {
-			fmt.Println("MVC structure deleted successfully!")
-		}
+		fmt.Fprintln(os.Stderr, "Warning: -delete is deprecated and will be removed in a future release; use 'gomvc destroy <path>' instead.")
+		runDelete(*deleteFlag, "", os.TempDir(), false, false, false)
 	} else {
 		showHelp()
 	}
 }
+
+func main() {
+	if len(os.Args) < 2 && stdinIsTerminal() {
+		newCommand(nil)
+		return
+	}
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		legacyMain()
+		return
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "new":
+		newCommand(args)
+	case "destroy":
+		destroyCommand(args)
+	case "generate":
+		generateCommand(args)
+	case "add":
+		addCommand(args)
+	case "remove":
+		removeCommand(args)
+	case "restore":
+		restoreCommand(args)
+	case "routes":
+		routesCommand(args)
+	case "version":
+		versionCommand()
+	case "upgrade":
+		upgradeCommand(args)
+	case "rename-module":
+		renameModuleCommand(args)
+	case "doctor":
+		doctorCommand(args)
+	case "list", "options":
+		listCommand(args)
+	case "completion":
+		completionCommand(args)
+	case "preset":
+		presetCommand(args)
+	case "help":
+		showHelp()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", command)
+		showHelp()
+		os.Exit(exitUsage)
+	}
+}
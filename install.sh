@@ -12,7 +12,7 @@ if [ "$GOOS" = "windows" ]; then
     OUTPUT+=".exe"
 fi
 
-go build -o $OUTPUT gomvc.go
+go build -o $OUTPUT .
 
 # Determine install path based on OS
 if [ "$GOOS" = "windows" ]; then
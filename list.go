@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+// listResult is the document 'gomvc list -output json' prints.
+type listResult struct {
+	Options           []scaffold.Option          `json:"options"`
+	Features          []listFeature              `json:"features"`
+	Incompatibilities []scaffold.Incompatibility `json:"incompatibilities"`
+}
+
+// listFeature is an on/off flag of 'gomvc new'.
+type listFeature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// notFeatures are the boolean flags of 'gomvc new' that change how it runs
+// rather than what it generates, besides perRunFlags.
+var notFeatures = map[string]bool{
+	"skip-verify":     true,
+	"offline":         true,
+	"template-update": true,
+}
+
+// listFlags returns the flags of 'gomvc list', parsed into output.
+func listFlags(output *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.StringVar(output, "output", outputText, "Output format: text, or json for editors and scripts ("+outputText+", "+outputJSON+")")
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc list [-output json]")
+		fmt.Fprintln(w, "\nPrints the options of 'gomvc new' with their values, the features that")
+		fmt.Fprintln(w, "can be turned on, and the combinations that cannot be generated.")
+		fmt.Fprintln(w, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// newFeatures returns the boolean flags of 'gomvc new' that are off by
+// default and add to the project, sorted by name.
+func newFeatures() []listFeature {
+	features := []listFeature{}
+	newFlags(new(createOptions)).VisitAll(func(f *flag.Flag) {
+		if isBoolFlag(f) && f.DefValue == "false" && !perRunFlags[f.Name] && !notFeatures[f.Name] {
+			features = append(features, listFeature{Name: f.Name, Description: f.Usage})
+		}
+	})
+	return features
+}
+
+func listCommand(args []string) {
+	var output string
+	fs := listFlags(&output)
+	if len(parseArgs(fs, args)) != 0 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	result := listResult{
+		Options:           scaffold.Options(),
+		Features:          newFeatures(),
+		Incompatibilities: scaffold.Incompatibilities(),
+	}
+	switch output {
+	case outputText:
+	case outputJSON:
+		newPrinter(output, false).JSON(result)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q (supported: %s, %s)\n", output, outputText, outputJSON)
+		os.Exit(exitUsage)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Options of 'gomvc new':")
+	for _, o := range result.Options {
+		def := ""
+		if o.Default != "" {
+			def = " (default " + o.Default + ")"
+		}
+		fmt.Fprintf(w, "\n  -%s\t%s%s\n", o.Name, o.Description, def)
+		for _, c := range o.Choices {
+			fmt.Fprintf(w, "    %s\t%s\n", c.Name, c.Description)
+		}
+	}
+	fmt.Fprintln(w, "\nFeatures, off unless given:")
+	for _, f := range result.Features {
+		fmt.Fprintf(w, "  -%s\t%s\n", f.Name, f.Description)
+	}
+	fmt.Fprintln(w, "\nCombinations that cannot be generated:")
+	for _, inc := range result.Incompatibilities {
+		fmt.Fprintf(w, "  - %s\n", inc.Description)
+	}
+	w.Flush()
+	fmt.Println("\nRun 'gomvc new -h' for the other options, e.g. -module and -port.")
+}
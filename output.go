@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Output formats of the -output flag.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// printer writes the messages of the CLI: errors to err, and everything
+// else to out unless quiet is set. With -output json, the messages go to
+// standard error, so that standard output only holds the JSON document.
+type printer struct {
+	out   io.Writer
+	err   io.Writer
+	doc   io.Writer
+	quiet bool
+}
+
+// newPrinter returns a printer for standard output and standard error in
+// the given output format.
+func newPrinter(format string, quiet bool) *printer {
+	p := &printer{out: os.Stdout, err: os.Stderr, doc: os.Stdout, quiet: quiet}
+	if format == outputJSON {
+		p.out = os.Stderr
+	}
+	return p
+}
+
+// Out returns the writer of progress messages, which discards them when
+// quiet is set.
+func (p *printer) Out() io.Writer {
+	if p.quiet {
+		return io.Discard
+	}
+	return p.out
+}
+
+func (p *printer) Printf(format string, args ...any) {
+	fmt.Fprintf(p.Out(), format, args...)
+}
+
+func (p *printer) Println(args ...any) {
+	fmt.Fprintln(p.Out(), args...)
+}
+
+// Errorf prints an error message, even when quiet is set.
+func (p *printer) Errorf(format string, args ...any) {
+	fmt.Fprintf(p.err, format, args...)
+}
+
+// JSON writes v as an indented JSON document to standard output.
+func (p *printer) JSON(v any) {
+	enc := json.NewEncoder(p.doc)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		p.Errorf("failed to write JSON: %v\n", err)
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A preset is a YAML file setting the options of 'gomvc new', one key per
+// flag, so that every project of a team is created the same way:
+//
+//	framework: echo
+//	db: postgres
+//	docker: true
+//	middleware: [Audit, RequestTimer]
+//
+// Only a flat mapping of scalars and lists of scalars is supported, which
+// is all the flags need. It is parsed with yaml.v3, like the OpenAPI
+// documents of the scaffold package, so anchors and aliases work too.
+
+// perRunFlags are the flags of 'gomvc new' that describe a single run
+// or project rather than a recipe, and cannot be set in a preset.
+var perRunFlags = map[string]bool{
+	"module":          true,
+	"preset":          true,
+	"dry-run":         true,
+	"v":               true,
+	"q":               true,
+	"output":          true,
+	"interactive":     true,
+	"force":           true,
+	"backup-dir":      true,
+	"no-backup":       true,
+	"keep-on-failure": true,
+	"into-existing":   true,
+}
+
+// presetEntry is a key of a preset and its value.
+type presetEntry struct {
+	key  string
+	line int
+	// value is the scalar value, and list the values of a list.
+	value  string
+	list   []string
+	isList bool
+}
+
+// defaultPresetPath returns the path of the preset applied when -preset is
+// not given, ~/.config/gomvc/defaults.yaml on Linux.
+func defaultPresetPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gomvc", "defaults.yaml")
+}
+
+// findPreset returns the preset to apply: path if set, otherwise the
+// default preset if it exists.
+func findPreset(path string) string {
+	if path != "" {
+		return path
+	}
+	if def := defaultPresetPath(); def != "" {
+		if _, err := os.Stat(def); err == nil {
+			return def
+		}
+	}
+	return ""
+}
+
+// applyPreset sets the flags of fs to the values of the preset at path,
+// except those set on the command line, which override the preset.
+// Relative paths in the preset are relative to its directory.
+func applyPreset(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading preset: %w", err)
+	}
+	entries, err := parsePreset(path, data)
+	if err != nil {
+		return err
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for _, e := range entries {
+		f := fs.Lookup(e.key)
+		switch {
+		case perRunFlags[e.key]:
+			return fmt.Errorf("%s:%d: %s is chosen for each project and cannot be set in a preset; pass -%s instead", path, e.line, e.key, e.key)
+		case f == nil:
+			return fmt.Errorf("%s:%d: unknown key %q%s; the keys are the options of 'gomvc new', see 'gomvc preset init'", path, e.line, e.key, suggestFlag(fs, e.key))
+		case set[e.key]:
+			continue
+		}
+		value := e.value
+		if e.isList {
+			if isBoolFlag(f) {
+				return fmt.Errorf("%s:%d: %s is true or false, not a list", path, e.line, e.key)
+			}
+			value = strings.Join(e.list, ",")
+		}
+		if isBoolFlag(f) {
+			switch strings.ToLower(value) {
+			case "yes", "on":
+				value = "true"
+			case "no", "off", "":
+				value = "false"
+			}
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("%s:%d: invalid value %q for %s: use true or false", path, e.line, value, e.key)
+			}
+		}
+		if value, err = presetPath(path, e.key, value); err != nil {
+			return err
+		}
+		if err := fs.Set(e.key, value); err != nil {
+			return fmt.Errorf("%s:%d: invalid value %q for %s: %v", path, e.line, value, e.key, err)
+		}
+	}
+	return nil
+}
+
+// presetPaths are the keys of a preset whose values are paths. Those of
+// template are paths only when they start with a dot, as other values are
+// repositories, e.g. "github.com/org/templates@v1.2.0".
+var presetPaths = map[string]func(value string) bool{
+	"templates":    func(string) bool { return true },
+	"from-openapi": func(string) bool { return true },
+	"template":     func(value string) bool { return strings.HasPrefix(value, ".") },
+}
+
+// presetPath returns value, the value of key in the preset at path, made
+// absolute against the directory of the preset if it is a relative path.
+func presetPath(path, key, value string) (string, error) {
+	isPath := presetPaths[key]
+	if value == "" || isPath == nil || !isPath(value) || filepath.IsAbs(value) {
+		return value, nil
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, value), nil
+}
+
+// parsePreset parses the YAML of the preset at path into its entries, in
+// the order of the file. Errors start with the path and, if known, the
+// line, e.g. "defaults.yaml:3: ...".
+func parsePreset(path string, data []byte) ([]presetEntry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, presetYAMLError(path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: expected key: value, one option per line", path, root.Line)
+	}
+	nested := func(n *yaml.Node) error {
+		return fmt.Errorf("%s:%d: nested values are not supported; set each option as key: value", path, n.Line)
+	}
+	var entries []presetEntry
+	seen := make(map[string]int)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], resolveAlias(root.Content[i+1])
+		if key.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s:%d: expected key: value, got a key that is not a name", path, key.Line)
+		}
+		if prev, ok := seen[key.Value]; ok {
+			return nil, fmt.Errorf("%s:%d: %s is already set on line %d", path, key.Line, key.Value, prev)
+		}
+		seen[key.Value] = key.Line
+		e := presetEntry{key: key.Value, line: key.Line}
+		switch value.Kind {
+		case yaml.ScalarNode:
+			e.value = presetScalar(value)
+		case yaml.SequenceNode:
+			// An empty list is an empty value
+			e.isList = len(value.Content) > 0
+			for _, item := range value.Content {
+				if item = resolveAlias(item); item.Kind != yaml.ScalarNode {
+					return nil, nested(item)
+				}
+				e.list = append(e.list, presetScalar(item))
+			}
+		default:
+			return nil, nested(value)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// resolveAlias returns the node an alias refers to, or n if it is none.
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+// presetScalar returns the string a YAML scalar stands for: its value,
+// without quotes, or nothing for null.
+func presetScalar(n *yaml.Node) string {
+	if n.ShortTag() == "!!null" {
+		return ""
+	}
+	return n.Value
+}
+
+// presetYAMLError returns err, an error of yaml.v3 parsing the preset at
+// path, as "path:N: message", or "path: message" if yaml.v3 does not know
+// the line.
+func presetYAMLError(path string, err error) error {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	if rest, ok := strings.CutPrefix(msg, "line "); ok {
+		if line, msg, ok := strings.Cut(rest, ": "); ok {
+			return fmt.Errorf("%s:%s: %s", path, line, msg)
+		}
+	}
+	return fmt.Errorf("%s: %s", path, msg)
+}
+
+// suggestFlag returns a hint naming the flag of fs closest to name, if one
+// is close enough to be a typo of it.
+func suggestFlag(fs *flag.FlagSet, name string) string {
+	best, bestDist := "", 3
+	fs.VisitAll(func(f *flag.Flag) {
+		if perRunFlags[f.Name] {
+			return
+		}
+		if d := editDistance(name, f.Name); d < bestDist {
+			best, bestDist = f.Name, d
+		}
+	})
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// writePreset writes a preset setting every option of fs that a preset
+// can set to its current value, each with its usage as a comment.
+func writePreset(w io.Writer, fs *flag.FlagSet, source string) {
+	fmt.Fprintln(w, "# gomvc preset: the options of 'gomvc new', one key per flag.")
+	fmt.Fprintln(w, "# Use it with 'gomvc new <path> -preset <file>', or save it as")
+	fmt.Fprintf(w, "# %s to apply it whenever -preset is not given.\n", defaultPresetPath())
+	fmt.Fprintln(w, "# Flags given on the command line override the values below.")
+	if source != "" {
+		fmt.Fprintf(w, "# The values are those of %s.\n", source)
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		if perRunFlags[f.Name] {
+			return
+		}
+		fmt.Fprintf(w, "\n# %s\n", f.Usage)
+		value := f.Value.String()
+		switch {
+		case isBoolFlag(f):
+		case f.Name == "middleware":
+			value = "[" + strings.Join(splitList(value), ", ") + "]"
+		case value == "" || strings.ContainsAny(value, ":#'\"[]{},&*!|>%@`") || value != strings.TrimSpace(value):
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(w, "%s: %s\n", f.Name, value)
+	})
+}
+
+func presetCommand(args []string) {
+	if len(args) == 0 || args[0] != "init" {
+		fmt.Fprintln(os.Stderr, "Usage: gomvc preset init [file] [-force]")
+		os.Exit(exitUsage)
+	}
+	var force bool
+	fs := presetInitFlags(&force)
+	paths := parseArgs(fs, args[1:])
+	if len(paths) > 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		// The starting point is what 'gomvc new' would use without flags
+		var opts createOptions
+		newFs := newFlags(&opts)
+		source := findPreset("")
+		if source != "" {
+			if err := applyPreset(newFs, source); err != nil {
+				return err
+			}
+		}
+		if len(paths) == 0 {
+			writePreset(os.Stdout, newFs, source)
+			return nil
+		}
+		flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if force {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		if dir := filepath.Dir(paths[0]); dir != "" {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+		f, err := os.OpenFile(paths[0], flags, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s already exists (use -force to overwrite it)", paths[0])
+		}
+		if err != nil {
+			return err
+		}
+		writePreset(f, newFs, source)
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", paths[0])
+		return nil
+	}()
+	if err != nil {
+		fail("Error writing preset", err)
+	}
+}
+
+// presetInitFlags returns the flags of 'gomvc preset init', parsed into
+// force.
+func presetInitFlags(force *bool) *flag.FlagSet {
+	fs := flag.NewFlagSet("preset init", flag.ExitOnError)
+	fs.BoolVar(force, "force", false, "Overwrite the file if it already exists")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gomvc preset init [file] [options]")
+		fmt.Fprintln(fs.Output(), "\nWrites a preset holding the defaults of 'gomvc new', to start a recipe from,")
+		fmt.Fprintf(fs.Output(), "to file or to stdout. The values of %s are used if it exists.\n", defaultPresetPath())
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
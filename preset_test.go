@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePreset(t *testing.T) {
+	src := `# team defaults
+framework: echo
+db: postgres   # the database
+docker: yes
+module_name: "a # b"
+config: ~
+middleware: [Audit, 'Request''s']
+ci:
+  - github
+empty: []
+templates: &t ./templates
+template: *t
+`
+	entries, err := parsePreset("p.yaml", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []presetEntry{
+		{key: "framework", line: 2, value: "echo"},
+		{key: "db", line: 3, value: "postgres"},
+		{key: "docker", line: 4, value: "yes"},
+		{key: "module_name", line: 5, value: "a # b"},
+		{key: "config", line: 6},
+		{key: "middleware", line: 7, list: []string{"Audit", "Request's"}, isList: true},
+		{key: "ci", line: 8, list: []string{"github"}, isList: true},
+		{key: "empty", line: 10},
+		{key: "templates", line: 11, value: "./templates"},
+		{key: "template", line: 12, value: "./templates"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("parsePreset =\n%+v\nwant\n%+v", entries, want)
+	}
+}
+
+func TestParsePresetErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"duplicate", "db: postgres\ndb: mongo\n", "p.yaml:2: db is already set on line 1"},
+		{"nested mapping", "db:\n  name: postgres\n", "p.yaml:2: nested values are not supported"},
+		{"nested list", "middleware: [[Audit]]\n", "p.yaml:1: nested values are not supported"},
+		{"not a mapping", "- echo\n", "p.yaml:1: expected key: value"},
+		{"unterminated list", "framework: [echo\n", "p.yaml:1: did not find expected ',' or ']'"},
+		{"syntax", "db: postgres: mongo\n", "p.yaml: mapping values are not allowed"},
+	}
+	for _, tt := range tests {
+		_, err := parsePreset("p.yaml", []byte(tt.src))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want it to start with %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+// TestApplyPresetPaths checks that the relative paths of a preset in
+// another directory are resolved against that directory rather than the
+// working directory, and that the other values are kept as they are.
+func TestApplyPresetPaths(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "presets")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key, value string
+		get        func(opts *createOptions) string
+		want       string
+	}{
+		{"templates", "./company-templates", func(o *createOptions) string { return o.templatesDir }, filepath.Join(dir, "company-templates")},
+		{"from-openapi", "api/openapi.yaml", func(o *createOptions) string { return o.openAPI }, filepath.Join(dir, "api", "openapi.yaml")},
+		{"template", "../templates@v1.2.0", func(o *createOptions) string { return o.template }, filepath.Join(filepath.Dir(dir), "templates@v1.2.0")},
+		{"template", "github.com/org/templates@v1.2.0", func(o *createOptions) string { return o.template }, "github.com/org/templates@v1.2.0"},
+		{"framework", "echo", func(o *createOptions) string { return o.framework }, "echo"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "team.yaml")
+		if err := os.WriteFile(path, []byte(tt.key+": "+tt.value+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var opts createOptions
+		if err := applyPreset(newFlags(&opts), path); err != nil {
+			t.Fatalf("%s: %v", tt.key, err)
+		}
+		if got := tt.get(&opts); got != tt.want {
+			t.Errorf("%s: %s = %q, want %q", tt.value, tt.key, got, tt.want)
+		}
+	}
+}
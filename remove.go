@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+func showRemoveHelp() {
+	fmt.Println("Usage: gomvc remove <what> <name> [options]")
+	fmt.Println("\nUndoes what gomvc generated for a slice of the project in the working")
+	fmt.Println("directory, as recorded in its manifest. Files changed since they were")
+	fmt.Println("generated are kept, with a warning.")
+	fmt.Println("\nSlices:")
+	fmt.Println("  resource <Name>\tDelete the model, controller and DTO of a resource and unregister its routes")
+	fmt.Println("  feature <name>\tTurn off a feature of 'gomvc new', e.g. swagger")
+	fmt.Println("\nRun 'gomvc remove <what> -h' for the options of a removal.")
+}
+
+func removeCommand(args []string) {
+	if len(args) == 0 {
+		showRemoveHelp()
+		os.Exit(exitUsage)
+	}
+
+	what, args := args[0], args[1:]
+	switch what {
+	case "resource":
+		removeResourceCommand(args)
+	case "feature":
+		removeFeatureCommand(args)
+	case "help", "-h", "-help", "--help":
+		showRemoveHelp()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown slice %q\n\n", what)
+		showRemoveHelp()
+		os.Exit(exitUsage)
+	}
+}
+
+// removeOptions holds the flags of 'gomvc remove resource' and 'gomvc
+// remove feature'.
+type removeOptions struct {
+	dryRun, force, skipVerify bool
+	plural, templatesDir      string
+	backup                    backupOptions
+}
+
+// removeResourceFlags returns the flags of 'gomvc remove resource', parsed
+// into opts.
+func removeResourceFlags(opts *removeOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("remove resource", flag.ExitOnError)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "List what would be removed, with the router diff, without changing anything")
+	fs.BoolVar(&opts.force, "force", false, "Also delete the files changed since they were generated")
+	fs.StringVar(&opts.plural, "plural", "", "Plural the resource was generated with, if it was given with -plural")
+	addBackupFlags(fs, &opts.backup)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc remove resource <Name> [options]")
+		fmt.Fprintln(w, "\nDeletes models/<name>.go, controller/<name>_controller.go and its test and")
+		fmt.Fprintln(w, "dto/<name>.go where gomvc generated them, and removes the statements")
+		fmt.Fprintln(w, "registering the routes of the resource from router/router.go.")
+		fmt.Fprintln(w, "\nExample: gomvc remove resource Product -dry-run")
+		fmt.Fprintln(w, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func removeResourceCommand(args []string) {
+	var opts removeOptions
+	fs := removeResourceFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := openProject(opts.force)
+		if err != nil {
+			return err
+		}
+		project.DryRun, project.Plural = opts.dryRun, opts.plural
+		project.BackupDir = opts.backup.backupDir()
+		return project.RemoveResource(context.Background(), positional[0])
+	}()
+	if errors.Is(err, scaffold.ErrNoManifest) {
+		err = fmt.Errorf("%w: only what gomvc generated can be removed", err)
+	}
+	if err != nil {
+		fail("Error removing resource", err)
+	}
+}
+
+// removeFeatureFlags returns the flags of 'gomvc remove feature', parsed
+// into opts.
+func removeFeatureFlags(opts *removeOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("remove feature", flag.ExitOnError)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "List what would be removed, with the diff of every file updated, without changing anything")
+	fs.BoolVar(&opts.force, "force", false, "Also delete the files of the feature changed since they were generated")
+	fs.BoolVar(&opts.skipVerify, "skip-verify", false, "Skip running go mod tidy and go build after removing the feature")
+	fs.StringVar(&opts.templatesDir, "templates", "", "Directory of templates the project was created with, if any")
+	addBackupFlags(fs, &opts.backup)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc remove feature <name> [options]")
+		fmt.Fprintln(w, "\nRenders the templates with the options the project was created with, with")
+		fmt.Fprintln(w, "and without the feature, deletes the files only the feature has, and")
+		fmt.Fprintln(w, "reverts its changes to the other files, such as main.go and the router.")
+		fmt.Fprintln(w, "\nFeatures: "+strings.Join(scaffold.RemovableFeatures(), ", "))
+		fmt.Fprintln(w, "\nExample: gomvc remove feature swagger -dry-run")
+		fmt.Fprintln(w, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func removeFeatureCommand(args []string) {
+	var opts removeOptions
+	fs := removeFeatureFlags(&opts)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err := func() error {
+		project, err := openProject(opts.force)
+		if err != nil {
+			return err
+		}
+		project.DryRun, project.SkipVerify = opts.dryRun, opts.skipVerify
+		project.BackupDir = opts.backup.backupDir()
+		if opts.templatesDir != "" {
+			if info, err := os.Stat(opts.templatesDir); err != nil || !info.IsDir() {
+				return &scaffold.ValidationError{Err: fmt.Errorf("invalid templates directory: %s is not a directory", opts.templatesDir)}
+			}
+			project.Templates = os.DirFS(opts.templatesDir)
+		}
+		return project.RemoveFeature(context.Background(), positional[0])
+	}()
+	if errors.Is(err, scaffold.ErrNoManifest) {
+		err = fmt.Errorf("%w: only what gomvc generated can be removed", err)
+	}
+	if err != nil {
+		fail("Error removing feature", err)
+	}
+}
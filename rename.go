@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+// renameModuleOptions holds the flags of 'gomvc rename-module'.
+type renameModuleOptions struct {
+	dryRun     bool
+	skipVerify bool
+	verbose    bool
+}
+
+// renameModuleFlags returns the flags of 'gomvc rename-module', parsed into
+// opts.
+func renameModuleFlags(opts *renameModuleOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("rename-module", flag.ExitOnError)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files that would change, with diffs, without touching the filesystem")
+	fs.BoolVar(&opts.skipVerify, "skip-verify", false, "Skip running go build after renaming")
+	fs.BoolVar(&opts.verbose, "v", false, "Print every file written and command run")
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc rename-module <path> <module> [options]")
+		fmt.Fprintln(w, "\nChanges the module path of the project at path to module: the module")
+		fmt.Fprintln(w, "directive of go.mod and every import of a package of the project. Only")
+		fmt.Fprintln(w, "import paths are rewritten, so comments and strings are left alone; other")
+		fmt.Fprintln(w, "files mentioning the old path are listed to be updated by hand.")
+		fmt.Fprintln(w, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func renameModuleCommand(args []string) {
+	var opts renameModuleOptions
+	fs := renameModuleFlags(&opts)
+	paths := parseArgs(fs, args)
+	if len(paths) != 2 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	project, err := scaffold.Open(paths[0])
+	if err != nil {
+		fail("Error renaming the module", err)
+	}
+	project.DryRun, project.SkipVerify, project.Verbose, project.Out = opts.dryRun, opts.skipVerify, opts.verbose, os.Stdout
+	if err := project.RenameModule(context.Background(), scaffold.CleanModulePath(paths[1])); err != nil {
+		fail("Error renaming the module", err)
+	}
+	if opts.dryRun {
+		fmt.Println("Dry run complete, nothing was changed.")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+// restoreFlags returns the flags of 'gomvc restore', parsed into dryRun
+// and backup.
+func restoreFlags(dryRun *bool, backup *backupOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	fs.BoolVar(dryRun, "dry-run", false, "List the files that would be restored without touching the filesystem")
+	addBackupFlags(fs, backup)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc restore <archive> <path> [options]")
+		fmt.Fprintln(w, "\nUnpacks a backup that destroy, remove, or new and generate with -force")
+		fmt.Fprintln(w, "wrote before deleting or overwriting files, into the project at path. The")
+		fmt.Fprintln(w, "files in the archive replace those at path, which are backed up first;")
+		fmt.Fprintln(w, "other files are left alone.")
+		fmt.Fprintln(w, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func restoreCommand(args []string) {
+	var dryRun bool
+	var backup backupOptions
+	fs := restoreFlags(&dryRun, &backup)
+	paths := parseArgs(fs, args)
+	if len(paths) != 2 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	project := &scaffold.Project{Root: paths[1], DryRun: dryRun, BackupDir: backup.backupDir(), Out: os.Stdout}
+	if err := project.Restore(paths[0]); err != nil {
+		fail("Error restoring the backup", err)
+	}
+	if dryRun {
+		fmt.Println("Dry run complete, nothing was changed.")
+	}
+}
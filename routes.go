@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+// routesFlags returns the flags of 'gomvc routes', parsed into output.
+func routesFlags(output *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("routes", flag.ExitOnError)
+	fs.StringVar(output, "output", outputText, "Output format: text, or json for editors and scripts ("+outputText+", "+outputJSON+")")
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc routes [path] [options]")
+		fmt.Fprintln(w, "\nLists the routes the project at path, by default the one containing the")
+		fmt.Fprintln(w, "working directory, registers: their method, path, handler and the")
+		fmt.Fprintln(w, "middleware they are served through. The code is analyzed without running")
+		fmt.Fprintln(w, "it; registrations that cannot be analyzed are reported as warnings.")
+		fmt.Fprintln(w, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func routesCommand(args []string) {
+	var output string
+	fs := routesFlags(&output)
+	paths := parseArgs(fs, args)
+	if len(paths) > 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	if output != outputText && output != outputJSON {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q (supported: %s, %s)\n", output, outputText, outputJSON)
+		os.Exit(exitUsage)
+	}
+	dir := "."
+	if len(paths) == 1 {
+		dir = paths[0]
+	}
+	project, err := scaffold.Open(dir)
+	if err != nil {
+		fail("Error listing routes", err)
+	}
+	routes, warnings, err := project.Routes()
+	if err != nil {
+		fail("Error listing routes", err)
+	}
+	if output == outputJSON {
+		newPrinter(output, false).JSON(struct {
+			Routes   []scaffold.Route        `json:"routes"`
+			Warnings []scaffold.RouteWarning `json:"warnings"`
+		}{routes, append([]scaffold.RouteWarning{}, warnings...)})
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tMIDDLEWARE")
+	for _, r := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Method, r.Path, r.Handler, strings.Join(r.Middleware, ", "))
+	}
+	w.Flush()
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+}
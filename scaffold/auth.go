@@ -0,0 +1,81 @@
+package scaffold
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// authScheme describes an authentication slice. Its files come from the
+// template layers "auth/users" and "auth/users-<data layer>", or
+// "auth/users-memory" for projects without a database, which store the
+// users, then "auth/<scheme>" and "auth/<scheme>-<framework>".
+type authScheme struct {
+	// requires lists the module@version pairs added to go.mod.
+	requires []string
+	// frameworkRequires lists further modules needed with some frameworks.
+	frameworkRequires map[string][]string
+}
+
+// hashRequire provides bcrypt for the password hashes of every scheme.
+const hashRequire = "golang.org/x/crypto@v0.28.0"
+
+var authSchemes = map[string]authScheme{
+	"jwt": {
+		requires: []string{"github.com/golang-jwt/jwt/v5@v5.2.1", hashRequire},
+	},
+	"session": {
+		requires: []string{hashRequire},
+		frameworkRequires: map[string][]string{
+			"gin":    {"github.com/gin-contrib/sessions@v1.0.1"},
+			"chi":    {"github.com/gorilla/sessions@v1.4.0"},
+			"echo":   {"github.com/gorilla/sessions@v1.4.0"},
+			"fiber":  {"github.com/gorilla/securecookie@v1.1.2"},
+			"stdlib": {"github.com/gorilla/sessions@v1.4.0"},
+		},
+	},
+}
+
+// AuthSchemes returns the supported authentication schemes in sorted order.
+func AuthSchemes() []string {
+	names := make([]string, 0, len(authSchemes))
+	for name := range authSchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateAuth returns an error unless name is a supported authentication
+// scheme.
+func ValidateAuth(name string) error {
+	if _, ok := authSchemes[name]; !ok {
+		return fmt.Errorf("unknown auth scheme %q (supported: %s)", name, strings.Join(AuthSchemes(), ", "))
+	}
+	return nil
+}
+
+// authLayers returns the template layers of the project's auth scheme.
+func (p *Project) authLayers() []string {
+	store := "memory"
+	if p.Database != "" {
+		store = p.dataLayer()
+	}
+	return []string{"auth/users", "auth/users-" + store, "auth/" + p.Auth, "auth/" + p.Auth + "-" + p.framework()}
+}
+
+// authRequires returns the modules the project's auth scheme needs.
+func (p *Project) authRequires() []string {
+	scheme := authSchemes[p.Auth]
+	return slices.Concat(scheme.requires, scheme.frameworkRequires[p.framework()])
+}
+
+// newSecret returns 32 random bytes, hex encoded.
+func newSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,240 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// backupExcludes are the directories left out of backups, as they are
+// large and can be downloaded or built again.
+var backupExcludes = map[string]bool{"vendor": true, "node_modules": true}
+
+// backup is a tar.gz archive of the files of a project about to be deleted
+// or overwritten. It is written to its directory once files are added, and
+// written again whenever more are, so that it is complete before each
+// change. A nil backup ignores what is added to it.
+type backup struct {
+	fsys FS
+	root string
+	dir  string
+	// path is the archive, once written.
+	path    string
+	entries []backupEntry
+	added   map[string]bool
+	// excluded lists the directories of backupExcludes left out.
+	excluded []string
+}
+
+// backupEntry is a file in a backup, with its path relative to the root.
+type backupEntry struct {
+	rel  string
+	mode fs.FileMode
+	data []byte
+}
+
+// newBackup returns the backup of the project into BackupDir, or nil if
+// it is not set or in dry runs.
+func (p *Project) newBackup() *backup {
+	if p.BackupDir == "" || p.DryRun {
+		return nil
+	}
+	return &backup{fsys: p.fs(), root: p.Root, dir: p.BackupDir, added: make(map[string]bool)}
+}
+
+// add adds the files rel, relative to the root, and the files below the
+// directories among them, to b, and writes the archive if any were new.
+// Paths that do not exist are skipped.
+func (b *backup) add(rels ...string) error {
+	if b == nil {
+		return nil
+	}
+	n := len(b.entries)
+	for _, rel := range rels {
+		if err := b.collect(rel); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", rel, err)
+		}
+	}
+	if len(b.entries) == n {
+		return nil
+	}
+	if err := b.write(); err != nil {
+		return fmt.Errorf("failed to write the backup: %w", err)
+	}
+	return nil
+}
+
+// collect adds the file rel, or the files below the directory rel, to the
+// entries of b.
+func (b *backup) collect(rel string) error {
+	if b.added[rel] {
+		return nil
+	}
+	name := filepath.Join(b.root, filepath.FromSlash(rel))
+	info, err := b.fsys.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	b.added[rel] = true
+	if !info.IsDir() {
+		data, err := b.fsys.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		b.entries = append(b.entries, backupEntry{rel: rel, mode: info.Mode().Perm(), data: data})
+		return nil
+	}
+	if backupExcludes[path.Base(rel)] {
+		b.excluded = append(b.excluded, rel)
+		return nil
+	}
+	entries, err := b.fsys.ReadDir(name)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := b.collect(path.Join(rel, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write writes the archive of the entries of b, naming it after the root
+// and the time the first time. It is only readable by its owner, as the
+// files may hold secrets, such as .env.
+func (b *backup) write() error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, e := range b.entries {
+		hdr := &tar.Header{Name: e.rel, Mode: int64(e.mode), Size: int64(len(e.data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	if b.path == "" {
+		if err := b.fsys.MkdirAll(b.dir, DefaultDirMode); err != nil {
+			return err
+		}
+		name := baseName(b.root, "project")
+		base := filepath.Join(b.dir, "gomvc-backup-"+name+"-"+now.Format("20060102-150405"))
+		b.path = base + ".tar.gz"
+		for i := 2; ; i++ {
+			if _, err := b.fsys.Stat(b.path); errors.Is(err, os.ErrNotExist) {
+				break
+			}
+			b.path = base + "-" + strconv.Itoa(i) + ".tar.gz"
+		}
+	}
+	return b.fsys.WriteFile(b.path, buf.Bytes(), 0o600)
+}
+
+// report prints where the archive went, if it was written, and which
+// directories were left out of it.
+func (b *backup) report(out io.Writer) {
+	if b == nil || b.path == "" {
+		return
+	}
+	fmt.Fprintf(out, "Backed up %d %s to %s (restore them with: gomvc restore %s %s)\n", len(b.entries), plural(len(b.entries), "file", "files"), b.path, b.path, b.root)
+	for _, dir := range b.excluded {
+		fmt.Fprintf(out, "  left %s%c out of the backup\n", filepath.FromSlash(dir), filepath.Separator)
+	}
+}
+
+// Restore unpacks the archive of a backup, written before files were
+// deleted or overwritten as BackupDir asks, into Root: the files it holds
+// are written back, replacing the files there, and the directories they
+// need are created. The archive is checked before anything is written;
+// entries that are not regular files or whose paths leave Root are
+// refused. With BackupDir, the files it replaces that differ from those
+// of the archive are backed up first. Every file restored is listed.
+// DryRun only lists them.
+func (p *Project) Restore(archive string) error {
+	data, err := p.fs().ReadFile(archive)
+	if err != nil {
+		return err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s is not a gomvc backup: %v", archive, err)
+	}
+	tr := tar.NewReader(gz)
+	var entries []backupEntry
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s is not a gomvc backup: %v", archive, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return fmt.Errorf("%s is not a gomvc backup: %s is not a regular file", archive, hdr.Name)
+		}
+		if !filepath.IsLocal(filepath.FromSlash(hdr.Name)) {
+			return fmt.Errorf("%s is not a gomvc backup: %s is outside the project", archive, hdr.Name)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("%s is not a gomvc backup: %v", archive, err)
+		}
+		entries = append(entries, backupEntry{rel: path.Clean(hdr.Name), mode: fs.FileMode(hdr.Mode).Perm(), data: content})
+	}
+
+	var replaced []string
+	for _, e := range entries {
+		current, err := p.fs().ReadFile(filepath.Join(p.Root, filepath.FromSlash(e.rel)))
+		if err == nil && !bytes.Equal(current, e.data) {
+			replaced = append(replaced, e.rel)
+		}
+	}
+	b := p.newBackup()
+	if err := b.add(replaced...); err != nil {
+		return err
+	}
+	b.report(p.out())
+
+	fsys, _ := p.effects()
+	for _, e := range entries {
+		name := filepath.Join(p.Root, filepath.FromSlash(e.rel))
+		if err := fsys.MkdirAll(filepath.Dir(name), p.dirMode()); err != nil {
+			return err
+		}
+		if err := fsys.WriteFile(name, e.data, e.mode); err != nil {
+			return err
+		}
+		if !p.DryRun {
+			fmt.Fprintf(p.out(), "  restore   %s\n", filepath.FromSlash(e.rel))
+		}
+	}
+	verb := "Restored"
+	if p.DryRun {
+		verb = "Would restore"
+	}
+	fmt.Fprintf(p.out(), "%s %d %s to %s.\n", verb, len(entries), plural(len(entries), "file", "files"), p.Root)
+	return nil
+}
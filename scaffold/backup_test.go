@@ -0,0 +1,87 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// backupFiles returns the contents of the files of the backup archive by
+// their path.
+func backupFiles(t *testing.T, fsys FS, archive string) map[string]string {
+	t.Helper()
+	data, err := fsys.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	files := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = string(content)
+	}
+}
+
+func TestRestoreBacksUpReplacedFiles(t *testing.T) {
+	for _, backupDir := range []string{"/backups", ""} {
+		t.Run("backup-dir="+backupDir, func(t *testing.T) {
+			p := createdProject(t, nil)
+			readme, gomod := filepath.Join(p.Root, "README.md"), filepath.Join(p.Root, "go.mod")
+			original, err := p.FS.ReadFile(readme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.BackupDir = "/archives"
+			b := p.newBackup()
+			if err := b.add("README.md", "go.mod"); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.FS.WriteFile(readme, []byte("edited\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			p.BackupDir = backupDir
+			if err := p.Restore(b.path); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if got, _ := p.FS.ReadFile(readme); !bytes.Equal(got, original) {
+				t.Errorf("README.md = %q, want it restored", got)
+			}
+			entries, _ := p.FS.ReadDir("/backups")
+			if backupDir == "" {
+				if len(entries) != 0 {
+					t.Errorf("backed up to %s without -backup-dir", entries[0].Name())
+				}
+				return
+			}
+			if len(entries) != 1 {
+				t.Fatalf("%d backups, want 1", len(entries))
+			}
+			files := backupFiles(t, p.FS, filepath.Join("/backups", entries[0].Name()))
+			// go.mod is unchanged, so it is not replaced
+			if want := map[string]string{"README.md": "edited\n"}; len(files) != len(want) || files["README.md"] != want["README.md"] {
+				t.Errorf("backup holds %q, want %q", files, want)
+			}
+			if _, err := p.FS.Stat(gomod); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
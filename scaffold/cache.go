@@ -0,0 +1,31 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// cacheBackends lists the shared caches a project can use, in sorted
+// order. Each adds pkg/cache with that backend and an in-memory one the
+// config can switch to, and the framework's controller caching a report.
+var cacheBackends = []string{"redis"}
+
+// Caches returns the supported cache backends in sorted order.
+func Caches() []string {
+	return slices.Clone(cacheBackends)
+}
+
+// ValidateCache returns an error unless name is a supported cache backend.
+func ValidateCache(name string) error {
+	if !slices.Contains(cacheBackends, name) {
+		return fmt.Errorf("unknown cache %q (supported: %s)", name, strings.Join(cacheBackends, ", "))
+	}
+	return nil
+}
+
+// cacheLayers returns the template layers of -cache: pkg/cache, and the
+// framework's controller caching a report.
+func (p *Project) cacheLayers() []string {
+	return []string{"cache/base", "cache/" + p.framework()}
+}
@@ -0,0 +1,25 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ciProviders lists the CI services a pipeline can be generated for, in
+// sorted order. Each takes its files from the template layer
+// "ci/<provider>".
+var ciProviders = []string{"github", "gitlab"}
+
+// CIProviders returns the supported CI providers in sorted order.
+func CIProviders() []string {
+	return slices.Clone(ciProviders)
+}
+
+// ValidateCI returns an error unless name is a supported CI provider.
+func ValidateCI(name string) error {
+	if !slices.Contains(ciProviders, name) {
+		return fmt.Errorf("unknown CI provider %q (supported: %s)", name, strings.Join(ciProviders, ", "))
+	}
+	return nil
+}
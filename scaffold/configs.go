@@ -0,0 +1,52 @@
+package scaffold
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// configLoader describes how a project reads its settings. Loaders other
+// than the default take their files from the template layer
+// "config/<name>", applied after the database layers.
+type configLoader struct {
+	// requires lists the module@version pairs added to go.mod.
+	requires []string
+}
+
+// defaultConfig is the loader used when none is given. It reads environment
+// variables and an optional .env file with the standard library only.
+const defaultConfig = "env"
+
+var configLoaders = map[string]configLoader{
+	defaultConfig: {},
+	"viper": {
+		requires: []string{"github.com/spf13/viper@v1.19.0"},
+	},
+}
+
+// Configs returns the supported config loader names in sorted order.
+func Configs() []string {
+	names := make([]string, 0, len(configLoaders))
+	for name := range configLoaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateConfig returns an error unless name is a supported config loader.
+func ValidateConfig(name string) error {
+	if _, ok := configLoaders[name]; !ok {
+		return fmt.Errorf("unknown config loader %q (supported: %s)", name, strings.Join(Configs(), ", "))
+	}
+	return nil
+}
+
+// config returns the project's config loader, applying the default.
+func (p *Project) config() string {
+	if p.Config == "" {
+		return defaultConfig
+	}
+	return p.Config
+}
@@ -0,0 +1,442 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronRequire is the module the generated scheduler runs on.
+const cronRequire = "github.com/robfig/cron/v3@v3.0.1"
+
+// cronDir holds the scheduler and tasks of gomvc generate cron.
+const cronDir = "internal/cron"
+
+// cronTasks is the file listing the scheduled tasks.
+const cronTasks = cronDir + "/tasks.go"
+
+// mainPath is the file starting the API server, relative to the root.
+const mainPath = "cmd/api/main.go"
+
+// cronField is a field of a cron expression with its bounds and, for
+// months and days of the week, the names it accepts.
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+// cronFields are the five fields of a cron expression, as parsed by
+// robfig/cron's standard parser.
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 6, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// cronDescriptors are the predefined schedules robfig/cron accepts in
+// place of five fields.
+var cronDescriptors = map[string]bool{
+	"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true,
+	"@daily": true, "@midnight": true, "@hourly": true,
+}
+
+// ValidateCronSchedule checks that schedule is a cron expression the
+// generated scheduler accepts: five fields, minute to day of the week, or
+// a descriptor such as "@daily" or "@every 1h30m", optionally preceded by
+// CRON_TZ=<zone>. The checks and messages follow robfig/cron, so that
+// schedules are rejected when the task is generated rather than when the
+// application starts.
+func ValidateCronSchedule(schedule string) error {
+	if err := parseCronSchedule(schedule); err != nil {
+		return fmt.Errorf("invalid schedule %q: %v", schedule, err)
+	}
+	return nil
+}
+
+// parseCronSchedule parses schedule, returning the first problem found.
+func parseCronSchedule(schedule string) error {
+	spec := strings.TrimSpace(schedule)
+	if spec == "" {
+		return errors.New("empty spec string")
+	}
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		zone, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(zone, "=")
+		if _, err := time.LoadLocation(name); err != nil {
+			return fmt.Errorf("provided bad location %s: %v", name, err)
+		}
+		spec = strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		if every, ok := strings.CutPrefix(spec, "@every "); ok {
+			if _, err := time.ParseDuration(strings.TrimSpace(every)); err != nil {
+				return fmt.Errorf("failed to parse duration %s: %v", spec, err)
+			}
+			return nil
+		}
+		if !cronDescriptors[spec] {
+			return fmt.Errorf("unrecognized descriptor: %s", spec)
+		}
+		return nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("expected exactly %d fields, found %d: %s", len(cronFields), len(fields), fields)
+	}
+	for i, f := range cronFields {
+		for _, expr := range strings.Split(fields[i], ",") {
+			if err := f.parse(expr); err != nil {
+				return fmt.Errorf("%s: %v", f.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parse checks one comma-separated part of the field: *, ? or a number or
+// range, optionally followed by /step.
+func (f cronField) parse(expr string) error {
+	rangeAndStep := strings.Split(expr, "/")
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+	singleDigit := len(lowAndHigh) == 1
+
+	var start, end int
+	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
+		start, end = f.min, f.max
+	} else {
+		var err error
+		if start, err = f.value(lowAndHigh[0]); err != nil {
+			return err
+		}
+		switch len(lowAndHigh) {
+		case 1:
+			end = start
+		case 2:
+			if end, err = f.value(lowAndHigh[1]); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("too many hyphens: %s", expr)
+		}
+	}
+
+	switch len(rangeAndStep) {
+	case 1:
+	case 2:
+		step, err := cronNumber(rangeAndStep[1])
+		if err != nil {
+			return err
+		}
+		if step <= 0 {
+			return fmt.Errorf("step of range should be a positive number: %s", expr)
+		}
+		// "N/step" means every step from N on
+		if singleDigit {
+			end = f.max
+		}
+	default:
+		return fmt.Errorf("too many slashes: %s", expr)
+	}
+
+	if start < f.min {
+		return fmt.Errorf("beginning of range (%d) below minimum (%d): %s", start, f.min, expr)
+	}
+	if end > f.max {
+		return fmt.Errorf("end of range (%d) above maximum (%d): %s", end, f.max, expr)
+	}
+	if start > end {
+		return fmt.Errorf("beginning of range (%d) beyond end of range (%d): %s", start, end, expr)
+	}
+	return nil
+}
+
+// value returns the number s stands for in the field, accepting names
+// such as "jan" or "mon" where the field has them.
+func (f cronField) value(s string) (int, error) {
+	if n, ok := f.names[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+	return cronNumber(s)
+}
+
+// cronNumber parses a non-negative number of a cron expression.
+func cronNumber(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse int from %s: %v", s, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative number (%d) not allowed: %s", n, s)
+	}
+	return n, nil
+}
+
+// cronReserved are the names the scheduler package declares itself, or
+// whose files it uses.
+var cronReserved = map[string]bool{"New": true, "Scheduler": true, "SchedulerTest": true, "Task": true, "Tasks": true}
+
+// cronData is passed to the cron templates.
+type cronData struct {
+	// Name is the task's Go function name.
+	Name string
+	// Schedule is the task's cron expression.
+	Schedule string
+}
+
+// GenerateCron writes internal/cron/<name>.go with an empty task function
+// and lists it with schedule in internal/cron/tasks.go. The first task
+// also writes the scheduler, adds robfig/cron to go.mod and starts and
+// stops the scheduler in cmd/api/main.go. The schedule is validated first,
+// so an invalid one changes nothing. In dry runs the changes to existing
+// files are printed as diffs.
+func (p *Project) GenerateCron(ctx context.Context, name, schedule string) error {
+	if err := validateName("task", name); err != nil {
+		return err
+	}
+	if err := ValidateCronSchedule(schedule); err != nil {
+		return &ValidationError{Err: err}
+	}
+	data := cronData{Name: camelCase(name), Schedule: strings.TrimSpace(schedule)}
+	rel := cronDir + "/" + snakeCase(name) + ".go"
+	if cronReserved[data.Name] {
+		return &ValidationError{Err: fmt.Errorf("%s is declared by the scheduler of %s: choose another task name", data.Name, cronDir)}
+	}
+
+	fsys := p.fs()
+	dir := filepath.Join(p.Root, filepath.FromSlash(cronDir))
+	if file, ok := declaredIn(fsys, dir, data.Name); ok && (file != filepath.Base(rel) || !p.Force) {
+		return &ValidationError{Err: fmt.Errorf("%s is already declared in %s/%s", data.Name, cronDir, file)}
+	}
+	task, err := renderGoTemplate("templates/generate/cron/task.go.tmpl", data)
+	if err != nil {
+		return err
+	}
+	files := []templateFile{{rel, task}}
+
+	// The tasks list is written with the first task, and extended after
+	var tasks, tasksSrc []byte
+	src, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(cronTasks)))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		for _, name := range []string{"scheduler.go", "scheduler_test.go", "tasks.go"} {
+			content, err := renderGoTemplate("templates/generate/cron/"+name+".tmpl", data)
+			if err != nil {
+				return err
+			}
+			files = append(files, templateFile{cronDir + "/" + name, content})
+		}
+	case err != nil:
+		return err
+	default:
+		tasksSrc = src
+		if tasks, err = addCronTask(src, data); err != nil {
+			return err
+		}
+	}
+
+	// Start the scheduler with the server, unless main.go does already
+	var main, mainSrc []byte
+	if mainSrc, err = fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(mainPath))); err != nil {
+		return err
+	}
+	main, err = wireCron(mainSrc, p.Module+"/"+cronDir)
+	if errors.Is(err, errNoLifecycle) {
+		fmt.Fprintf(p.out(), "Note: %s does not wait for a signal with <-ctx.Done() and a ShutdownTimeout. Start and stop the scheduler of %s there yourself.\n", mainPath, cronDir)
+	} else if err != nil {
+		return err
+	}
+
+	gomod, err := fsys.ReadFile(filepath.Join(p.Root, "go.mod"))
+	if err != nil {
+		return err
+	}
+	module, _, _ := strings.Cut(cronRequire, "@")
+	hasRequire := strings.Contains(string(gomod), module+" ")
+
+	return p.generate(ctx, func(g *generator) error {
+		for _, f := range files {
+			if err := p.generateFile(g, f.path, f.content); err != nil {
+				return err
+			}
+		}
+		for _, u := range []struct {
+			rel           string
+			before, after []byte
+			msg           string
+		}{
+			{cronTasks, tasksSrc, tasks, "Scheduled " + data.Name + " in " + cronTasks},
+			{mainPath, mainSrc, main, "Started the scheduler in " + mainPath},
+		} {
+			if u.after == nil {
+				continue
+			}
+			if p.DryRun {
+				fmt.Fprint(p.out(), unifiedDiff(u.rel, string(u.before), string(u.after)))
+			}
+			if err := g.updateFile(u.rel, string(u.after)); err != nil {
+				return err
+			}
+			if !p.DryRun {
+				fmt.Fprintln(p.out(), u.msg)
+			}
+		}
+		if hasRequire {
+			return nil
+		}
+		// The second go get records robfig/cron as a direct dependency
+		if err := g.runGo([]string{"go.sum"}, "get", cronRequire); err != nil {
+			return err
+		}
+		return g.runGo(nil, "get", "./...")
+	})
+}
+
+// addCronTask returns the source of tasks.go with the task appended to the
+// tasks list, or nil if it is listed already.
+func addCronTask(src []byte, data cronData) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, cronTasks, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var list *ast.CompositeLit
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, id := range vs.Names {
+				if id.Name == "tasks" && i < len(vs.Values) {
+					list, _ = vs.Values[i].(*ast.CompositeLit)
+				}
+			}
+		}
+	}
+	if list == nil {
+		return nil, fmt.Errorf("no tasks list found in %s", cronTasks)
+	}
+
+	for _, elt := range list.Elts {
+		lit, ok := elt.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		for _, kv := range lit.Elts {
+			if kv, ok := kv.(*ast.KeyValueExpr); ok {
+				if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Run" {
+					if run, ok := kv.Value.(*ast.Ident); ok && run.Name == data.Name {
+						return nil, nil
+					}
+				}
+			}
+		}
+	}
+
+	entry := fmt.Sprintf("\t{Name: %q, Schedule: %q, Run: %s},\n", data.Name, data.Schedule, data.Name)
+	return applyEdits(cronTasks, src, []textEdit{{fset.Position(list.Rbrace).Offset, entry}})
+}
+
+// errNoLifecycle is returned by wireCron for main functions it does not
+// know where to start and stop the scheduler in.
+var errNoLifecycle = errors.New("no shutdown sequence found")
+
+// wireCron returns the source of main.go starting the scheduler of the
+// package at importPath before waiting for a signal, and stopping it once
+// one arrives, or nil if main.go imports the package already.
+func wireCron(src []byte, importPath string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, mainPath, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if fileImports(file, importPath) {
+		return nil, nil
+	}
+	var body *ast.BlockStmt
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" && fn.Body != nil {
+			body = fn.Body
+		}
+	}
+	if body == nil {
+		return nil, fmt.Errorf("no main function found in %s", mainPath)
+	}
+
+	// The scheduler is started before <-ctx.Done() and stopped after it,
+	// with the timeout the server shuts down in
+	wait := -1
+	for i, stmt := range body.List {
+		if expr, ok := stmt.(*ast.ExprStmt); ok {
+			if recv, ok := expr.X.(*ast.UnaryExpr); ok && recv.Op == token.ARROW {
+				wait = i
+				break
+			}
+		}
+	}
+	var timeout string
+	ast.Inspect(body, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "ShutdownTimeout" && timeout == "" {
+			timeout = string(src[fset.Position(sel.Pos()).Offset:fset.Position(sel.End()).Offset])
+		}
+		return timeout == ""
+	})
+	if wait < 1 || timeout == "" {
+		return nil, errNoLifecycle
+	}
+
+	// Stop it right after the signal, and after the call of stop that
+	// usually follows
+	stopAfter := body.List[wait]
+	if wait+1 < len(body.List) && isCallOf(body.List[wait+1], "stop") {
+		stopAfter = body.List[wait+1]
+	}
+
+	start := "\n\n\t// Run the scheduled tasks of internal/cron\n" +
+		"\tscheduler, err := cron.New()\n" +
+		"\tif err != nil {\n\t\tlog.Fatalf(\"Failed to schedule the tasks: %v\", err)\n\t}\n" +
+		"\tscheduler.Start()"
+	stop := "\n\t// Cancel the scheduled tasks still running\n\tscheduler.Stop(" + timeout + ")"
+	edits := []textEdit{
+		{fset.Position(body.List[wait-1].End()).Offset, start},
+		{fset.Position(stopAfter.End()).Offset, stop},
+	}
+	if missing := missingImports(file, []string{importPath, "log"}); len(missing) > 0 {
+		edits = append(edits, importEdit(fset, file, missing))
+	}
+	return applyEdits(mainPath, src, edits)
+}
+
+// isCallOf reports whether s is a call of the function name without
+// arguments.
+func isCallOf(s ast.Stmt, name string) bool {
+	expr, ok := s.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := expr.X.(*ast.CallExpr)
+	if !ok || len(call.Args) > 0 {
+		return false
+	}
+	id, ok := call.Fun.(*ast.Ident)
+	return ok && id.Name == name
+}
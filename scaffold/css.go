@@ -0,0 +1,38 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultCSS is the way the pages are styled when none is given: a plain
+// stylesheet, which needs no build step.
+const DefaultCSS = "plain"
+
+// cssSetups lists the ways the pages of the web modes can be styled, in
+// sorted order. Each takes its files from the template layer "css/<name>":
+// "plain" a handwritten static/css/style.css, and "tailwind" a Tailwind
+// CSS config and the input static/css/style.css is built from.
+var cssSetups = []string{"plain", "tailwind"}
+
+// CSSSetups returns the supported CSS setups in sorted order.
+func CSSSetups() []string {
+	return slices.Clone(cssSetups)
+}
+
+// ValidateCSS returns an error unless name is a supported CSS setup.
+func ValidateCSS(name string) error {
+	if !slices.Contains(cssSetups, name) {
+		return fmt.Errorf("unknown CSS setup %q (supported: %s)", name, strings.Join(cssSetups, ", "))
+	}
+	return nil
+}
+
+// css returns the project's CSS setup, applying the default.
+func (p *Project) css() string {
+	if p.CSS == "" {
+		return DefaultCSS
+	}
+	return p.CSS
+}
@@ -0,0 +1,206 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// diModes lists how the dependencies of the server can be composed, in
+// sorted order. Without one, main.go constructs them itself; with one,
+// internal/app does, from the template layer "di" and, for wire, "di/wire".
+var diModes = []string{"app", "wire"}
+
+// wireRequire is the module of Wire, which the injector in
+// internal/app/wire.go imports.
+const wireRequire = "github.com/google/wire@v0.7.0"
+
+// wireCommand runs the wire command of the version of Wire in go.mod,
+// which generates internal/app/wire_gen.go from the injector, as "make
+// wire" does. -mod=mod lets go run add the checksums of the modules the
+// command needs, which the project does not import.
+var wireCommand = []string{"run", "-mod=mod", "github.com/google/wire/cmd/wire", "./internal/app"}
+
+// wireOutput lists the files wireCommand writes.
+var wireOutput = []string{"internal/app/wire_gen.go"}
+
+// DIModes returns the supported dependency injection modes in sorted order.
+func DIModes() []string {
+	return slices.Clone(diModes)
+}
+
+// ValidateDI returns an error unless name is a supported dependency
+// injection mode.
+func ValidateDI(name string) error {
+	if !slices.Contains(diModes, name) {
+		return fmt.Errorf("unknown dependency injection mode %q (supported: %s)", name, strings.Join(diModes, ", "))
+	}
+	return nil
+}
+
+// diLayers returns the template layers composing the server in
+// internal/app.
+func (p *Project) diLayers() []string {
+	if p.DI == "wire" {
+		return []string{"di", "di/wire"}
+	}
+	return []string{"di"}
+}
+
+// servicesPath and providersPath are the files gomvc generate service
+// registers the services it generates in, in projects with a DI mode.
+const (
+	servicesPath  = serviceDir + "/services.go"
+	providersPath = "internal/app/providers.go"
+)
+
+// detectDI returns the DI mode of the project at Root, told from its files:
+// "wire" if internal/app has an injector for Wire, "app" if it constructs
+// the Services of internal/service by hand, and "" otherwise.
+func (p *Project) detectDI() string {
+	fsys := p.fs()
+	if _, ok := declaredIn(fsys, filepath.Join(p.Root, filepath.FromSlash(serviceDir)), "Services"); !ok {
+		return ""
+	}
+	if _, err := fsys.Stat(filepath.Join(p.Root, "internal", "app", "wire.go")); err == nil {
+		return "wire"
+	}
+	if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(providersPath))); err == nil {
+		return "app"
+	}
+	return ""
+}
+
+// registerService returns the changes adding the service in data to the
+// Services struct and constructing it in internal/app, by hand in
+// provideServices for the app mode, or with providers added to
+// ProviderSet for wire.
+func (p *Project) registerService(data layerData, di string) ([]fileUpdate, error) {
+	fsys := p.fs()
+	var updates []fileUpdate
+	src, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(servicesPath)))
+	if err != nil {
+		return nil, err
+	}
+	services, err := addServiceField(src, data.Name)
+	if err != nil {
+		return nil, err
+	}
+	if services != nil {
+		updates = append(updates, fileUpdate{servicesPath, src, services})
+	}
+
+	if src, err = fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(providersPath))); err != nil {
+		return nil, err
+	}
+	providers, err := addServiceProviders(src, data, p.Module+"/"+repositoryDir, di)
+	if err != nil {
+		return nil, err
+	}
+	if providers != nil {
+		updates = append(updates, fileUpdate{providersPath, src, providers})
+	}
+	return updates, nil
+}
+
+// addServiceField returns the source of services.go with a field name of
+// type <name>Service added to the Services struct, or nil if it has one.
+func addServiceField(src []byte, name string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, servicesPath, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || ts.Name.Name != "Services" {
+				continue
+			}
+			for _, f := range st.Fields.List {
+				for _, id := range f.Names {
+					if id.Name == name {
+						return nil, nil
+					}
+				}
+			}
+			field := "\t" + name + " " + name + "Service\n"
+			return applyEdits(servicesPath, src, []textEdit{{fset.Position(st.Fields.Closing).Offset, field}})
+		}
+	}
+	return nil, fmt.Errorf("%s does not declare a Services struct", servicesPath)
+}
+
+// addServiceProviders returns the source of providers.go constructing the
+// service in data with its repository in memory, or nil if it does
+// already. With the app mode the service is added to the Services literal
+// of provideServices, and with wire the constructors to ProviderSet.
+func addServiceProviders(src []byte, data layerData, repoImport, di string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, providersPath, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	constructor := "service.New" + data.Name + "Service"
+	var edits []textEdit
+	var found, registered bool
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		var elts []ast.Expr
+		var closing token.Pos
+		var entry string
+		switch n := n.(type) {
+		case *ast.CompositeLit:
+			if di != "app" || types.ExprString(n.Type) != "service.Services" {
+				return true
+			}
+			elts, closing = n.Elts, n.Rbrace
+			entry = data.Name + ": " + constructor + "(repository.NewMemory" + data.Name + "Repository()),\n"
+		case *ast.CallExpr:
+			if di != "wire" || types.ExprString(n.Fun) != "wire.NewSet" {
+				return true
+			}
+			elts, closing = n.Args, n.Rparen
+			entry = "repository.NewMemory" + data.Name + "Repository,\n" +
+				"wire.Bind(new(repository." + data.Name + "Repository), new(*repository.Memory" + data.Name + "Repository)),\n" +
+				constructor + ",\n"
+		default:
+			return true
+		}
+		found = true
+		for _, e := range elts {
+			if strings.Contains(types.ExprString(e), constructor) {
+				registered = true
+			}
+		}
+		if len(elts) == 0 {
+			entry = "\n" + entry
+		}
+		edits = append(edits, textEdit{fset.Position(closing).Offset, entry})
+		return false
+	})
+	switch {
+	case !found && di == "wire":
+		return nil, fmt.Errorf("%s does not declare a ProviderSet with wire.NewSet", providersPath)
+	case !found:
+		return nil, fmt.Errorf("%s does not construct a service.Services", providersPath)
+	case registered:
+		return nil, nil
+	}
+	if missing := missingImports(file, []string{repoImport}); len(missing) > 0 {
+		edits = append(edits, importEdit(fset, file, missing))
+	}
+	return applyEdits(providersPath, src, edits)
+}
@@ -0,0 +1,194 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// unifiedDiff returns a unified diff turning oldText into newText, labelled
+// with name. Line endings are ignored, so that a file with CRLF ones only
+// shows the lines that change. It returns "" when both are equal.
+func unifiedDiff(name, oldText, newText string) string {
+	oldText, newText = toLF(oldText), toLF(newText)
+	if oldText == newText {
+		return ""
+	}
+	ops := diffOps(splitLines(oldText), splitLines(newText))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+	for start := 0; start < len(ops); {
+		// Find the next change and grow the hunk while changes are close
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		lo := max(first-diffContext, 0)
+		hi := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				hi = k + 1
+			} else if k-hi >= 2*diffContext {
+				break
+			}
+		}
+		hi = min(hi+diffContext, len(ops))
+
+		// Line numbers of the hunk in the old and new text
+		oldStart, newStart := 1, 1
+		for _, o := range ops[:lo] {
+			if o.kind != '+' {
+				oldStart++
+			}
+			if o.kind != '-' {
+				newStart++
+			}
+		}
+		oldLen, newLen := 0, 0
+		for _, o := range ops[lo:hi] {
+			if o.kind != '+' {
+				oldLen++
+			}
+			if o.kind != '-' {
+				newLen++
+			}
+		}
+		// An empty range starts at the line before it, as with diff -u
+		if oldLen == 0 {
+			oldStart--
+		}
+		if newLen == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+		for _, o := range ops[lo:hi] {
+			fmt.Fprintf(&out, "%c%s\n", o.kind, o.line)
+		}
+		start = hi
+	}
+	return out.String()
+}
+
+// diffOp is a line of a diff: kind is ' ' for a line kept, '-' for one
+// removed and '+' for one added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffOps returns the lines kept, removed and added to turn a into b, along
+// a longest common subsequence.
+func diffOps(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]; the files diffed here are small enough for the quadratic
+	// table.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		// Removals come first on ties, so that a changed line is shown
+		// removed before it is shown added
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// merge3 merges the changes turning base into ours and into theirs, line
+// by line. It reports false, with the merge unfinished, when both change
+// the same lines differently.
+func merge3(base, ours, theirs string) (string, bool) {
+	b, o, t := splitLines(base), splitLines(ours), splitLines(theirs)
+	inOurs, inTheirs := matches(b, o), matches(b, t)
+
+	var merged []string
+	i, oi, ti := 0, 0, 0
+	for i < len(b) || oi < len(o) || ti < len(t) {
+		// The next base line kept by both sides ends the current chunk
+		k := i
+		for k < len(b) && (inOurs[k] < 0 || inTheirs[k] < 0) {
+			k++
+		}
+		if k == i && k < len(b) && inOurs[k] == oi && inTheirs[k] == ti {
+			merged = append(merged, b[i])
+			i, oi, ti = i+1, oi+1, ti+1
+			continue
+		}
+		oEnd, tEnd := len(o), len(t)
+		if k < len(b) {
+			oEnd, tEnd = inOurs[k], inTheirs[k]
+		}
+		bc, oc, tc := b[i:k], o[oi:oEnd], t[ti:tEnd]
+		switch {
+		case slices.Equal(oc, bc):
+			merged = append(merged, tc...)
+		case slices.Equal(tc, bc), slices.Equal(oc, tc):
+			merged = append(merged, oc...)
+		default:
+			return "", false
+		}
+		i, oi, ti = k, oEnd, tEnd
+	}
+	if len(merged) == 0 {
+		return "", true
+	}
+	return strings.Join(merged, "\n") + "\n", true
+}
+
+// matches returns, for each line of a, the index of the line of b it is
+// kept as, or -1 if it is removed.
+func matches(a, b []string) []int {
+	m := make([]int, len(a))
+	i, j := 0, 0
+	for _, op := range diffOps(a, b) {
+		switch op.kind {
+		case ' ':
+			m[i] = j
+			i, j = i+1, j+1
+		case '-':
+			m[i] = -1
+			i++
+		case '+':
+			j++
+		}
+	}
+	return m
+}
+
+// splitLines splits text into lines without their trailing newlines.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
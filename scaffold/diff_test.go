@@ -0,0 +1,80 @@
+package scaffold
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"line endings only", "a\r\nb\r\n", "a\nb\n", ""},
+		{
+			"changed line",
+			"a\nb\nc\n", "a\nB\nc\n",
+			"--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			"changed lines",
+			"a\nb\nc\nd\n", "a\nB\nC\nd\n",
+			"--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n a\n-b\n-c\n+B\n+C\n d\n",
+		},
+		{
+			"new file",
+			"", "a\nb\n",
+			"--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			"deleted file",
+			"a\nb\n", "",
+			"--- a/f\n+++ b/f\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			"insertion without context",
+			"a\n", "a\nb\n",
+			"--- a/f\n+++ b/f\n@@ -1,1 +1,2 @@\n a\n+b\n",
+		},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n", "x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n",
+			"--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("f", tt.old, tt.new); got != tt.want {
+				t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		wantOK             bool
+	}{
+		{"unchanged", "a\nb\n", "a\nb\n", "a\nb\n", "a\nb\n", true},
+		{"ours only", "a\nb\n", "a\nB\n", "a\nb\n", "a\nB\n", true},
+		{"theirs only", "a\nb\n", "a\nb\n", "A\nb\n", "A\nb\n", true},
+		{"both on different lines", "a\nb\nc\n", "A\nb\nc\n", "a\nb\nC\n", "A\nb\nC\n", true},
+		{"both the same way", "a\nb\n", "a\nB\n", "a\nB\n", "a\nB\n", true},
+		{"both add lines apart", "a\nb\nc\n", "x\na\nb\nc\n", "a\nb\nc\ny\n", "x\na\nb\nc\ny\n", true},
+		{"theirs removes a line", "a\nb\nc\nd\n", "A\nb\nc\nd\n", "a\nb\nd\n", "A\nb\nd\n", true},
+		{"everything removed", "a\n", "a\n", "", "", true},
+		{"conflict", "a\nb\nc\n", "a\nX\nc\n", "a\nY\nc\n", "", false},
+		{"adjacent changes", "a\nb\nc\n", "A\nb\nc\n", "a\nc\n", "", false},
+		{"ours removes what theirs changes", "a\nb\nc\n", "a\nc\n", "a\nB\nc\n", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := merge3(tt.base, tt.ours, tt.theirs)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("merge3 = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package scaffold
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// composeDatabaseURL returns the database URL the app container of
+// docker-compose.yml uses: the development URL with localhost replaced by
+// the database service, or for file databases the file in the /data
+// volume.
+func (p *Project) composeDatabaseURL(url string) string {
+	if strings.Contains(url, "localhost") {
+		return strings.Replace(url, "localhost", p.Database, 1)
+	}
+	return "/data/" + url
+}
+
+// hasCompose reports whether the project gets a docker-compose.yml, either
+// from -docker or for the database it uses.
+func (p *Project) hasCompose() bool {
+	if p.Docker {
+		return true
+	}
+	if p.Database == "" {
+		return false
+	}
+	_, err := fs.Stat(templates, "templates/database/"+p.Database+"/docker-compose.yml.tmpl")
+	return err == nil
+}
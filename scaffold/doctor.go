@@ -0,0 +1,259 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/version"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MinGoVersion is the oldest Go release generated projects build with:
+// they use the method and wildcard patterns of http.ServeMux and range
+// over integers, both added in Go 1.22.
+const MinGoVersion = "1.22"
+
+// DefaultProxy is the module proxy checked when GOPROXY is not set.
+const DefaultProxy = "https://proxy.golang.org"
+
+// Check is the result of one check of Doctor.
+type Check struct {
+	// Name is what was checked, e.g. "go" or "git".
+	Name string `json:"name"`
+	OK   bool   `json:"ok"`
+	// Required is set if projects cannot be generated without it. Failed
+	// checks that are not required are warnings.
+	Required bool `json:"required"`
+	// Detail is what was found, or why the check failed.
+	Detail string `json:"detail"`
+	// Hint says how to fix a failed check.
+	Hint string `json:"hint,omitempty"`
+	// Err is the error of a failed check.
+	Err error `json:"-"`
+}
+
+// Doctor checks that the environment has what generating a project needs:
+// a recent enough go command with modules enabled, git, docker, a writable
+// destination and a reachable module proxy.
+type Doctor struct {
+	// Root is the path the project would be created at. Its nearest
+	// existing directory must be writable. It is not checked if empty.
+	Root string
+	// Git and Docker require git and docker. Without them, their checks
+	// fail as warnings only.
+	Git    bool
+	Docker bool
+	// SkipDocker and SkipNetwork leave those checks out.
+	SkipDocker  bool
+	SkipNetwork bool
+	// Timeout bounds the network check, by default 5 seconds.
+	Timeout time.Duration
+}
+
+// Run runs the checks of d in order.
+func (d *Doctor) Run(ctx context.Context) []Check {
+	checks := []Check{d.checkGo(ctx), d.checkModules(ctx), d.checkTool(ctx, "git", d.Git, "Install git from https://git-scm.com/downloads; it is only needed by -template and to commit the project")}
+	if !d.SkipDocker {
+		hint := "Install Docker from https://docs.docker.com/get-docker/; it is only needed by -docker"
+		checks = append(checks, d.checkTool(ctx, "docker", d.Docker, hint))
+	}
+	if d.Root != "" {
+		checks = append(checks, d.checkWritable())
+	}
+	if !d.SkipNetwork {
+		checks = append(checks, d.checkNetwork(ctx))
+	}
+	return checks
+}
+
+// checkGo checks that the go command is installed and at least
+// MinGoVersion.
+func (d *Doctor) checkGo(ctx context.Context) Check {
+	c := Check{Name: "go", Required: true}
+	path, goVersion, err := findGo(ctx)
+	if err != nil {
+		c.Detail, c.Err = err.Error(), err
+		c.Hint = "Install Go " + MinGoVersion + " or later from https://go.dev/dl/ and make sure it is on PATH"
+		return c
+	}
+	if version.IsValid(goVersion) && version.Compare(goVersion, "go"+MinGoVersion) < 0 {
+		c.Err = fmt.Errorf("%s is older than go%s", goVersion, MinGoVersion)
+		c.Detail = c.Err.Error()
+		c.Hint = "Upgrade Go from https://go.dev/dl/, or set GOTOOLCHAIN=go" + MinGoVersion + " to download a newer toolchain"
+		return c
+	}
+	c.OK, c.Detail = true, goVersion+" at "+path
+	return c
+}
+
+// checkModules checks that the go command has modules enabled and a
+// module cache, which a GOPATH set up for GOPATH mode may not have.
+func (d *Doctor) checkModules(ctx context.Context) Check {
+	c := Check{Name: "modules", Required: true}
+	env, err := goEnv(ctx, "GO111MODULE", "GOMODCACHE")
+	if err != nil {
+		c.Detail, c.Err = "go env failed: "+err.Error(), err
+		c.Hint = "Fix the Go installation until 'go env' works"
+		return c
+	}
+	if env["GO111MODULE"] == "off" {
+		c.Err = errors.New("GO111MODULE=off disables modules")
+		c.Detail = c.Err.Error()
+		c.Hint = "Run 'go env -u GO111MODULE' and unset it in your shell profile"
+		return c
+	}
+	if env["GOMODCACHE"] == "" {
+		c.Err = errors.New("no module cache: GOPATH and GOMODCACHE are unset")
+		c.Detail = c.Err.Error()
+		c.Hint = "Set GOPATH, e.g. with 'go env -w GOPATH=$HOME/go'"
+		return c
+	}
+	c.OK, c.Detail = true, "module cache in "+env["GOMODCACHE"]
+	return c
+}
+
+// checkTool checks that the command name is installed, failing as an
+// error if required and as a warning otherwise.
+func (d *Doctor) checkTool(ctx context.Context, name string, required bool, hint string) Check {
+	c := Check{Name: name, Required: required}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		c.Detail, c.Err, c.Hint = name+" not found on PATH", err, hint
+		return c
+	}
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		c.Detail, c.Err, c.Hint = name+" --version failed: "+err.Error(), err, hint
+		return c
+	}
+	c.OK, c.Detail = true, strings.TrimSpace(string(out))
+	return c
+}
+
+// checkWritable checks that a directory can be created at Root, by
+// creating and removing a temporary directory in its nearest existing
+// parent.
+func (d *Doctor) checkWritable() Check {
+	c := Check{Name: "destination", Required: true}
+	dir := d.Root
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				c.Err = &fs.PathError{Op: "create", Path: dir, Err: errors.New("not a directory")}
+				c.Detail, c.Hint = c.Err.Error(), "Choose another path for the project"
+				return c
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	tmp, err := os.MkdirTemp(dir, ".gomvc-doctor-")
+	if err != nil {
+		c.Detail, c.Err = dir+" is not writable: "+err.Error(), err
+		c.Hint = "Choose a path you can write to, or fix the permissions of " + dir
+		return c
+	}
+	os.Remove(tmp)
+	c.OK, c.Detail = true, dir+" is writable"
+	return c
+}
+
+// checkNetwork checks that the first module proxy of GOPROXY answers. It
+// is never required, since the module cache may hold every module.
+func (d *Doctor) checkNetwork(ctx context.Context) Check {
+	c := Check{Name: "network"}
+	proxy := DefaultProxy
+	if env, err := goEnv(ctx, "GOPROXY"); err == nil && env["GOPROXY"] != "" {
+		proxy, _, _ = strings.Cut(env["GOPROXY"], ",")
+		proxy, _, _ = strings.Cut(proxy, "|")
+	}
+	if proxy == "off" || proxy == "direct" {
+		c.OK, c.Detail = true, "GOPROXY="+proxy+", no proxy to reach"
+		return c
+	}
+	timeout := d.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, proxy, nil)
+	if err == nil {
+		var resp *http.Response
+		if resp, err = http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+	if err != nil {
+		c.Detail, c.Err = proxy+" is not reachable: "+err.Error(), err
+		c.Hint = "Check your connection or proxy settings; offline, only modules in the module cache can be added"
+		return c
+	}
+	c.OK, c.Detail = true, proxy+" is reachable"
+	return c
+}
+
+// goEnv returns the values of the go environment variables names.
+func goEnv(ctx context.Context, names ...string) (map[string]string, error) {
+	path, err := exec.LookPath("go")
+	if err != nil {
+		return nil, err
+	}
+	out, err := exec.CommandContext(ctx, path, append([]string{"env"}, names...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	values := strings.Split(strings.TrimSuffix(strings.ReplaceAll(string(out), "\r\n", "\n"), "\n"), "\n")
+	env := make(map[string]string, len(names))
+	for i, name := range names {
+		if i < len(values) {
+			env[name] = strings.TrimSpace(values[i])
+		}
+	}
+	return env, nil
+}
+
+// DoctorError reports the required checks of Doctor that failed. It
+// unwraps to the error of the first, e.g. exec.ErrNotFound for a missing
+// command.
+type DoctorError struct {
+	Checks []Check
+}
+
+// CheckFailures returns a *DoctorError with the required checks that
+// failed, or nil if they all passed.
+func CheckFailures(checks []Check) error {
+	var failed []Check
+	for _, c := range checks {
+		if c.Required && !c.OK {
+			failed = append(failed, c)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &DoctorError{Checks: failed}
+}
+
+func (e *DoctorError) Error() string {
+	names := make([]string, 0, len(e.Checks))
+	for _, c := range e.Checks {
+		names = append(names, c.Name)
+	}
+	return fmt.Sprintf("the environment is missing prerequisites (%s); run gomvc doctor for details", strings.Join(names, ", "))
+}
+
+func (e *DoctorError) Unwrap() error {
+	return e.Checks[0].Err
+}
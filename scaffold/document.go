@@ -0,0 +1,292 @@
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// docKind is the kind of a docNode.
+type docKind int
+
+const (
+	docNull docKind = iota
+	docScalar
+	docMapping
+	docSequence
+)
+
+// docNode is a value of a JSON or YAML document: a scalar, kept as the
+// text it is written as, a mapping keeping the order of its keys, a
+// sequence or null. YAML is parsed with yaml.v3, so documents such as
+// OpenAPI specifications may use all of it, anchors, aliases and merge
+// keys included, except for keys other than scalars.
+type docNode struct {
+	kind  docKind
+	value string
+	// jsonType is the type of a scalar parsed from JSON: "string",
+	// "number" or "boolean".
+	jsonType string
+	keys     []string
+	// fields are the values of a mapping by key, and items those of a
+	// sequence.
+	fields map[string]*docNode
+	items  []*docNode
+	// line is where the value starts in the document.
+	line int
+}
+
+// get returns the value of key in the mapping n, or nil if n is not a
+// mapping or has no such key.
+func (n *docNode) get(key string) *docNode {
+	if n == nil || n.kind != docMapping {
+		return nil
+	}
+	return n.fields[key]
+}
+
+// str returns the scalar value of key in the mapping n, or "".
+func (n *docNode) str(key string) string {
+	if v := n.get(key); v != nil && v.kind == docScalar {
+		return v.value
+	}
+	return ""
+}
+
+// list returns the items of the sequence n, or nil.
+func (n *docNode) list() []*docNode {
+	if n == nil || n.kind != docSequence {
+		return nil
+	}
+	return n.items
+}
+
+// set adds key to the mapping n, rejecting keys it has already.
+func (n *docNode) set(key string, v *docNode, line int) error {
+	if _, ok := n.fields[key]; ok {
+		return fmt.Errorf("line %d: duplicate key %q", line, key)
+	}
+	n.keys = append(n.keys, key)
+	n.fields[key] = v
+	return nil
+}
+
+func newMapping(line int) *docNode {
+	return &docNode{kind: docMapping, fields: make(map[string]*docNode), line: line}
+}
+
+// parseDocument parses src as JSON if it starts with a brace or bracket,
+// and as YAML otherwise.
+func parseDocument(src []byte) (*docNode, error) {
+	src = bytes.TrimPrefix(src, []byte("\xef\xbb\xbf"))
+	if trimmed := bytes.TrimLeft(src, " \t\r\n"); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return parseJSONDocument(src)
+	}
+	return parseYAMLDocument(src)
+}
+
+// parseJSONDocument parses src as JSON, keeping the order of object keys.
+func parseJSONDocument(src []byte) (*docNode, error) {
+	var newlines []int
+	for i, c := range src {
+		if c == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	line := func() int { return sort.SearchInts(newlines, int(dec.InputOffset())) + 1 }
+
+	var value func() (*docNode, error)
+	value = func() (*docNode, error) {
+		n := line()
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '[' {
+				seq := &docNode{kind: docSequence, line: n}
+				for dec.More() {
+					item, err := value()
+					if err != nil {
+						return nil, err
+					}
+					seq.items = append(seq.items, item)
+				}
+				_, err := dec.Token()
+				return seq, err
+			}
+			m := newMapping(n)
+			for dec.More() {
+				n := line()
+				tok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := value()
+				if err != nil {
+					return nil, err
+				}
+				if err := m.set(tok.(string), v, n); err != nil {
+					return nil, err
+				}
+			}
+			_, err := dec.Token()
+			return m, err
+		case string:
+			return &docNode{kind: docScalar, value: t, jsonType: "string", line: n}, nil
+		case json.Number:
+			return &docNode{kind: docScalar, value: t.String(), jsonType: "number", line: n}, nil
+		case bool:
+			return &docNode{kind: docScalar, value: strconv.FormatBool(t), jsonType: "boolean", line: n}, nil
+		}
+		return &docNode{line: n}, nil
+	}
+	root, err := value()
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			return root, nil
+		} else if err == nil {
+			err = errors.New("unexpected content after the document")
+		}
+	}
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		return nil, fmt.Errorf("line %d: %v", sort.SearchInts(newlines, int(syntax.Offset))+1, err)
+	}
+	if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, errors.New("unexpected end of the document")
+	}
+	if strings.HasPrefix(err.Error(), "line ") {
+		return nil, err
+	}
+	return nil, fmt.Errorf("line %d: %v", line(), err)
+}
+
+// parseYAMLDocument parses src as a single YAML document. Aliases are
+// resolved to the value of their anchor, which both share, and merge keys
+// (<<) add the keys of the mappings they name that the mapping lacks.
+func parseYAMLDocument(src []byte) (*docNode, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	var doc yaml.Node
+	if err := dec.Decode(&doc); err == io.EOF {
+		return &docNode{line: 1}, nil
+	} else if err != nil {
+		return nil, yamlError(err)
+	}
+	var next yaml.Node
+	if err := dec.Decode(&next); err == nil {
+		return nil, fmt.Errorf("line %d: only one document is supported", next.Line)
+	} else if err != io.EOF {
+		return nil, yamlError(err)
+	}
+	c := yamlConverter{nodes: make(map[*yaml.Node]*docNode)}
+	return c.convert(&doc)
+}
+
+// yamlLine matches the position yaml.v3 starts its messages with.
+var yamlLine = regexp.MustCompile(`^yaml: (?:line (\d+): )?`)
+
+// yamlError returns err, an error of yaml.v3, as "line N: message", like
+// the errors of the JSON parser.
+func yamlError(err error) error {
+	m := yamlLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	msg := strings.TrimPrefix(err.Error(), m[0])
+	if m[1] == "" {
+		return errors.New(msg)
+	}
+	return fmt.Errorf("line %s: %s", m[1], msg)
+}
+
+// yamlConverter converts the nodes of yaml.v3 to docNodes, once each, so
+// that aliases share the value of their anchor.
+type yamlConverter struct {
+	nodes map[*yaml.Node]*docNode
+}
+
+func (c *yamlConverter) convert(n *yaml.Node) (*docNode, error) {
+	if d, ok := c.nodes[n]; ok {
+		return d, nil
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return &docNode{line: n.Line}, nil
+		}
+		return c.convert(n.Content[0])
+	case yaml.AliasNode:
+		return c.convert(n.Alias)
+	case yaml.ScalarNode:
+		d := &docNode{kind: docScalar, value: n.Value, line: n.Line}
+		if n.ShortTag() == "!!null" {
+			d = &docNode{line: n.Line}
+		}
+		c.nodes[n] = d
+		return d, nil
+	case yaml.SequenceNode:
+		d := &docNode{kind: docSequence, line: n.Line}
+		c.nodes[n] = d
+		for _, item := range n.Content {
+			v, err := c.convert(item)
+			if err != nil {
+				return nil, err
+			}
+			d.items = append(d.items, v)
+		}
+		return d, nil
+	case yaml.MappingNode:
+		d := newMapping(n.Line)
+		c.nodes[n] = d
+		var merged []*docNode
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Kind == yaml.AliasNode {
+				key = key.Alias
+			}
+			v, err := c.convert(value)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case key.Kind != yaml.ScalarNode:
+				return nil, fmt.Errorf("line %d: only scalar keys are supported", key.Line)
+			case key.ShortTag() == "!!merge" && v.kind == docSequence:
+				merged = append(merged, v.items...)
+			case key.ShortTag() == "!!merge":
+				merged = append(merged, v)
+			default:
+				if err := d.set(key.Value, v, key.Line); err != nil {
+					return nil, err
+				}
+			}
+		}
+		// The keys of the mapping take precedence over merged ones, and
+		// those of earlier merged mappings over later ones
+		for _, m := range merged {
+			if m.kind != docMapping {
+				return nil, fmt.Errorf("line %d: << must merge a mapping or a list of mappings", m.line)
+			}
+			for _, key := range m.keys {
+				if _, ok := d.fields[key]; !ok {
+					d.keys = append(d.keys, key)
+					d.fields[key] = m.fields[key]
+				}
+			}
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", n.Line)
+}
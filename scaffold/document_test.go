@@ -0,0 +1,89 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+)
+
+// docString returns n as compact JSON-like text, with the keys of mappings
+// in their order, for comparing documents.
+func docString(n *docNode) string {
+	switch n.kind {
+	case docScalar:
+		return n.value
+	case docSequence:
+		items := make([]string, len(n.items))
+		for i, item := range n.items {
+			items[i] = docString(item)
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	case docMapping:
+		fields := make([]string, len(n.keys))
+		for i, key := range n.keys {
+			fields[i] = key + ":" + docString(n.fields[key])
+		}
+		return "{" + strings.Join(fields, " ") + "}"
+	}
+	return "null"
+}
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"empty", "", "null"},
+		{"mapping", "a: 1\nb: two # comment\nc:\n", "{a:1 b:two c:null}"},
+		{"nested", "a:\n  b:\n    - x\n    - y: 1\n      z: 2\n", "{a:{b:[x {y:1 z:2}]}}"},
+		{"flow", "a: [1, 'two', {b: c}]\n", "{a:[1 two {b:c}]}"},
+		{"quoted", `a: "x # y"` + "\nb: 'it''s'\n", "{a:x # y b:it's}"},
+		{"literal", "a: |\n  one\n  two\nb: >-\n  three\n  four\n", "{a:one\ntwo\n b:three four}"},
+		{"document marker", "---\na: b\n...\n", "{a:b}"},
+		{"anchor and alias", "a: &x {b: 1}\nc: *x\n", "{a:{b:1} c:{b:1}}"},
+		{"alias in a list", "base: &name Pet\nrefs: [*name, *name]\n", "{base:Pet refs:[Pet Pet]}"},
+		{"merge", "base: &base {a: 1, b: 2}\nm:\n  <<: *base\n  b: 3\n", "{base:{a:1 b:2} m:{b:3 a:1}}"},
+		{"merge list", "x: &x {a: 1}\ny: &y {a: 2, b: 2}\nm: {<<: [*x, *y]}\n", "{x:{a:1} y:{a:2 b:2} m:{a:1 b:2}}"},
+		{"json", `{"a": [1, true, "s"], "b": null}`, "{a:[1 true s] b:null}"},
+	}
+	for _, tt := range tests {
+		n, err := parseDocument([]byte(tt.src))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := docString(n); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseDocumentLines(t *testing.T) {
+	n, err := parseDocument([]byte("openapi: 3.0.3\npaths:\n  /pets:\n    get: {}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := n.get("paths").get("/pets").line; line != 4 {
+		t.Errorf("/pets is on line %d, want 4", line)
+	}
+	if line := n.get("openapi").line; line != 1 {
+		t.Errorf("openapi is on line %d, want 1", line)
+	}
+}
+
+func TestParseDocumentErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"duplicate key", "a: 1\nb: 2\na: 3\n", `line 3: `},
+		{"two documents", "a: 1\n---\nb: 2\n", "line 2: only one document is supported"},
+		{"bad indentation", "a:\n  b: 1\n c: 2\n", "did not find expected key"},
+		{"unknown alias", "a: *x\n", "unknown anchor"},
+		{"complex key", "? [a, b]\n: c\n", "only scalar keys are supported"},
+		{"json", "{\"a\": 1,\n\"a\": 2}", `duplicate key "a"`},
+	}
+	for _, tt := range tests {
+		_, err := parseDocument([]byte(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package scaffold
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests in testdata")
+
+// fileSizes matches the sizes of the files dry runs print, which change
+// with every template.
+var fileSizes = regexp.MustCompile(`\(\d+ bytes\)`)
+
+// checkGolden compares out, with slashes for separators and without the
+// sizes of files, to testdata/name, or writes it there with -update.
+func checkGolden(t *testing.T, name, out string) {
+	t.Helper()
+	out = fileSizes.ReplaceAllString(filepath.ToSlash(out), "(N bytes)")
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, []byte(out), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to write it)", err)
+	}
+	if out != string(want) {
+		t.Errorf("output differs from %s (run go test -update to rewrite it):\n%s", golden, unifiedDiff(name, string(want), out))
+	}
+}
+
+// TestCreateDryRun checks the plan a dry run of Create prints, and that
+// it changes nothing.
+func TestCreateDryRun(t *testing.T) {
+	p := memProject()
+	p.DryRun = true
+	var out strings.Builder
+	p.Out = &out
+	if err := p.Create(context.Background()); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	checkGolden(t, "create_dry_run.golden", out.String())
+	if files := p.FS.(*memFS).files; len(files) != 0 {
+		t.Errorf("dry run wrote %d files", len(files))
+	}
+}
+
+// TestDestroyDryRun checks the plan a dry run of Destroy prints, and that
+// it removes nothing.
+func TestDestroyDryRun(t *testing.T) {
+	p := createdProject(t, nil)
+	before := len(p.FS.(*memFS).files)
+	p.DryRun = true
+	var out strings.Builder
+	p.Out = &out
+	if err := p.Destroy(context.Background()); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+	checkGolden(t, "destroy_dry_run.golden", out.String())
+	if after := len(p.FS.(*memFS).files); after != before {
+		t.Errorf("dry run removed %d files", before-after)
+	}
+}
+
+// TestDryRunMkdir checks that dry runs only print mkdir for directories
+// that are missing.
+func TestDryRunMkdir(t *testing.T) {
+	p := createdProject(t, nil)
+	p.DryRun = true
+	var out strings.Builder
+	p.Out = &out
+	if err := p.GenerateController(context.Background(), "Order", false); err != nil {
+		t.Fatalf("GenerateController: %v", err)
+	}
+	if strings.Contains(out.String(), "mkdir") {
+		t.Errorf("dry run prints mkdir for existing directories:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), filepath.FromSlash("controller/order_controller.go")) {
+		t.Errorf("dry run does not print the controller:\n%s", out.String())
+	}
+}
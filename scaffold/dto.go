@@ -0,0 +1,227 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// dtoDir holds the request and response bodies of the API, apart from the
+// models.
+const dtoDir = "dto"
+
+// dtoVerbs are the prefixes of DTO names that are not part of the name of
+// the model they describe, e.g. Create in CreateProduct.
+var dtoVerbs = []string{"Create", "Update", "Patch", "Replace"}
+
+// dtoField is a field of a request or response.
+type dtoField struct {
+	Field
+	// Tags are the struct tags after the json tag, e.g. `validate:"required"`.
+	Tags string
+	// Mapped is set when the model has a field of the same name and type,
+	// which ToModel and FromModel copy.
+	Mapped bool
+}
+
+// dtoData is passed to the DTO template.
+type dtoData struct {
+	// Request and Response are the type names, and Model the name of the
+	// model they map to, "" if there is none.
+	Request, Response, Model string
+	Module                   string
+	// Doc is set when the file is the first of the package, which gets
+	// the package comment.
+	Doc bool
+	// Fields are the fields of the request, and ResponseFields those of
+	// the response, which is only declared with WithResponse.
+	Fields, ResponseFields []dtoField
+	WithResponse           bool
+	// Imports are the standard library packages the field types need.
+	Imports []string
+	// Validate is set when the project has pkg/validate, whose Request
+	// checks the validate tags. Binding is set when gin checks binding tags
+	// instead, as it does when the project lacks it.
+	Validate, Binding bool
+}
+
+// GenerateDTO writes dto/<name>.go with a <Name>Request of fields, with
+// json, validate and, for gin, binding tags, and a response named after the
+// model, e.g. ProductResponse for CreateProduct, unless the package declares
+// it already. When the project has that model, the request gets a ToModel
+// method and the response a <Model>ResponseFromModel function copying the
+// fields the model has. An existing file is only overwritten when Force is
+// set.
+func (p *Project) GenerateDTO(ctx context.Context, name string, fields []Field) error {
+	if err := validateName("DTO", name); err != nil {
+		return err
+	}
+	name = strings.TrimSuffix(camelCase(name), "Request")
+	if name == "" {
+		return fmt.Errorf("invalid DTO name: it needs more than the Request suffix")
+	}
+	model := dtoModel(name)
+	modelFields, _ := structFields(p.fs(), filepath.Join(p.Root, "models"), model)
+	data, notes := p.dtoData(name, model, fields, modelFields)
+	rel := dtoDir + "/" + snakeCase(name) + ".go"
+	if file, ok := declaredIn(p.fs(), filepath.Join(p.Root, dtoDir), data.Request); ok && (file != filepath.Base(rel) || !p.Force) {
+		return &ValidationError{Err: fmt.Errorf("%s is already declared in %s/%s", data.Request, dtoDir, file)}
+	}
+	content, err := renderGoTemplate("templates/generate/dto.go.tmpl", data)
+	if err != nil {
+		return err
+	}
+	return p.generate(ctx, func(g *generator) error {
+		if err := p.generateFile(g, rel, content); err != nil {
+			return err
+		}
+		for _, note := range notes {
+			fmt.Fprintln(p.out(), "Note: "+note)
+		}
+		return nil
+	})
+}
+
+// dtoModel returns the name of the model the DTO name describes: name
+// without a leading verb such as Create.
+func dtoModel(name string) string {
+	for _, verb := range dtoVerbs {
+		if rest, ok := strings.CutPrefix(name, verb); ok && rest != "" && unicode.IsUpper(rune(rest[0])) {
+			return rest
+		}
+	}
+	return name
+}
+
+// dtoData returns the data of the DTO name of the model with fields. The
+// DTO maps to the model if modelFields, the types of its fields by name, is
+// not nil; the notes list the fields it cannot map.
+func (p *Project) dtoData(name, model string, fields []Field, modelFields map[string]string) (dtoData, []string) {
+	fsys := p.fs()
+	dir := filepath.Join(p.Root, dtoDir)
+	rel := snakeCase(name) + ".go"
+	data := dtoData{Request: name + "Request", Response: model + "Response", Module: p.Module}
+	file, declared := declaredIn(fsys, dir, data.Response)
+	data.WithResponse = !declared || file == rel
+	entries, _ := fsys.ReadDir(dir)
+	data.Doc = true
+	for _, entry := range entries {
+		if entry.Name() != rel && strings.HasSuffix(entry.Name(), ".go") {
+			data.Doc = false
+		}
+	}
+	_, data.Validate = declaredIn(fsys, filepath.Join(p.Root, "pkg", "validate"), "Request")
+	data.Binding = p.framework() == "gin" && !data.Validate
+	if modelFields != nil {
+		data.Model = model
+	}
+
+	var notes []string
+	seen := make(map[string]bool)
+	imports := func(typ string) {
+		paths, _ := typeImports(typ)
+		for _, path := range paths {
+			if !seen[path] {
+				seen[path] = true
+				data.Imports = append(data.Imports, path)
+			}
+		}
+	}
+	// The response carries the ID of the model, if it has one
+	if typ, ok := modelFields["ID"]; ok && !hasField(fields, "ID") {
+		if _, err := typeImports(typ); err == nil {
+			data.ResponseFields = append(data.ResponseFields, dtoField{Field: Field{Name: "id", Type: typ}, Mapped: true})
+			if data.WithResponse {
+				imports(typ)
+			}
+		}
+	}
+	for _, f := range fields {
+		df := dtoField{Field: f, Mapped: modelFields[f.GoName()] == f.Type}
+		if data.Model != "" && !df.Mapped {
+			notes = append(notes, fmt.Sprintf("models.%s has no field %s of type %s, so the mappers leave it out", model, f.GoName(), f.Type))
+		}
+		if rule := validateRule(f.Type); rule != "" {
+			if data.Binding {
+				df.Tags = fmt.Sprintf(" binding:%q", rule)
+			}
+			df.Tags += fmt.Sprintf(" validate:%q", rule)
+		}
+		data.Fields = append(data.Fields, df)
+		data.ResponseFields = append(data.ResponseFields, dtoField{Field: f, Mapped: df.Mapped})
+		imports(f.Type)
+	}
+	sort.Strings(data.Imports)
+	return data, notes
+}
+
+// hasField reports whether fields has a field with the Go name name.
+func hasField(fields []Field, name string) bool {
+	for _, f := range fields {
+		if f.GoName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRule returns the validation rule of request fields of type typ:
+// required, unless the zero value is a valid one, as for booleans and
+// pointers.
+func validateRule(typ string) string {
+	if typ == "bool" || typ == "any" || strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "interface") {
+		return ""
+	}
+	return "required"
+}
+
+// structFields returns the types of the named fields of the struct type
+// name declared in the Go files of dir, by field name, and whether it is
+// declared there.
+func structFields(fsys FS, dir, name string) (map[string]string, bool) {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		src, err := fsys.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), entry.Name(), src, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || ts.Name.Name != name {
+					continue
+				}
+				fields := make(map[string]string)
+				for _, f := range st.Fields.List {
+					for _, id := range f.Names {
+						fields[id.Name] = types.ExprString(f.Type)
+					}
+				}
+				return fields, true
+			}
+		}
+	}
+	return nil, false
+}
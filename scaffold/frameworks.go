@@ -0,0 +1,28 @@
+package scaffold
+
+import "sort"
+
+// framework describes the web framework a project is generated for. Its
+// files come from the template layer of the same name.
+type framework struct {
+	// requires lists the module@version pairs added to go.mod.
+	requires []string
+}
+
+var frameworks = map[string]framework{
+	"gin":    {requires: []string{"github.com/gin-gonic/gin@v1.10.0", "github.com/gin-contrib/cors@v1.7.2", "github.com/gin-contrib/pprof@v1.5.0"}},
+	"chi":    {requires: []string{"github.com/go-chi/chi/v5@v5.1.0"}},
+	"echo":   {requires: []string{"github.com/labstack/echo/v4@v4.12.0"}},
+	"fiber":  {requires: []string{"github.com/gofiber/fiber/v2@v2.52.5"}},
+	"stdlib": {},
+}
+
+// Frameworks returns the supported framework names in sorted order.
+func Frameworks() []string {
+	names := make([]string, 0, len(frameworks))
+	for name := range frameworks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
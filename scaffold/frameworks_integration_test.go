@@ -0,0 +1,64 @@
+//go:build integration
+
+package scaffold
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestFrameworksVet generates a project for every framework into a
+// temporary directory and runs go vet on it. Create downloads the modules
+// of the framework, so it needs the network and runs only with the
+// integration build tag.
+func TestFrameworksVet(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	for _, fw := range Frameworks() {
+		t.Run(fw, func(t *testing.T) {
+			t.Parallel()
+			root := filepath.Join(t.TempDir(), "app")
+			p := &Project{Root: root, Module: "example.com/app", Framework: fw}
+			if err := p.Create(context.Background()); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			cmd := exec.Command("go", "vet", "./...")
+			cmd.Dir = root
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("go vet: %v\n%s", err, out)
+			}
+		})
+	}
+}
+
+// TestGeneratedMiddlewareVet generates a middleware, registered where the
+// framework supports it, into a project of every framework and runs go vet
+// on it, which type-checks it against the framework.
+func TestGeneratedMiddlewareVet(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	for _, fw := range Frameworks() {
+		t.Run(fw, func(t *testing.T) {
+			t.Parallel()
+			root := filepath.Join(t.TempDir(), "app")
+			p := &Project{Root: root, Module: "example.com/app", Framework: fw, SkipVerify: true}
+			ctx := context.Background()
+			if err := p.Create(ctx); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			_, register := middlewareUse[fw]
+			if err := p.GenerateMiddleware(ctx, "Timer", register); err != nil {
+				t.Fatalf("GenerateMiddleware: %v", err)
+			}
+			cmd := exec.Command("go", "vet", "./...")
+			cmd.Dir = root
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("go vet: %v\n%s", err, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FS is the filesystem a Project is generated into and removed from. Paths
+// are OS paths that include the project root. Implement it to target an
+// in-memory filesystem or afero in tests.
+type FS interface {
+	Stat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	MkdirAll(path string, perm fs.FileMode) error
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Remove(name string) error
+	RemoveAll(path string) error
+}
+
+// Runner executes external commands such as go mod init in dir.
+type Runner interface {
+	Run(ctx context.Context, dir, name string, args ...string) error
+}
+
+// OSFS is the FS backed by the os package.
+type OSFS struct{}
+
+func (OSFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (OSFS) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (OSFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (OSFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OSFS) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+// ExecRunner is the Runner backed by os/exec.
+type ExecRunner struct {
+	// Log, if set, receives the command line of every command before it
+	// runs, and then its output as it is printed.
+	Log io.Writer
+}
+
+// Run runs the command, looked up in PATH first, with the extensions of
+// PATHEXT on Windows, so that a missing one fails with exec.ErrNotFound
+// before anything runs. If it fails, the returned *CommandError carries
+// its combined standard output and standard error.
+func (r ExecRunner) Run(ctx context.Context, dir, name string, args ...string) error {
+	line := commandLine(name, args)
+	if r.Log != nil {
+		changeLog{out: r.Log}.print("run", line)
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return &CommandError{Command: line, Err: err}
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if r.Log != nil {
+		cmd.Stdout = io.MultiWriter(&out, r.Log)
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Run(); err != nil {
+		return &CommandError{Command: line, Output: strings.TrimSpace(out.String()), Err: err}
+	}
+	return nil
+}
+
+// findGo returns the path of the go command and the version it reports,
+// e.g. "go1.23.4", so a missing toolchain is reported before anything is
+// generated.
+func findGo(ctx context.Context) (path, version string, err error) {
+	path, err = exec.LookPath("go")
+	if err != nil {
+		return "", "", fmt.Errorf("%w; install Go from https://go.dev/dl/", err)
+	}
+	cmd := exec.CommandContext(ctx, path, "env", "GOVERSION")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", "", &CommandError{Command: commandLine(path, cmd.Args[1:]), Output: strings.TrimSpace(string(out)), Err: err}
+	}
+	return path, strings.TrimSpace(string(out)), nil
+}
+
+// CommandError reports a failed external command along with what it
+// printed. It unwraps to the error of os/exec, e.g. exec.ErrNotFound.
+type CommandError struct {
+	// Command is the command line, e.g. "go mod init example.com/app".
+	Command string
+	// Output is the combined standard output and standard error.
+	Output string
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	if e.Output == "" {
+		return fmt.Sprintf("%s: %v", e.Command, e.Err)
+	}
+	return fmt.Sprintf("%s: %v\n%s", e.Command, e.Err, e.Output)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// commandLine formats a command for messages, quoting the arguments that
+// need it so it can be pasted into a shell.
+func commandLine(name string, args []string) string {
+	parts := []string{name}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// dryRun prints one line per change, with paths relative to root, instead
+// of applying it. Reads are passed through to the underlying FS.
+type dryRun struct {
+	FS
+	changeLog
+	// dirs are the directories it printed mkdir for.
+	dirs map[string]bool
+}
+
+// MkdirAll prints mkdir for path unless it exists, or would by an earlier
+// MkdirAll.
+func (d *dryRun) MkdirAll(path string, perm fs.FileMode) error {
+	path = filepath.Clean(path)
+	if d.dirs[path] {
+		return nil
+	}
+	if _, err := d.FS.Stat(path); err == nil {
+		return nil
+	}
+	if d.dirs == nil {
+		d.dirs = make(map[string]bool)
+	}
+	for dir := path; !d.dirs[dir]; dir = filepath.Dir(dir) {
+		d.dirs[dir] = true
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	d.print("mkdir", d.rel(path))
+	return nil
+}
+
+func (d *dryRun) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	d.print("write", fmt.Sprintf("%s (%d bytes)", d.rel(name), len(data)))
+	return nil
+}
+
+func (d *dryRun) Remove(name string) error {
+	d.print("remove", d.rel(name))
+	return nil
+}
+
+func (d *dryRun) RemoveAll(path string) error {
+	d.print("remove", d.rel(path)+string(filepath.Separator)+" (recursively)")
+	return nil
+}
+
+func (d *dryRun) Run(ctx context.Context, dir, name string, args ...string) error {
+	d.print("run", commandLine(name, args))
+	return nil
+}
+
+// verboseFS prints one line per change like dryRun, and then applies it.
+type verboseFS struct {
+	FS
+	changeLog
+}
+
+func (v *verboseFS) MkdirAll(path string, perm fs.FileMode) error {
+	if _, err := v.FS.Stat(path); errors.Is(err, os.ErrNotExist) {
+		v.print("mkdir", v.rel(path))
+	}
+	return v.FS.MkdirAll(path, perm)
+}
+
+func (v *verboseFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	v.print("write", fmt.Sprintf("%s (%d bytes)", v.rel(name), len(data)))
+	return v.FS.WriteFile(name, data, perm)
+}
+
+func (v *verboseFS) Remove(name string) error {
+	v.print("remove", v.rel(name))
+	return v.FS.Remove(name)
+}
+
+func (v *verboseFS) RemoveAll(path string) error {
+	v.print("remove", v.rel(path)+string(filepath.Separator)+" (recursively)")
+	return v.FS.RemoveAll(path)
+}
+
+// changeLog prints the changes of dryRun and verboseFS to out, with paths
+// relative to root.
+type changeLog struct {
+	out  io.Writer
+	root string
+}
+
+func (l changeLog) print(action, detail string) {
+	fmt.Fprintf(l.out, "  %-7s %s\n", action, detail)
+}
+
+func (l changeLog) rel(path string) string {
+	if rel, err := filepath.Rel(l.root, path); err == nil {
+		return rel
+	}
+	return path
+}
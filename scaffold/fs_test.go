@@ -0,0 +1,262 @@
+package scaffold
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// memFS is an in-memory FS for tests. The root of its paths, e.g. / or C:\,
+// always exists.
+type memFS struct {
+	mu    sync.Mutex
+	files map[string]memFile
+	dirs  map[string]fs.FileMode
+}
+
+type memFile struct {
+	data []byte
+	mode fs.FileMode
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: make(map[string]memFile), dirs: make(map[string]fs.FileMode)}
+}
+
+// memInfo is the fs.FileInfo of a file or directory of a memFS.
+type memInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return i.mode }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
+
+func (m *memFS) isDir(name string) bool {
+	_, ok := m.dirs[name]
+	return ok || filepath.Dir(name) == name
+}
+
+func (m *memFS) stat(name string) (memInfo, bool) {
+	if f, ok := m.files[name]; ok {
+		return memInfo{name: filepath.Base(name), size: int64(len(f.data)), mode: f.mode}, true
+	}
+	if m.isDir(name) {
+		return memInfo{name: filepath.Base(name), mode: fs.ModeDir | m.dirs[name]}, true
+	}
+	return memInfo{}, false
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info, ok := m.stat(filepath.Clean(name))
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return info, nil
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if f, ok := m.files[name]; ok {
+		return slices.Clone(f.data), nil
+	}
+	if m.isDir(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: syscall.EISDIR}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// children returns the paths of the files and directories in dir.
+func (m *memFS) children(dir string) []string {
+	var paths []string
+	for path := range m.files {
+		if filepath.Dir(path) == dir {
+			paths = append(paths, path)
+		}
+	}
+	for path := range m.dirs {
+		if filepath.Dir(path) == dir && path != dir {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if !m.isDir(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	var entries []fs.DirEntry
+	for _, path := range m.children(name) {
+		info, _ := m.stat(path)
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	return entries, nil
+}
+
+func (m *memFS) MkdirAll(path string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	var missing []string
+	for dir := path; !m.isDir(dir); dir = filepath.Dir(dir) {
+		if _, ok := m.files[dir]; ok {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: syscall.ENOTDIR}
+		}
+		missing = append(missing, dir)
+	}
+	for _, dir := range missing {
+		m.dirs[dir] = perm.Perm()
+	}
+	return nil
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	switch dir := filepath.Dir(name); {
+	case m.isDir(name):
+		return &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+	case !m.isDir(dir):
+		err := fs.ErrNotExist
+		if _, ok := m.files[dir]; ok {
+			err = syscall.ENOTDIR
+		}
+		return &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	mode := perm.Perm()
+	if f, ok := m.files[name]; ok {
+		mode = f.mode
+	}
+	m.files[name] = memFile{data: slices.Clone(data), mode: mode}
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if _, ok := m.files[name]; ok {
+		delete(m.files, name)
+		return nil
+	}
+	if _, ok := m.dirs[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if len(m.children(name)) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+	}
+	delete(m.dirs, name)
+	return nil
+}
+
+func (m *memFS) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	below := func(p string) bool {
+		return p == path || strings.HasPrefix(p, path+string(filepath.Separator))
+	}
+	for p := range m.files {
+		if below(p) {
+			delete(m.files, p)
+		}
+	}
+	for p := range m.dirs {
+		if below(p) {
+			delete(m.dirs, p)
+		}
+	}
+	return nil
+}
+
+// fakeRunner records the commands it is asked to run instead of running
+// them. Like go mod init, it writes go.mod for it.
+type fakeRunner struct {
+	fs       FS
+	commands []string
+}
+
+func (r *fakeRunner) Run(ctx context.Context, dir, name string, args ...string) error {
+	r.commands = append(r.commands, commandLine(name, args))
+	if name == "go" && len(args) == 3 && args[0] == "mod" && args[1] == "init" {
+		return r.fs.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+args[2]+"\n\ngo 1.22\n"), 0o644)
+	}
+	return nil
+}
+
+// memProject returns a Project generated into a memFS rather than the disk,
+// without running go, for the module example.com/app.
+func memProject() *Project {
+	fsys := newMemFS()
+	return &Project{
+		Root:       filepath.Join(string(filepath.Separator), "work", "app"),
+		Module:     "example.com/app",
+		SkipVerify: true,
+		FS:         fsys,
+		Runner:     &fakeRunner{fs: fsys},
+	}
+}
+
+// createdProject returns memProject, changed by set if not nil, after
+// Create has generated it. The output is discarded unless set sets Out.
+func createdProject(t *testing.T, set func(p *Project)) *Project {
+	t.Helper()
+	p := memProject()
+	p.Out = io.Discard
+	if set != nil {
+		set(p)
+	}
+	if err := p.Create(context.Background()); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return p
+}
+
+func TestMemFS(t *testing.T) {
+	fsys := newMemFS()
+	dir := filepath.Join(string(filepath.Separator), "a", "b")
+	if err := fsys.WriteFile(filepath.Join(dir, "f"), nil, 0o644); err == nil {
+		t.Error("WriteFile into a missing directory succeeded")
+	}
+	if err := fsys.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile(filepath.Join(dir, "f"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Remove(dir); err == nil {
+		t.Error("Remove of a non-empty directory succeeded")
+	}
+	entries, err := fsys.ReadDir(filepath.Dir(dir))
+	if err != nil || len(entries) != 1 || entries[0].Name() != "b" || !entries[0].IsDir() {
+		t.Errorf("ReadDir = %v, %v; want [b/]", entries, err)
+	}
+	if err := fsys.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat(filepath.Join(dir, "f")); err == nil {
+		t.Error("file still exists after RemoveAll")
+	}
+}
@@ -0,0 +1,911 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Field is a struct field given on the command line as name:type, e.g.
+// "created_at:time.Time".
+type Field struct {
+	// Name is the field name as given, usually snake_case.
+	Name string
+	// Type is the Go type of the field.
+	Type string
+}
+
+// GoName is the exported Go identifier of the field.
+func (f Field) GoName() string {
+	return camelCase(f.Name)
+}
+
+// JSONName is the snake_case name used in the json struct tag.
+func (f Field) JSONName() string {
+	return snakeCase(f.Name)
+}
+
+// typePackages maps the package qualifiers allowed in field types to their
+// import paths.
+var typePackages = map[string]string{
+	"json": "encoding/json",
+	"sql":  "database/sql",
+	"time": "time",
+}
+
+// typePackageList returns the package qualifiers allowed in field types,
+// e.g. "json, sql, time".
+func typePackageList() string {
+	names := make([]string, 0, len(typePackages))
+	for name := range typePackages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ParseFields parses name:type arguments into fields.
+func ParseFields(args []string) ([]Field, error) {
+	fields := make([]Field, 0, len(args))
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		name, typ, ok := strings.Cut(arg, ":")
+		if !ok || typ == "" {
+			return nil, &ValidationError{Err: fmt.Errorf("invalid field %q: expected name:type, e.g. price:float64", arg)}
+		}
+		if err := validateName("field", name); err != nil {
+			return nil, err
+		}
+		if _, err := typeImports(typ); err != nil {
+			return nil, &ValidationError{Err: fmt.Errorf("invalid field %q: %v", arg, err)}
+		}
+
+		f := Field{Name: name, Type: typ}
+		if seen[f.GoName()] {
+			return nil, &ValidationError{Err: fmt.Errorf("duplicate field %q", name)}
+		}
+		seen[f.GoName()] = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// typeImports checks that typ is a Go type expression and returns the
+// import paths it needs. Its identifiers must be predeclared types, such as
+// string or int64, or exported ones, such as the models of the package.
+func typeImports(typ string) ([]string, error) {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a Go type", typ)
+	}
+
+	var imports []string
+	var check func(ast.Expr) error
+	check = func(e ast.Expr) error {
+		switch e := e.(type) {
+		case *ast.Ident:
+			if _, ok := types.Universe.Lookup(e.Name).(*types.TypeName); ok || e.IsExported() {
+				return nil
+			}
+			return fmt.Errorf("unknown type %s: use a predeclared type such as string, int64 or bool, an exported type of the package such as Product, or a type of the %s packages", e.Name, typePackageList())
+		case *ast.StarExpr:
+			return check(e.X)
+		case *ast.ArrayType:
+			return check(e.Elt)
+		case *ast.MapType:
+			if err := check(e.Key); err != nil {
+				return err
+			}
+			return check(e.Value)
+		case *ast.SelectorExpr:
+			pkg, ok := e.X.(*ast.Ident)
+			if !ok || typePackages[pkg.Name] == "" {
+				return fmt.Errorf("unsupported package in type %q (supported: %s)", typ, typePackageList())
+			}
+			if !e.Sel.IsExported() {
+				return fmt.Errorf("%s.%s is not an exported type", pkg.Name, e.Sel.Name)
+			}
+			imports = append(imports, typePackages[pkg.Name])
+			return nil
+		case *ast.InterfaceType:
+			return nil
+		}
+		return fmt.Errorf("%q is not a Go type", typ)
+	}
+	if err := check(expr); err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// modelData is passed to the model template. Table is the table of the
+// model when it is not the one derived from its name.
+type modelData struct {
+	Name    string
+	Fields  []Field
+	Imports []string
+	Table   string
+}
+
+// GenerateModel writes models/<name>.go with a struct named after name
+// holding fields. An existing file is only overwritten when Force is set.
+func (p *Project) GenerateModel(ctx context.Context, name string, fields []Field) error {
+	if err := validateName("model", name); err != nil {
+		return err
+	}
+	if err := p.validateNaming(); err != nil {
+		return err
+	}
+
+	content, err := p.renderModel(name, fields)
+	if err != nil {
+		return err
+	}
+	return p.generate(ctx, func(g *generator) error {
+		return p.generateFile(g, "models/"+p.names(name).File+".go", content)
+	})
+}
+
+// renderModel renders the model file for name with fields, with a
+// TableName method if Table is set.
+func (p *Project) renderModel(name string, fields []Field) (string, error) {
+	data := modelData{Name: p.names(name).Model, Fields: fields, Table: p.Table}
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		imports, _ := typeImports(f.Type)
+		for _, imp := range imports {
+			if !seen[imp] {
+				seen[imp] = true
+				data.Imports = append(data.Imports, imp)
+			}
+		}
+	}
+	sort.Strings(data.Imports)
+
+	return renderGoTemplate("templates/generate/model.go.tmpl", data)
+}
+
+// controllerData is passed to the controller templates.
+type controllerData struct {
+	Name string
+	CRUD bool
+	// Var prefixes the names a CRUD controller declares, and Module is the
+	// project's module path, for importing pkg/pagination.
+	Var    string
+	Module string
+	// Model is set when Index lists models.<Name>, and Sortable holds the
+	// JSON names of the model fields Index may sort by.
+	Model    bool
+	Sortable []string
+	// APIErrors is set when the project has pkg/apierror and the
+	// ErrorHandler middleware, which Index reports invalid parameters
+	// through. Projects generated before they existed lack them.
+	APIErrors bool
+	// Path is the route the controller is mounted on in its test, and
+	// Handler the handler expression tested when CRUD is not set.
+	Path    string
+	Handler string
+	// Service is set when Show gets the model from the <Name>Service
+	// GenerateService passes the controller. IDType is the type of its ID,
+	// parsed with strconv.<IDParse> in IDBits bits unless it is a string,
+	// and NotFound the error for unknown IDs, declared in the package
+	// ErrImport unless that is models. Mocks is the tool the mock of the
+	// service is generated with, for the test of Show.
+	Service                                      bool
+	IDType, IDParse, IDBits, NotFound, ErrImport string
+	Mocks                                        string
+	// DTO is set when Create and Update bind a dto.<Name>Request and
+	// respond with a dto.<Name>Response, and Validate when they check the
+	// request with pkg/validate.
+	DTO, Validate bool
+}
+
+// GenerateController writes controller/<name>_controller.go for the
+// project's framework. With crud it holds a <Name>Controller type with
+// Index, Show, Create, Update and Delete handlers, Index paginating through
+// pkg/pagination, which is written if the project lacks it; otherwise a
+// single <Name>Controller handler like HomeController. An existing file is
+// only overwritten when Force is set.
+func (p *Project) GenerateController(ctx context.Context, name string, crud bool) error {
+	name = strings.TrimSuffix(name, "Controller")
+	if err := validateName("controller", name); err != nil {
+		return err
+	}
+	if err := p.validateNaming(); err != nil {
+		return err
+	}
+	if err := ValidateFramework(p.framework()); err != nil {
+		return err
+	}
+
+	names := p.names(name)
+	data := controllerData{Name: names.Model, CRUD: crud, Path: "/", Handler: names.Model + "Controller"}
+	var files []templateFile
+	if crud {
+		// Resources are served in the versioned API, if the router has one
+		data.Path = "/" + names.Plural
+		if rf, err := parseRouter(p.fs(), p.Root); err == nil {
+			data.Path = rf.v1Path() + data.Path
+		}
+		data.Var, data.Module = names.Var, p.Module
+		_, data.APIErrors = declaredIn(p.fs(), filepath.Join(p.Root, "middleware"), "ErrorHandler")
+		var err error
+		if files, err = p.paginationFiles(); err != nil {
+			return err
+		}
+	}
+	content, err := renderGoTemplate("templates/generate/controller/"+p.framework()+".go.tmpl", data)
+	if err != nil {
+		return err
+	}
+	base := "controller/" + names.File + "_controller"
+	files = append([]templateFile{{base + ".go", content}}, files...)
+	if p.WithTests {
+		test, err := renderGoTemplate("templates/generate/controller_test/"+p.framework()+".go.tmpl", data)
+		if err != nil {
+			return err
+		}
+		files = append(files, templateFile{base + "_test.go", test})
+	}
+	return p.generate(ctx, func(g *generator) error {
+		for _, f := range files {
+			if err := p.generateFile(g, f.path, f.content); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// paginationDir holds the package CRUD controllers paginate with.
+const paginationDir = "pkg/pagination"
+
+// paginationFiles returns pkg/pagination and its test when the project
+// lacks the package or Force is set, and nothing otherwise.
+func (p *Project) paginationFiles() ([]templateFile, error) {
+	if _, err := p.fs().Stat(filepath.Join(p.Root, filepath.FromSlash(paginationDir), "pagination.go")); err == nil && !p.Force {
+		return nil, nil
+	}
+	var files []templateFile
+	for _, name := range []string{"pagination.go", "pagination_test.go"} {
+		content, err := renderGoTemplate("templates/generate/pagination/"+name+".tmpl", nil)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, templateFile{paginationDir + "/" + name, content})
+	}
+	return files, nil
+}
+
+// sortableFields returns the JSON names of the fields whose values have an
+// order, which CRUD controllers may sort by.
+func sortableFields(fields []Field) []string {
+	var names []string
+	for _, f := range fields {
+		switch f.Type {
+		case "string", "bool", "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64",
+			"byte", "rune", "time.Time", "*time.Time":
+			names = append(names, f.JSONName())
+		}
+	}
+	return names
+}
+
+// routesData is passed to the route registration templates.
+type routesData struct {
+	// Name is the resource's Go name, e.g. "OrderItem".
+	Name string
+	// Var holds the controller, e.g. "orderItems".
+	Var string
+	// Path is the collection path, e.g. "/order_items".
+	Path string
+	// Router is the name of InitializeRoutes' router parameter.
+	Router string
+	// RequestID is set when the project has the RequestID middleware,
+	// which stdlib routes are wrapped in. Projects generated before it
+	// existed lack it.
+	RequestID bool
+	// Group is set when the routes go into AddV1Routes. The stdlib router
+	// wraps the v1 routes in middleware as a whole.
+	Group bool
+}
+
+// GenerateResource writes a model with fields and a CRUD controller for
+// name, whose Index paginates the models and sorts them by the fields with
+// an order, writes pkg/pagination if the project lacks it, and registers
+// GET, POST, PUT and DELETE routes for the controller in AddV1Routes, or
+// in InitializeRoutes in projects generated without a versioned API.
+// Routes that are already registered are left alone, and unless Force is
+// set, a resource whose model and routes both exist is skipped. With DTO,
+// dto/<name>.go is written too, and Create and Update bind its
+// <Name>Request and respond with its <Name>Response rather than the model.
+// In dry runs the router change is printed as a diff.
+func (p *Project) GenerateResource(ctx context.Context, name string, fields []Field) error {
+	if err := validateName("resource", name); err != nil {
+		return err
+	}
+	model, err := p.renderModel(name, fields)
+	if err != nil {
+		return err
+	}
+	return p.generateResource(ctx, name, model, fields, sortableFields(fields))
+}
+
+// generateResource is GenerateResource for the rendered model file of name,
+// with the fields the DTO maps and the JSON names of the sortable ones.
+func (p *Project) generateResource(ctx context.Context, name, model string, fields []Field, sortable []string) error {
+	if err := p.validateNaming(); err != nil {
+		return err
+	}
+	if err := ValidateFramework(p.framework()); err != nil {
+		return err
+	}
+
+	fsys := p.fs()
+	names := p.names(name)
+	var err error
+	cd := controllerData{
+		Name:     names.Model,
+		CRUD:     true,
+		Var:      names.Var,
+		Module:   p.Module,
+		Model:    true,
+		Sortable: sortable,
+	}
+	_, cd.APIErrors = declaredIn(fsys, filepath.Join(p.Root, "middleware"), "ErrorHandler")
+	var dto string
+	if p.DTO {
+		modelFields := make(map[string]string, len(fields))
+		for _, f := range fields {
+			modelFields[f.GoName()] = f.Type
+		}
+		data, _ := p.dtoData(cd.Name, cd.Name, fields, modelFields)
+		if file, ok := declaredIn(fsys, filepath.Join(p.Root, dtoDir), data.Request); ok && file != names.File+".go" {
+			return &ValidationError{Err: fmt.Errorf("%s is already declared in %s/%s", data.Request, dtoDir, file)}
+		}
+		if dto, err = renderGoTemplate("templates/generate/dto.go.tmpl", data); err != nil {
+			return err
+		}
+		cd.DTO, cd.Validate = true, data.Validate
+	}
+	ctrl, err := renderGoTemplate("templates/generate/controller/"+p.framework()+".go.tmpl", cd)
+	if err != nil {
+		return err
+	}
+
+	files := []templateFile{
+		{"models/" + names.File + ".go", model},
+		{"controller/" + names.File + "_controller.go", ctrl},
+	}
+	if p.DTO {
+		files = append(files, templateFile{dtoDir + "/" + names.File + ".go", dto})
+	}
+	rf, err := parseRouter(fsys, p.Root)
+	if err != nil {
+		return err
+	}
+	fn := rf.resourceFunc()
+	router, err := rf.routerVar(fn)
+	if err != nil {
+		return err
+	}
+	data := routesData{Name: names.Model, Var: names.Vars, Path: "/" + names.Plural, Router: router, Group: fn == rf.v1}
+	_, data.RequestID = declaredIn(fsys, filepath.Join(p.Root, "middleware"), "RequestID")
+	fullPath := rf.v1Path() + data.Path
+	registered := rf.hasRoute(fn, data.Path)
+
+	for _, f := range files {
+		if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(f.path))); err == nil && !p.Force {
+			if registered && f.path == files[0].path {
+				fmt.Fprintf(p.out(), "Resource %s already exists (%s, and routes for %s in %s), skipping.\n", names.Model, filepath.FromSlash(f.path), fullPath, filepath.FromSlash(routerPath))
+				return nil
+			}
+			return &ValidationError{Err: fmt.Errorf("%s already exists (use -force to overwrite it)", f.path)}
+		}
+	}
+	pagination, err := p.paginationFiles()
+	if err != nil {
+		return err
+	}
+	files = append(files, pagination...)
+
+	var routes []byte
+	if registered {
+		fmt.Fprintf(p.out(), "Routes for %s already exist in %s, skipping.\n", fullPath, filepath.FromSlash(routerPath))
+	} else {
+		stmts, err := renderTemplate(templates, "templates/generate/routes/"+p.framework()+".go.tmpl", data)
+		if err != nil {
+			return err
+		}
+		imports := []string{p.Module + "/controller"}
+		if p.framework() == "stdlib" && !data.Group {
+			imports = append(imports, "net/http", p.Module+"/middleware")
+		}
+		if routes, err = rf.appendTo(fn, stmts, imports...); err != nil {
+			return err
+		}
+	}
+
+	return p.generate(ctx, func(g *generator) error {
+		for _, f := range files {
+			if err := p.generateFile(g, f.path, f.content); err != nil {
+				return err
+			}
+		}
+		if routes == nil {
+			return nil
+		}
+		if p.DryRun {
+			fmt.Fprint(p.out(), unifiedDiff(routerPath, string(rf.src), string(routes)))
+		}
+		if err := g.updateFile(routerPath, string(routes)); err != nil {
+			return err
+		}
+		if !p.DryRun {
+			fmt.Fprintf(p.out(), "Registered %s routes in %s\n", fullPath, filepath.FromSlash(routerPath))
+		}
+		return nil
+	})
+}
+
+// middlewareUse maps frameworks to the expression registering a generated
+// middleware with <router>.Use. The stdlib mux has no Use method.
+var middlewareUse = map[string]string{
+	"gin":   "middleware.%s()",
+	"echo":  "middleware.%s()",
+	"fiber": "middleware.%s()",
+	"chi":   "middleware.%s",
+}
+
+// validateMiddleware checks the names of Middleware for Create. The
+// frameworks they can be registered in are checked by CheckOptions.
+func (p *Project) validateMiddleware() error {
+	if len(p.Middleware) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, name := range p.Middleware {
+		if err := validateName("middleware", name); err != nil {
+			return err
+		}
+		if seen[camelCase(name)] {
+			return fmt.Errorf("middleware %s is listed twice", camelCase(name))
+		}
+		seen[camelCase(name)] = true
+	}
+	return nil
+}
+
+// addMiddleware returns the files of a new project with a file for each
+// of Middleware added, and the router edited to register them.
+func (p *Project) addMiddleware(files []templateFile, framework, module string) ([]templateFile, error) {
+	if len(p.Middleware) == 0 {
+		return files, nil
+	}
+	router := -1
+	for i, f := range files {
+		if f.path == routerPath {
+			router = i
+		}
+	}
+	if router < 0 {
+		return nil, fmt.Errorf("%s not found", routerPath)
+	}
+	for _, name := range p.Middleware {
+		funcName, rel := camelCase(name), "middleware/"+snakeCase(name)+".go"
+		for _, f := range files {
+			if f.path == rel {
+				return nil, &ValidationError{Err: fmt.Errorf("%s is already generated for the project", rel)}
+			}
+			if strings.HasPrefix(f.path, "middleware/") && strings.Contains(f.content, "\nfunc "+funcName+"(") {
+				return nil, &ValidationError{Err: fmt.Errorf("%s is already declared in %s", funcName, f.path)}
+			}
+		}
+		content, err := renderGoTemplate("templates/generate/middleware/"+framework+".go.tmpl", struct{ Name string }{funcName})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, templateFile{path: rel, content: content})
+
+		rf, err := parseRouterSource([]byte(files[router].content))
+		if err != nil {
+			return nil, err
+		}
+		v, err := rf.routerVar(rf.setup)
+		if err != nil {
+			return nil, err
+		}
+		src, err := rf.insertMiddleware(v, v+".Use("+fmt.Sprintf(middlewareUse[framework], funcName)+")", module+"/middleware")
+		if err != nil {
+			return nil, err
+		}
+		files[router].content = string(src)
+	}
+	return files, nil
+}
+
+// GenerateMiddleware writes middleware/<name>.go with a pass-through
+// middleware function for the project's framework. With register it is
+// also added to InitializeRoutes with a Use call.
+func (p *Project) GenerateMiddleware(ctx context.Context, name string, register bool) error {
+	if err := validateName("middleware", name); err != nil {
+		return err
+	}
+	if err := ValidateFramework(p.framework()); err != nil {
+		return err
+	}
+	funcName := camelCase(name)
+	rel := "middleware/" + snakeCase(name) + ".go"
+
+	// The function must not clash with another declaration in the package
+	if file, ok := declaredIn(p.fs(), filepath.Join(p.Root, "middleware"), funcName); ok && (file != filepath.Base(rel) || !p.Force) {
+		return &ValidationError{Err: fmt.Errorf("%s is already declared in middleware/%s", funcName, file)}
+	}
+
+	content, err := renderGoTemplate("templates/generate/middleware/"+p.framework()+".go.tmpl", struct{ Name string }{funcName})
+	if err != nil {
+		return err
+	}
+
+	var routes []byte
+	var rf *routerFile
+	if register {
+		use, ok := middlewareUse[p.framework()]
+		if !ok {
+			return fmt.Errorf("-register is not supported for %s projects: wrap handlers with middleware.%s in %s instead", p.framework(), funcName, routerPath)
+		}
+		if rf, err = parseRouter(p.fs(), p.Root); err != nil {
+			return err
+		}
+		router, err := rf.routerVar(rf.setup)
+		if err != nil {
+			return err
+		}
+		if rf.references("middleware", funcName) {
+			fmt.Fprintf(p.out(), "middleware.%s is already registered in %s, skipping.\n", funcName, filepath.FromSlash(routerPath))
+		} else {
+			stmt := router + ".Use(" + fmt.Sprintf(use, funcName) + ")"
+			if routes, err = rf.insertMiddleware(router, stmt, p.Module+"/middleware"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return p.generate(ctx, func(g *generator) error {
+		if err := p.generateFile(g, rel, content); err != nil {
+			return err
+		}
+		if routes == nil {
+			return nil
+		}
+		if p.DryRun {
+			fmt.Fprint(p.out(), unifiedDiff(routerPath, string(rf.src), string(routes)))
+		}
+		if err := g.updateFile(routerPath, string(routes)); err != nil {
+			return err
+		}
+		if !p.DryRun {
+			fmt.Fprintf(p.out(), "Registered middleware.%s in %s\n", funcName, filepath.FromSlash(routerPath))
+		}
+		return nil
+	})
+}
+
+// sseData is passed to the SSE controller templates.
+type sseData struct {
+	// Name is the controller's Go name without the Controller suffix.
+	Name string
+	// Module is the project's module path, for importing pkg/sse.
+	Module string
+	// Path is the route the events are streamed at, e.g. "/events".
+	Path string
+}
+
+// sseBroker is the broker package shared by every SSE controller.
+const sseBroker = "pkg/sse/broker.go"
+
+// GenerateSSE writes controller/<name>_controller.go, whose
+// <Name>Controller streams the events of a broker as Server-Sent Events,
+// and a test for it, and registers its Stream handler for GET path in
+// InitializeRoutes. The broker package pkg/sse is written too unless the
+// project has it already. A route that is already registered is left
+// alone. In dry runs the router change is printed as a diff.
+func (p *Project) GenerateSSE(ctx context.Context, name, path string) error {
+	name = strings.TrimSuffix(name, "Controller")
+	if err := validateName("controller", name); err != nil {
+		return err
+	}
+	if err := ValidateFramework(p.framework()); err != nil {
+		return err
+	}
+	if err := validatePath("path", path); err != nil {
+		return err
+	}
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return fmt.Errorf("the events cannot be streamed at /, which serves the home page")
+	}
+
+	data := sseData{Name: camelCase(name), Module: p.Module, Path: path}
+	base := "controller/" + snakeCase(name) + "_controller"
+	files := []templateFile{}
+	for _, f := range []struct{ rel, tmpl string }{
+		{base + ".go", "controller/"},
+		{base + "_test.go", "controller_test/"},
+	} {
+		content, err := renderGoTemplate("templates/generate/sse/"+f.tmpl+p.framework()+".go.tmpl", data)
+		if err != nil {
+			return err
+		}
+		files = append(files, templateFile{f.rel, content})
+	}
+
+	fsys := p.fs()
+	if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(sseBroker))); err != nil || p.Force {
+		for _, name := range []string{"broker.go", "broker_test.go"} {
+			content, err := renderGoTemplate("templates/generate/sse/"+name+".tmpl", data)
+			if err != nil {
+				return err
+			}
+			files = append(files, templateFile{"pkg/sse/" + name, content})
+		}
+	}
+	for _, f := range files[:2] {
+		if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(f.path))); err == nil && !p.Force {
+			return &ValidationError{Err: fmt.Errorf("%s already exists (use -force to overwrite it)", f.path)}
+		}
+	}
+
+	rf, err := parseRouter(fsys, p.Root)
+	if err != nil {
+		return err
+	}
+	router, err := rf.routerVar(rf.setup)
+	if err != nil {
+		return err
+	}
+	var routes []byte
+	if rf.hasRoute(rf.setup, path) {
+		fmt.Fprintf(p.out(), "A route for %s already exists in %s, skipping.\n", path, filepath.FromSlash(routerPath))
+	} else {
+		rd := routesData{Name: data.Name, Var: lowerCamelCase(name), Path: path, Router: router}
+		_, rd.RequestID = declaredIn(fsys, filepath.Join(p.Root, "middleware"), "RequestID")
+		stmts, err := renderTemplate(templates, "templates/generate/sse/routes/"+p.framework()+".go.tmpl", rd)
+		if err != nil {
+			return err
+		}
+		imports := []string{p.Module + "/controller", p.Module + "/pkg/sse"}
+		if p.framework() == "stdlib" {
+			imports = append(imports, "net/http", p.Module+"/middleware")
+		}
+		if routes, err = rf.appendTo(rf.setup, stmts, imports...); err != nil {
+			return err
+		}
+	}
+
+	return p.generate(ctx, func(g *generator) error {
+		for _, f := range files {
+			if err := p.generateFile(g, f.path, f.content); err != nil {
+				return err
+			}
+		}
+		if routes == nil {
+			return nil
+		}
+		if p.DryRun {
+			fmt.Fprint(p.out(), unifiedDiff(routerPath, string(rf.src), string(routes)))
+		}
+		if err := g.updateFile(routerPath, string(routes)); err != nil {
+			return err
+		}
+		if !p.DryRun {
+			fmt.Fprintf(p.out(), "Registered GET %s in %s\n", path, filepath.FromSlash(routerPath))
+		}
+		return nil
+	})
+}
+
+// migrationsDir holds the golang-migrate migrations of SQL projects.
+const migrationsDir = "migrations"
+
+// migrationVersionLayout formats the version of generated migrations: the
+// UTC creation time, so file names sort in the order they were created.
+const migrationVersionLayout = "20060102150405"
+
+// GenerateMigration writes an empty migrations/<version>_<name>.up.sql and
+// .down.sql pair, using the current time as the version.
+func (p *Project) GenerateMigration(ctx context.Context, name string) error {
+	if err := validateName("migration", name); err != nil {
+		return err
+	}
+	name = snakeCase(name)
+	version := time.Now().UTC().Format(migrationVersionLayout)
+
+	// golang-migrate refuses two migrations with the same version
+	if file, ok := migrationWithVersion(p.fs(), filepath.Join(p.Root, migrationsDir), version); ok {
+		return &ValidationError{Err: fmt.Errorf("%s/%s already has version %s: wait a second and try again", migrationsDir, file, version)}
+	}
+
+	base := migrationsDir + "/" + version + "_" + name
+	return p.generate(ctx, func(g *generator) error {
+		for _, direction := range []string{"up", "down"} {
+			content := fmt.Sprintf("-- %s (%s)\n", name, direction)
+			if err := p.generateFile(g, base+"."+direction+".sql", content); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GenerateTableMigration is GenerateMigration for the migration creating
+// the table of model, named create_<table>, e.g. create_people for Person,
+// with the dot of a schema-qualified table replaced by an underscore.
+func (p *Project) GenerateTableMigration(ctx context.Context, model string) error {
+	if err := validateName("model", model); err != nil {
+		return err
+	}
+	if err := p.validateNaming(); err != nil {
+		return err
+	}
+	return p.GenerateMigration(ctx, "create_"+strings.ReplaceAll(p.names(model).Table, ".", "_"))
+}
+
+// migrationWithVersion returns the name of a migration in dir with the
+// given version, if there is one.
+func migrationWithVersion(fsys FS, dir, version string) (string, bool) {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if v, _, ok := strings.Cut(entry.Name(), "_"); ok && v == version && strings.HasSuffix(entry.Name(), ".sql") {
+			return entry.Name(), true
+		}
+	}
+	return "", false
+}
+
+// declaredIn reports the name of the Go file in dir that declares name at
+// the top level, if any.
+func declaredIn(fsys FS, dir, name string) (string, bool) {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		src, err := fsys.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), entry.Name(), src, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.Name == name {
+					return entry.Name(), true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if spec.Name.Name == name {
+							return entry.Name(), true
+						}
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							if id.Name == name {
+								return entry.Name(), true
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// renderGoTemplate renders the embedded template at name and formats the
+// result as Go source.
+func renderGoTemplate(name string, data any) (string, error) {
+	content, err := renderTemplate(templates, name, data)
+	if err != nil {
+		return "", err
+	}
+	src, err := format.Source([]byte(content))
+	if err != nil {
+		return "", fmt.Errorf("format %s: %v", name, err)
+	}
+	return string(src), nil
+}
+
+// generate runs fn with a generator for the existing project, which must
+// have the default layout, and records the files it creates in the project
+// manifest.
+func (p *Project) generate(ctx context.Context, fn func(g *generator) error) error {
+	if err := p.requireDefaultLayout(); err != nil {
+		return err
+	}
+	return p.generateIn(ctx, fn)
+}
+
+// generateIn is generate for generators supporting other layouts, which
+// check the layout themselves.
+func (p *Project) generateIn(ctx context.Context, fn func(g *generator) error) error {
+	fsys, runner := p.effects()
+	m, err := readManifest(fsys, p.Root)
+	if err == ErrNoManifest {
+		m = &manifest{Module: p.Module, Framework: p.framework()}
+	} else if err != nil {
+		return err
+	}
+
+	g := &generator{
+		ctx:       ctx,
+		root:      p.Root,
+		fs:        fsys,
+		runner:    runner,
+		manifest:  *m,
+		overwrite: p.Force,
+		dirMode:   p.dirMode(),
+		fileMode:  p.fileMode(),
+		crlf:      m.Options.lineEndings() == "crlf",
+		backup:    p.newBackup(),
+	}
+	if err == ErrNoManifest {
+		g.crlf = p.lineEndings() == "crlf"
+	}
+	defer g.backup.report(p.out())
+	if err := fn(g); err != nil {
+		return err
+	}
+	if !g.changed {
+		return nil
+	}
+	return writeManifest(fsys, p.Root, &g.manifest)
+}
+
+// generateFile writes rel with content, refusing to replace an existing
+// file unless Force is set. Files already holding content are left alone.
+func (p *Project) generateFile(g *generator, rel, content string) error {
+	_, err := g.fs.Stat(filepath.Join(p.Root, filepath.FromSlash(rel)))
+	exists := !errors.Is(err, os.ErrNotExist)
+	if exists && g.upToDate(rel, content) {
+		if !p.DryRun {
+			fmt.Fprintf(p.out(), "Unchanged %s\n", filepath.FromSlash(rel))
+		}
+		return nil
+	}
+	if exists && !p.Force {
+		return &ValidationError{Err: fmt.Errorf("%s already exists (use -force to overwrite it)", filepath.FromSlash(rel))}
+	}
+	if err := g.createFile(rel, content); err != nil {
+		return err
+	}
+	if !p.DryRun {
+		if exists {
+			fmt.Fprintf(p.out(), "Overwrote %s\n", filepath.FromSlash(rel))
+		} else {
+			fmt.Fprintf(p.out(), "Created %s\n", filepath.FromSlash(rel))
+		}
+	}
+	return nil
+}
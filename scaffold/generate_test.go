@@ -0,0 +1,161 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTypeImports(t *testing.T) {
+	tests := []struct {
+		typ     string
+		imports []string
+		ok      bool
+	}{
+		{"string", nil, true},
+		{"int64", nil, true},
+		{"any", nil, true},
+		{"[]byte", nil, true},
+		{"*Product", nil, true},
+		{"map[string][]Tag", nil, true},
+		{"interface{}", nil, true},
+		{"time.Time", []string{"time"}, true},
+		{"map[string]json.RawMessage", []string{"encoding/json"}, true},
+		{"*sql.NullString", []string{"database/sql"}, true},
+		{"notatype", nil, false},
+		{"[]product", nil, false},
+		{"map[strin]int", nil, false},
+		{"time.now", nil, false},
+		{"uuid.UUID", nil, false},
+		{"func()", nil, false},
+		{"[]", nil, false},
+	}
+	for _, tt := range tests {
+		imports, err := typeImports(tt.typ)
+		if (err == nil) != tt.ok {
+			t.Errorf("typeImports(%q) error = %v, want ok %v", tt.typ, err, tt.ok)
+			continue
+		}
+		if !slices.Equal(imports, tt.imports) {
+			t.Errorf("typeImports(%q) = %q, want %q", tt.typ, imports, tt.imports)
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields([]string{"name:string", "created_at:time.Time"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Field{{Name: "name", Type: "string"}, {Name: "created_at", Type: "time.Time"}}
+	if !slices.Equal(fields, want) {
+		t.Errorf("ParseFields = %v, want %v", fields, want)
+	}
+	for _, args := range [][]string{
+		{"name"},
+		{"name:"},
+		{"name:notatype"},
+		{"9name:string"},
+		{"name:string", "Name:int"},
+	} {
+		if _, err := ParseFields(args); err == nil {
+			t.Errorf("ParseFields(%q) succeeded", args)
+		}
+	}
+}
+
+// TestMiddlewareTemplates renders the middleware of every framework and
+// checks that it declares the function. The stdlib one is type-checked
+// too; those of the other frameworks import their modules, so they are
+// vetted by TestGeneratedMiddlewareVet of the integration tests.
+func TestMiddlewareTemplates(t *testing.T) {
+	for _, fw := range Frameworks() {
+		name := "templates/generate/middleware/" + fw + ".go.tmpl"
+		src, err := renderGoTemplate(name, struct{ Name string }{"Timer"})
+		if err != nil {
+			t.Errorf("%s: %v", fw, err)
+			continue
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "timer.go", src, 0)
+		if err != nil {
+			t.Errorf("%s: %v", fw, err)
+			continue
+		}
+		if obj := file.Scope.Lookup("Timer"); obj == nil || obj.Kind != ast.Fun {
+			t.Errorf("%s: Timer is not declared:\n%s", fw, src)
+		}
+		if fw != "stdlib" {
+			continue
+		}
+		conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+		if _, err := conf.Check("middleware", fset, []*ast.File{file}, nil); err != nil {
+			t.Errorf("%s: %v", fw, err)
+		}
+	}
+}
+
+// TestGenerateResourceAgain checks that generating a resource a second
+// time skips it, and that with -force only the files whose content
+// changes are overwritten and backed up.
+func TestGenerateResourceAgain(t *testing.T) {
+	p := createdProject(t, nil)
+	ctx := context.Background()
+	fields := []Field{{Name: "name", Type: "string"}}
+	if err := p.GenerateResource(ctx, "Person", fields); err != nil {
+		t.Fatalf("GenerateResource: %v", err)
+	}
+	router := filepath.Join(p.Root, filepath.FromSlash(routerPath))
+	before, err := p.FS.ReadFile(router)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	p.Out = &out
+	if err := p.GenerateResource(ctx, "Person", fields); err != nil {
+		t.Fatalf("GenerateResource again: %v", err)
+	}
+	if !strings.Contains(out.String(), "Resource Person already exists") {
+		t.Errorf("output does not report the resource exists:\n%s", out.String())
+	}
+	if after, _ := p.FS.ReadFile(router); !bytes.Equal(after, before) {
+		t.Errorf("%s changed:\n%s", routerPath, after)
+	}
+
+	out.Reset()
+	p.Force = true
+	p.BackupDir = "/backups"
+	model := filepath.Join(p.Root, "models", "person.go")
+	if err := p.FS.WriteFile(model, []byte("package models\n\ntype Person struct{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.GenerateResource(ctx, "Person", fields); err != nil {
+		t.Fatalf("GenerateResource -force: %v", err)
+	}
+	for _, want := range []string{
+		"Overwrote " + filepath.FromSlash("models/person.go"),
+		"Unchanged " + filepath.FromSlash("controller/person_controller.go"),
+		"Unchanged " + filepath.FromSlash(paginationDir+"/pagination.go"),
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	entries, _ := p.FS.ReadDir("/backups")
+	if len(entries) != 1 {
+		t.Fatalf("%d backups, want 1", len(entries))
+	}
+	files := backupFiles(t, p.FS, filepath.Join("/backups", entries[0].Name()))
+	if len(files) != 1 || files["models/person.go"] == "" {
+		t.Errorf("backup holds %q, want models/person.go only", files)
+	}
+}
@@ -0,0 +1,56 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// initialCommitMessage is the message of the commit Create makes with Git.
+const initialCommitMessage = "scaffolded with gomvc"
+
+// initGit makes the project a git repository with the generated files as
+// its initial commit. It leaves the project alone when git is not installed
+// or the project is inside a repository already, and only initializes the
+// repository, without committing, when the directory held files before
+// Create.
+func (p *Project) initGit(ctx context.Context, runner Runner, hadFiles bool) error {
+	// A dry run shows every step, as the checks cannot run
+	if !p.DryRun {
+		err := runner.Run(ctx, p.Root, "git", "rev-parse", "--is-inside-work-tree")
+		if errors.Is(err, exec.ErrNotFound) {
+			fmt.Fprintln(p.out(), "Skipped git init: git is not installed")
+			p.reportWarning("skipped git init: git is not installed")
+			return nil
+		}
+		if err == nil {
+			fmt.Fprintln(p.out(), "Skipped git init: the project is inside a git repository")
+			return nil
+		}
+	}
+
+	if err := runner.Run(ctx, p.Root, "git", "init", "--quiet"); err != nil {
+		return fmt.Errorf("failed to initialize git repository: %w", err)
+	}
+	if hadFiles {
+		if !p.DryRun {
+			fmt.Fprintln(p.out(), "Initialized git repository without committing, as the directory already held files")
+		}
+		return nil
+	}
+	if err := runner.Run(ctx, p.Root, "git", "add", "--all"); err != nil {
+		return fmt.Errorf("failed to stage the generated files: %w", err)
+	}
+	// Committing fails without a git identity, which is no reason to fail
+	// the whole project
+	if err := runner.Run(ctx, p.Root, "git", "commit", "--quiet", "--message", initialCommitMessage); err != nil {
+		fmt.Fprintf(p.out(), "Initialized git repository, but the initial commit failed (%v); set git config user.name and user.email, then commit the files\n", err)
+		p.reportWarning(fmt.Sprintf("the initial commit failed: %v", err))
+		return nil
+	}
+	if !p.DryRun {
+		fmt.Fprintln(p.out(), "Initialized git repository with an initial commit")
+	}
+	return nil
+}
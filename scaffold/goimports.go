@@ -0,0 +1,43 @@
+package scaffold
+
+import (
+	"path"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/tools/imports"
+)
+
+// goimportsMu guards imports.LocalPrefix, which goimports reads from a
+// package variable rather than from its options. formatGo restores it
+// before unlocking, so that other users of the package in the same
+// process see their own value.
+var goimportsMu sync.Mutex
+
+// formatGo returns content, the Go file rel of the module at root,
+// formatted by goimports: gofmt-formatted, without the imports nothing
+// uses, and with the imports of each block sorted into groups, those of
+// the standard library first and those of module last. Other files, and Go
+// files that goimports cannot format, such as those that do not parse, are
+// returned unchanged, so that go build reports what is wrong with them.
+func formatGo(root, rel, content, module string) string {
+	if path.Ext(rel) != ".go" {
+		return content
+	}
+	goimportsMu.Lock()
+	prefix := imports.LocalPrefix
+	imports.LocalPrefix = module
+	defer func() {
+		imports.LocalPrefix = prefix
+		goimportsMu.Unlock()
+	}()
+	src, err := imports.Process(filepath.Join(root, filepath.FromSlash(rel)), []byte(content), &imports.Options{
+		Comments:  true,
+		TabIndent: true,
+		TabWidth:  8,
+	})
+	if err != nil {
+		return content
+	}
+	return string(src)
+}
@@ -0,0 +1,169 @@
+package scaffold
+
+import (
+	"context"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/imports"
+)
+
+func TestFormatGo(t *testing.T) {
+	tests := []struct {
+		name, rel, content, want string
+	}{
+		{
+			name: "unused import",
+			rel:  "main.go",
+			content: `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() { fmt.Println() }
+`,
+			want: `package main
+
+import (
+	"fmt"
+)
+
+func main() { fmt.Println() }
+`,
+		},
+		{
+			name: "groups",
+			rel:  "router/router.go",
+			content: `package router
+
+import (
+	"example.com/app/controller"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+var _ = []any{controller.Home, chi.NewRouter, http.StatusOK}
+`,
+			want: `package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/app/controller"
+)
+
+var _ = []any{controller.Home, chi.NewRouter, http.StatusOK}
+`,
+		},
+		{
+			name:    "does not parse",
+			rel:     "main.go",
+			content: "package main\nfunc {\n",
+			want:    "package main\nfunc {\n",
+		},
+		{
+			name:    "not Go",
+			rel:     "README.md",
+			content: "import (  \"os\" )\n",
+			want:    "import (  \"os\" )\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatGo("/work/app", tt.rel, tt.content, "example.com/app"); got != tt.want {
+				t.Errorf("formatGo:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatGoModules creates projects of different modules side by side
+// and checks that the imports of each module, and only those, are grouped
+// last in their files, and that imports.LocalPrefix is left as it was.
+func TestFormatGoModules(t *testing.T) {
+	t.Run("modules", func(t *testing.T) {
+		for _, module := range []string{"example.com/app", "github.com/org/shop"} {
+			t.Run(module, func(t *testing.T) {
+				t.Parallel()
+				p := createdProject(t, func(p *Project) { p.Module = module })
+				for _, rel := range []string{"router/router.go", "cmd/api/main.go"} {
+					src, err := p.FS.ReadFile(filepath.Join(p.Root, filepath.FromSlash(rel)))
+					if err != nil {
+						t.Fatal(err)
+					}
+					groups := importGroups(t, rel, src)
+					if len(groups) < 2 {
+						t.Fatalf("%s has %d import groups, want the module's last:\n%s", rel, len(groups), src)
+					}
+					for i, group := range groups {
+						for _, imp := range group {
+							if local := strings.HasPrefix(imp, module+"/"); local != (i == len(groups)-1) {
+								t.Errorf("%s: %s is in import group %d of %d:\n%s", rel, imp, i+1, len(groups), src)
+							}
+						}
+					}
+				}
+			})
+		}
+	})
+	if imports.LocalPrefix != "" {
+		t.Errorf("imports.LocalPrefix = %q, want it restored", imports.LocalPrefix)
+	}
+}
+
+// importGroups returns the import paths of the Go file src, in groups
+// separated by blank lines.
+func importGroups(t *testing.T, rel string, src []byte) [][]string {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, src, parser.ImportsOnly)
+	if err != nil {
+		t.Fatalf("%s does not parse: %v", rel, err)
+	}
+	var groups [][]string
+	line := 0
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		if l := fset.Position(imp.Pos()).Line; len(groups) == 0 || l > line+1 {
+			groups = append(groups, nil)
+		}
+		line = fset.Position(imp.End()).Line
+		groups[len(groups)-1] = append(groups[len(groups)-1], p)
+	}
+	return groups
+}
+
+// TestGoFilesFormatted creates every project of projectCombos and checks
+// that the Go files written are gofmt-formatted.
+func TestGoFilesFormatted(t *testing.T) {
+	if testing.Short() {
+		t.Skip("creates hundreds of projects")
+	}
+	for _, c := range projectCombos(t) {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.p.Create(context.Background()); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			for rel, src := range goFiles(c.p) {
+				src := []byte(toLF(string(src)))
+				formatted, err := format.Source(src)
+				if err != nil {
+					t.Errorf("%s: %v", rel, err)
+					continue
+				}
+				if string(formatted) != string(src) {
+					t.Errorf("%s is not formatted:\n%s", rel, unifiedDiff(rel, string(src), string(formatted)))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultAPI is the API style used when none is given: REST routes only.
+const DefaultAPI = "rest"
+
+// apiStyles lists the styles of API a project can serve, in sorted order.
+// "graphql" keeps the REST routes and adds the template layer "graphql"
+// with a gqlgen schema and resolvers, served at /query.
+var apiStyles = []string{"graphql", "rest"}
+
+// gqlgenRequire is the gqlgen module, which the generated code imports and
+// "make gqlgen" runs.
+const gqlgenRequire = "github.com/99designs/gqlgen@v0.17.95"
+
+// gqlgenOutput lists the files "gqlgen generate" writes besides the
+// resolvers, which gomvc writes itself.
+var gqlgenOutput = []string{"graph/generated.go", "graph/model/models_gen.go"}
+
+// APIStyles returns the supported API styles in sorted order.
+func APIStyles() []string {
+	return slices.Clone(apiStyles)
+}
+
+// ValidateAPI returns an error unless name is a supported API style.
+func ValidateAPI(name string) error {
+	if !slices.Contains(apiStyles, name) {
+		return fmt.Errorf("unknown API style %q (supported: %s)", name, strings.Join(apiStyles, ", "))
+	}
+	return nil
+}
+
+// api returns the project's API style, applying the default.
+func (p *Project) api() string {
+	if p.API == "" {
+		return DefaultAPI
+	}
+	return p.API
+}
@@ -0,0 +1,121 @@
+package scaffold
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// grpcRequires lists the modules of -grpc. The generated code in gen/
+// matches protoc-gen-go of the protobuf version and protoc-gen-go-grpc
+// v1.6.0, which buf.gen.yaml pins too.
+var grpcRequires = []string{"google.golang.org/grpc@v1.79.0", "google.golang.org/protobuf@v1.36.11"}
+
+// serviceData is passed to the templates of the sample gRPC service.
+type serviceData struct {
+	TemplateData
+	// Descriptor is the Go string literal of the serialized descriptor of
+	// service.proto, see serviceDescriptor.
+	Descriptor string
+}
+
+// protoPackage returns the protobuf package of the project's services,
+// without the version: the project name in lower case, with anything but
+// letters and digits dropped. It must be a valid identifier, so names that
+// end up empty or starting with a digit get an "app" prefix.
+func protoPackage(projectName string) string {
+	name := strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(projectName))
+	if name == "" || name[0] <= '9' {
+		name = "app" + name
+	}
+	return name
+}
+
+// grpcServiceFiles renders proto/<package>/v1/service.proto and the Go code
+// protoc-gen-go and protoc-gen-go-grpc generate from it into gen/, so the
+// project builds before "make proto" first runs. Their paths depend on the
+// package, so they are not part of a template layer.
+func grpcServiceFiles(data TemplateData) ([]templateFile, error) {
+	dir := data.ProtoPackage + "/v1/"
+	proto, err := renderTemplate(templates, "templates/grpc/service/service.proto.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+	files := []templateFile{{"proto/" + dir + "service.proto", proto}}
+
+	sd := serviceData{TemplateData: data, Descriptor: serviceDescriptor(data.ProtoPackage, data.Module)}
+	for _, name := range []string{"service.pb.go", "service_grpc.pb.go"} {
+		content, err := renderGoTemplate("templates/grpc/service/"+name+".tmpl", sd)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, templateFile{"gen/" + dir + name, content})
+	}
+	return files, nil
+}
+
+// serviceDescriptor returns the FileDescriptorProto of service.proto in
+// the protobuf wire format, as the string literal protoc-gen-go embeds in
+// service.pb.go: one line per newline byte. It must describe the messages
+// and service of grpc/service/service.proto.tmpl exactly, or the generated
+// code panics on init.
+func serviceDescriptor(pkg, module string) string {
+	field := func(name string) []byte {
+		f := appendProtoBytes(nil, 1, name)
+		f = appendProtoVarint(f, 3, 1)       // number
+		f = appendProtoVarint(f, 4, 1)       // label: LABEL_OPTIONAL
+		f = appendProtoVarint(f, 5, 9)       // type: TYPE_STRING
+		return appendProtoBytes(f, 10, name) // json_name
+	}
+	message := func(name, fieldName string) string {
+		m := appendProtoBytes(nil, 1, name)
+		return string(appendProtoBytes(m, 2, string(field(fieldName))))
+	}
+	method := appendProtoBytes(nil, 1, "SayHello")
+	method = appendProtoBytes(method, 2, "."+pkg+".v1.SayHelloRequest")
+	method = appendProtoBytes(method, 3, "."+pkg+".v1.SayHelloResponse")
+	service := appendProtoBytes(nil, 1, "GreeterService")
+	service = appendProtoBytes(service, 2, string(method))
+	options := appendProtoBytes(nil, 11, module+"/gen/"+pkg+"/v1;"+pkg+"v1") // go_package
+
+	desc := appendProtoBytes(nil, 1, pkg+"/v1/service.proto")
+	desc = appendProtoBytes(desc, 2, pkg+".v1")
+	desc = appendProtoBytes(desc, 4, message("SayHelloRequest", "name"))
+	desc = appendProtoBytes(desc, 4, message("SayHelloResponse", "message"))
+	desc = appendProtoBytes(desc, 6, string(service))
+	desc = appendProtoBytes(desc, 8, string(options))
+	desc = appendProtoBytes(desc, 12, "proto3") // syntax
+
+	var b strings.Builder
+	b.WriteString(`"" +`)
+	for rest := string(desc); rest != ""; {
+		line, after, found := strings.Cut(rest, "\n")
+		if found {
+			line += "\n"
+		}
+		b.WriteString("\n\t" + strconv.Quote(line))
+		if after != "" {
+			b.WriteString(" +")
+		}
+		rest = after
+	}
+	return b.String()
+}
+
+// appendProtoBytes appends field num of the length-delimited wire type.
+func appendProtoBytes(b []byte, num int, value string) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// appendProtoVarint appends field num of the varint wire type.
+func appendProtoVarint(b []byte, num int, value uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3)
+	return binary.AppendUvarint(b, value)
+}
@@ -0,0 +1,9 @@
+package scaffold
+
+// testcontainersRequires are the modules of testcontainers-go, which
+// internal/testutil starts Postgres in a container with for the
+// integration tests of projects using it.
+var testcontainersRequires = []string{
+	"github.com/testcontainers/testcontainers-go@v0.44.0",
+	"github.com/testcontainers/testcontainers-go/modules/postgres@v0.44.0",
+}
@@ -0,0 +1,310 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonShape is what the values found at one place of a JSON sample have in
+// common: the kinds they have, the keys of the objects among them and the
+// shape of the items of the arrays.
+type jsonShape struct {
+	// kinds holds "null", "string", "int", "float", "bool", "object" and
+	// "array".
+	kinds map[string]bool
+	// keys are the keys of the objects in the order they first appear,
+	// fields their shapes and seen how many of the objects have them.
+	keys    []string
+	fields  map[string]*jsonShape
+	seen    map[string]int
+	objects int
+	items   *jsonShape
+}
+
+// add merges the value n into s.
+func (s *jsonShape) add(n *docNode) {
+	if s.kinds == nil {
+		s.kinds = make(map[string]bool)
+	}
+	switch n.kind {
+	case docNull:
+		s.kinds["null"] = true
+	case docScalar:
+		switch n.jsonType {
+		case "number":
+			// Numbers written without a fraction or exponent are taken
+			// for integers
+			if strings.ContainsAny(n.value, ".eE") {
+				s.kinds["float"] = true
+			} else {
+				s.kinds["int"] = true
+			}
+		case "boolean":
+			s.kinds["bool"] = true
+		default:
+			s.kinds["string"] = true
+		}
+	case docMapping:
+		s.kinds["object"] = true
+		if s.fields == nil {
+			s.fields = make(map[string]*jsonShape)
+			s.seen = make(map[string]int)
+		}
+		s.objects++
+		for _, key := range n.keys {
+			if s.fields[key] == nil {
+				s.keys = append(s.keys, key)
+				s.fields[key] = &jsonShape{}
+			}
+			s.fields[key].add(n.fields[key])
+			s.seen[key]++
+		}
+	case docSequence:
+		s.kinds["array"] = true
+		if s.items == nil {
+			s.items = &jsonShape{}
+		}
+		for _, item := range n.items {
+			s.items.add(item)
+		}
+	}
+}
+
+// kindList returns the kinds of s other than null, with integers counted
+// as floats when both occur.
+func (s *jsonShape) kindList() []string {
+	var kinds []string
+	for kind := range s.kinds {
+		if kind == "null" || kind == "int" && s.kinds["float"] {
+			continue
+		}
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// jsonKindNames are the names of the kinds of a jsonShape in messages.
+var jsonKindNames = map[string]string{
+	"string": "strings", "int": "integers", "float": "numbers", "bool": "booleans",
+	"object": "objects", "array": "arrays",
+}
+
+// jsonModel infers the types of a model from a JSON sample.
+type jsonModel struct {
+	file apiModelFile
+	// names are the type names taken, and open the fields typed any as
+	// the sample leaves their type open, e.g. "Webhook.Meta (null)".
+	names map[string]bool
+	open  []string
+}
+
+// unique returns name, or name with a number if it is taken, and takes it.
+func (m *jsonModel) unique(name string) string {
+	unique := name
+	for i := 2; m.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	m.names[unique] = true
+	return unique
+}
+
+// goType returns the Go type of the values of shape s, declaring the
+// structs of objects named after hint. path is the Go path of the field,
+// e.g. "Webhook.Items", for the fields that end up typed any, and todo
+// receives the reason when it is the field itself.
+func (m *jsonModel) goType(s *jsonShape, hint, path string, todo *string) string {
+	kinds := s.kindList()
+	open := func(reason string) string {
+		m.open = append(m.open, path+" ("+reason+")")
+		if *todo == "" {
+			*todo = "TODO: the sample " + reason + "; set the type."
+		}
+		return "any"
+	}
+	switch {
+	case len(kinds) == 0 && s.kinds["null"]:
+		return open("only has null")
+	case len(kinds) > 1:
+		names := make([]string, len(kinds))
+		for i, kind := range kinds {
+			names[i] = jsonKindNames[kind]
+		}
+		return open("mixes " + strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1])
+	}
+
+	var typ string
+	switch kinds[0] {
+	case "string":
+		typ = "string"
+	case "int":
+		typ = "int64"
+	case "float":
+		typ = "float64"
+	case "bool":
+		typ = "bool"
+	case "object":
+		typ = m.structType(m.unique(hint), s, path)
+	case "array":
+		if len(s.items.kinds) == 0 {
+			m.open = append(m.open, path+" (no items)")
+			if *todo == "" {
+				*todo = "TODO: the sample has no items; set their type."
+			}
+			return "[]any"
+		}
+		words := splitWords(hint)
+		if len(words) > 0 {
+			words[len(words)-1] = singularize(words[len(words)-1])
+		}
+		var itemTodo string
+		item := m.goType(s.items, camelCase(strings.Join(words, "_")), path+"[]", &itemTodo)
+		if itemTodo != "" && *todo == "" {
+			*todo = strings.Replace(itemTodo, "the sample", "the sample of the items", 1)
+		}
+		return "[]" + item
+	}
+	if s.kinds["null"] {
+		return "*" + typ
+	}
+	return typ
+}
+
+// structType declares name as a struct of the fields of the objects of
+// shape s and returns it.
+func (m *jsonModel) structType(name string, s *jsonShape, path string) string {
+	t := apiType{Name: name, Struct: true}
+	if len(m.file.Types) == 0 {
+		t.Doc = []string{name + " represents the " + name + " model, inferred from a JSON sample."}
+	} else {
+		t.Doc = []string{name + " is an object of " + path + " in the JSON sample."}
+	}
+	// Declare the struct before the ones of its fields
+	i := len(m.file.Types)
+	m.file.Types = append(m.file.Types, t)
+
+	fields := make(map[string]bool)
+	for _, key := range s.keys {
+		f := apiField{Name: goName(key)}
+		if f.Name == "" {
+			f.Name = "Field"
+		}
+		for n := 2; fields[f.Name]; n++ {
+			f.Name = goName(key) + strconv.Itoa(n)
+		}
+		fields[f.Name] = true
+
+		var todo string
+		f.Type = m.goType(s.fields[key], name+f.Name, name+"."+f.Name, &todo)
+		if todo != "" {
+			f.Doc = []string{todo}
+		}
+		tag := key
+		if s.seen[key] < s.objects {
+			tag += ",omitempty"
+		}
+		f.Tag = "json:" + strconv.Quote(tag)
+		t.Fields = append(t.Fields, f)
+	}
+	m.file.Types[i] = t
+	return name
+}
+
+// inferJSONModel infers the struct typeName, and those of its nested
+// objects, from sample, as GenerateModelFromJSON does.
+func inferJSONModel(typeName string, sample []byte) (*jsonModel, error) {
+	if len(strings.TrimSpace(string(sample))) == 0 {
+		return nil, errors.New("the JSON sample is empty")
+	}
+	doc, err := parseJSONDocument(sample)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON sample: %v", err)
+	}
+	shape := &jsonShape{}
+	switch {
+	case doc.kind == docMapping:
+		shape.add(doc)
+	case doc.kind == docSequence && len(doc.items) > 0:
+		for _, item := range doc.items {
+			if item.kind != docMapping {
+				return nil, errors.New("the JSON sample must be an object or an array of objects")
+			}
+			shape.add(item)
+		}
+	default:
+		return nil, errors.New("the JSON sample must be an object or an array of objects")
+	}
+
+	m := &jsonModel{names: make(map[string]bool)}
+	typeName = m.unique(typeName)
+	m.structType(typeName, shape, typeName)
+	return m, nil
+}
+
+// GenerateModelFromJSON writes models/<name>.go with a struct named after
+// name inferring its fields from sample, a JSON object or array of
+// objects, whose keys it merges. Nested objects become structs of their
+// own, named after their parent and key, numbers without a fraction or
+// exponent int64 and the others float64, and values that are null
+// wherever they occur pointers. Fields whose type the sample leaves open,
+// as they are only null or mix types, are typed any with a TODO comment
+// and reported. With crud, a CRUD controller and its routes are generated
+// for the model as by GenerateResource. An existing file is only
+// overwritten when Force is set.
+func (p *Project) GenerateModelFromJSON(ctx context.Context, name string, sample []byte, crud bool) error {
+	if err := validateName("model", name); err != nil {
+		return err
+	}
+	if err := p.validateNaming(); err != nil {
+		return err
+	}
+	names := p.names(name)
+	m, err := inferJSONModel(names.Model, sample)
+	if err != nil {
+		return err
+	}
+	rel := "models/" + names.File + ".go"
+	dir := filepath.Join(p.Root, "models")
+	for _, t := range m.file.Types {
+		if file, ok := declaredIn(p.fs(), dir, t.Name); ok && "models/"+file != rel {
+			return &ValidationError{Err: fmt.Errorf("%s is already declared in models/%s", t.Name, file)}
+		}
+	}
+	model, err := renderGoTemplate("templates/openapi/model.go.tmpl", m.file)
+	if err != nil {
+		return err
+	}
+
+	if crud {
+		// The pagination of Index may sort by the scalar fields
+		var sortable []string
+		for _, f := range m.file.Types[0].Fields {
+			switch f.Type {
+			case "string", "int64", "float64", "bool":
+				tag, _ := strconv.Unquote(strings.TrimPrefix(f.Tag, "json:"))
+				if key, _, _ := strings.Cut(tag, ","); token.IsIdentifier(key) {
+					sortable = append(sortable, key)
+				}
+			}
+		}
+		err = p.generateResource(ctx, name, model, nil, sortable)
+	} else {
+		err = p.generate(ctx, func(g *generator) error {
+			return p.generateFile(g, rel, model)
+		})
+	}
+	if err != nil || len(m.open) == 0 {
+		return err
+	}
+	fmt.Fprintln(p.out(), "Warning: the sample leaves the type of these fields open, so they are typed any with a TODO comment:")
+	for _, f := range m.open {
+		fmt.Fprintln(p.out(), "  "+f)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package scaffold
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInferJSONModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		// want holds a line per field, "Type.Field type tag".
+		want []string
+		open []string
+	}{
+		{
+			name:   "scalars",
+			sample: `{"id": 1, "price": 9.5, "rate": 1e3, "name": "a", "active": true}`,
+			want: []string{
+				`Item.ID int64 json:"id"`,
+				`Item.Price float64 json:"price"`,
+				`Item.Rate float64 json:"rate"`,
+				`Item.Name string json:"name"`,
+				`Item.Active bool json:"active"`,
+			},
+		},
+		{
+			name:   "integers and floats",
+			sample: `[{"amount": 1}, {"amount": 2.5}]`,
+			want:   []string{`Item.Amount float64 json:"amount"`},
+		},
+		{
+			name:   "initialisms",
+			sample: `{"user_id": 1, "avatarUrl": "x", "api-key": "k"}`,
+			want: []string{
+				`Item.UserID int64 json:"user_id"`,
+				`Item.AvatarURL string json:"avatarUrl"`,
+				`Item.APIKey string json:"api-key"`,
+			},
+		},
+		{
+			name:   "null",
+			sample: `[{"note": null, "parent_id": 1, "deleted": null}, {"note": "n", "parent_id": null, "deleted": null}]`,
+			want: []string{
+				`Item.Note *string json:"note"`,
+				`Item.ParentID *int64 json:"parent_id"`,
+				`Item.Deleted any json:"deleted"`,
+			},
+			open: []string{"Item.Deleted (only has null)"},
+		},
+		{
+			name:   "missing keys",
+			sample: `[{"id": 1, "tag": "a"}, {"id": 2}]`,
+			want: []string{
+				`Item.ID int64 json:"id"`,
+				`Item.Tag string json:"tag,omitempty"`,
+			},
+		},
+		{
+			name:   "nested objects",
+			sample: `{"owner": {"name": "a", "address": {"city": "b"}}, "meta": {"page": 1}}`,
+			want: []string{
+				`Item.Owner ItemOwner json:"owner"`,
+				`Item.Meta ItemMeta json:"meta"`,
+				`ItemOwner.Name string json:"name"`,
+				`ItemOwner.Address ItemOwnerAddress json:"address"`,
+				`ItemOwnerAddress.City string json:"city"`,
+				`ItemMeta.Page int64 json:"page"`,
+			},
+		},
+		{
+			name:   "arrays",
+			sample: `{"tags": ["a", "b"], "scores": [1, 2.5], "lines": [{"sku": "x"}, {"sku": "y", "qty": 2}], "empty": [], "matrix": [[1]]}`,
+			want: []string{
+				`Item.Tags []string json:"tags"`,
+				`Item.Scores []float64 json:"scores"`,
+				`Item.Lines []ItemLine json:"lines"`,
+				`Item.Empty []any json:"empty"`,
+				`Item.Matrix [][]int64 json:"matrix"`,
+				`ItemLine.Sku string json:"sku"`,
+				`ItemLine.Qty int64 json:"qty,omitempty"`,
+			},
+			open: []string{"Item.Empty (no items)"},
+		},
+		{
+			name:   "mixed types",
+			sample: `[{"value": 1}, {"value": "one"}, {"value": true}]`,
+			want:   []string{`Item.Value any json:"value"`},
+			open:   []string{"Item.Value (mixes booleans, integers and strings)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := inferJSONModel("Item", []byte(tt.sample))
+			if err != nil {
+				t.Fatalf("inferJSONModel: %v", err)
+			}
+			var got []string
+			for _, typ := range m.file.Types {
+				for _, f := range typ.Fields {
+					got = append(got, typ.Name+"."+f.Name+" "+f.Type+" "+f.Tag)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields =\n%q\nwant\n%q", got, tt.want)
+			}
+			if !reflect.DeepEqual(m.open, tt.open) {
+				t.Errorf("open fields = %q, want %q", m.open, tt.open)
+			}
+		})
+	}
+}
+
+func TestInferJSONModelInvalid(t *testing.T) {
+	for _, sample := range []string{"", " ", "[]", `"a"`, `[1, 2]`, `[{"a": 1}, 2]`, `{"a": }`} {
+		if _, err := inferJSONModel("Item", []byte(sample)); err == nil {
+			t.Errorf("inferJSONModel(%q) succeeded, want an error", sample)
+		}
+	}
+}
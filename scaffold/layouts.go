@@ -0,0 +1,171 @@
+package scaffold
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// projectLayout describes how the packages of a project are arranged. Its
+// files come from the template layers "layout/<name>/base" and
+// "layout/<name>/<framework>", applied after the framework layer.
+// Standalone layouts only use "layout/<name>/<framework>".
+type projectLayout struct {
+	// dirs lists the directories created even when no template writes to
+	// them.
+	dirs []string
+	// mainPackage is the server's main package as an argument of the go
+	// command, e.g. "./cmd/api".
+	mainPackage string
+	// files lists the files in the project root that Destroy removes with
+	// Force, besides go.mod.
+	files []string
+	// standalone layouts generate every file themselves, without the base
+	// and framework layers or the live reload config.
+	standalone bool
+}
+
+// DefaultLayout is the layout used when none is given: controller, models,
+// router and middleware packages next to cmd/api.
+const DefaultLayout = "mvc"
+
+var layouts = map[string]projectLayout{
+	DefaultLayout: {
+		dirs:        []string{"cmd/api", "controller", "models", "pkg", "config", "views", "router", "middleware"},
+		mainPackage: "./cmd/api",
+	},
+	"clean": {
+		dirs:        []string{"cmd/api", "internal/domain", "internal/service", "internal/repository", "internal/handler", "pkg", "config", "middleware"},
+		mainPackage: "./cmd/api",
+	},
+	"hexagonal": {
+		dirs:        []string{"cmd/api", "internal/core", "internal/adapters/http", "internal/adapters/storage", "pkg", "config", "middleware"},
+		mainPackage: "./cmd/api",
+	},
+	"minimal": {
+		mainPackage: ".",
+		files:       []string{"main.go", "handlers.go", "handlers_test.go"},
+		standalone:  true,
+	},
+}
+
+// Layouts returns the supported layout names in sorted order.
+func Layouts() []string {
+	names := make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateLayout returns an error unless name is a supported layout.
+func ValidateLayout(name string) error {
+	if _, ok := layouts[name]; !ok {
+		return fmt.Errorf("unknown layout %q (supported: %s)", name, strings.Join(Layouts(), ", "))
+	}
+	return nil
+}
+
+// layout returns the project's layout, applying the default.
+func (p *Project) layout() string {
+	if p.Layout == "" {
+		return DefaultLayout
+	}
+	return p.Layout
+}
+
+// mvcOnlyOptions are the options only the default layout implements, by
+// flag of 'gomvc new'. what names the option in errors, or returns "" if
+// p does not use it.
+var mvcOnlyOptions = []struct {
+	flag string
+	what func(p *Project) string
+}{
+	{"-db", func(p *Project) string { return usedAs(p.Database != "", "a database") }},
+	{"-auth", func(p *Project) string { return usedAs(p.Auth != "", "authentication") }},
+	{"-config", func(p *Project) string {
+		return usedAs(p.config() != defaultConfig, "the "+p.config()+" config loader")
+	}},
+	{"-swagger", func(p *Project) string { return usedAs(p.Swagger, "Swagger") }},
+	{"-metrics", func(p *Project) string { return usedAs(p.Metrics, "metrics") }},
+	{"-otel", func(p *Project) string { return usedAs(p.Tracing, "tracing") }},
+	{"-ws", func(p *Project) string { return usedAs(p.WebSocket, "WebSockets") }},
+	{"-worker", func(p *Project) string { return usedAs(p.Worker, "background jobs") }},
+	{"-cache", func(p *Project) string { return usedAs(p.Cache != "", "a cache") }},
+	{"-messaging", func(p *Project) string { return usedAs(p.Messaging != "", "messaging") }},
+	{"-mailer", func(p *Project) string { return usedAs(p.Mailer, "a mailer") }},
+	{"-validation", func(p *Project) string { return usedAs(p.Validation, "request validation") }},
+	{"-ratelimit", func(p *Project) string { return usedAs(p.RateLimit, "rate limiting") }},
+	{"-tls", func(p *Project) string { return usedAs(p.TLS, "TLS") }},
+	{"-version-pkg", func(p *Project) string { return usedAs(p.VersionPkg, "a version package") }},
+	{"-middleware", func(p *Project) string { return usedAs(len(p.Middleware) > 0, "generated middleware") }},
+	{"-grpc", func(p *Project) string { return usedAs(p.GRPC, "gRPC") }},
+	{"-api", func(p *Project) string { return usedAs(p.api() != DefaultAPI, "a "+p.api()+" API") }},
+	{"-di", func(p *Project) string { return usedAs(p.DI != "", "dependency injection") }},
+	{"-with-tests", func(p *Project) string { return usedAs(p.WithTests, "controller tests") }},
+	{"-with-integration-tests", func(p *Project) string { return usedAs(p.IntegrationTests, "integration tests") }},
+	{"-from-openapi", func(p *Project) string { return usedAs(p.OpenAPI != "", "an API generated from OpenAPI") }},
+	{"-mode", func(p *Project) string { return usedAs(p.mode() != DefaultMode, "the "+p.mode()+" mode") }},
+}
+
+// usedAs returns what if used is set, and "" otherwise.
+func usedAs(used bool, what string) string {
+	if used {
+		return what
+	}
+	return ""
+}
+
+// checkLayoutOptions returns an error if p asks for options that only the
+// default layout implements, naming all of them.
+func (p *Project) checkLayoutOptions() error {
+	name := p.layout()
+	if name == DefaultLayout {
+		return nil
+	}
+	var unsupported []string
+	for _, o := range mvcOnlyOptions {
+		if what := o.what(p); what != "" {
+			unsupported = append(unsupported, what)
+		}
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("the %s layout does not support %s yet", name, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// requireDefaultLayout returns an error unless the project has the default
+// layout, which the generate commands write to.
+func (p *Project) requireDefaultLayout() error {
+	if name := p.layout(); name != DefaultLayout {
+		return fmt.Errorf("gomvc generate only supports the %s layout, not %s", DefaultLayout, name)
+	}
+	return nil
+}
+
+// layoutLayers returns the template layers of the project's layout for the
+// framework fw, in the order they are applied.
+func (p *Project) layoutLayers(fw string) []string {
+	name := p.layout()
+	if layouts[name].standalone {
+		return []string{"layout/" + name + "/" + fw}
+	}
+	return []string{"base", "layout/" + name + "/base", fw, "layout/" + name + "/" + fw}
+}
+
+// topLevelDirs returns the top-level directories of the project's layout,
+// in the order of first appearance.
+func (p *Project) topLevelDirs() []string {
+	var top []string
+	for _, dir := range layouts[p.layout()].dirs {
+		first, _, _ := strings.Cut(path.Clean(dir), "/")
+		if !slices.Contains(top, first) {
+			top = append(top, first)
+		}
+	}
+	return top
+}
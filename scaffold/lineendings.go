@@ -0,0 +1,61 @@
+package scaffold
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// DefaultLineEndings is used when Project.LineEndings is empty.
+const DefaultLineEndings = "auto"
+
+// lineEndings lists the line endings files can be written with, in sorted
+// order: "auto" for those of the OS gomvc runs on, "crlf" for Windows
+// ones, "lf" for Unix ones.
+var lineEndings = []string{"auto", "crlf", "lf"}
+
+// LineEndings returns the supported line endings in sorted order.
+func LineEndings() []string {
+	return slices.Clone(lineEndings)
+}
+
+// ValidateLineEndings returns an error unless name is a supported line
+// ending.
+func ValidateLineEndings(name string) error {
+	if !slices.Contains(lineEndings, name) {
+		return fmt.Errorf("unknown line endings %q (supported: %s)", name, strings.Join(lineEndings, ", "))
+	}
+	return nil
+}
+
+// lineEndings returns the line endings of the files of p, "lf" or "crlf",
+// resolving "auto" for the OS gomvc runs on.
+func (p *Project) lineEndings() string {
+	switch p.LineEndings {
+	case "lf", "crlf":
+		return p.LineEndings
+	}
+	if runtime.GOOS == "windows" {
+		return "crlf"
+	}
+	return "lf"
+}
+
+// withLineEndings returns content, generated with LF line endings, with
+// CRLF ones if crlf is set, without doubling those it has already. Shell
+// scripts keep LF line endings, as sh chokes on CRLF ones.
+func withLineEndings(rel, content string, crlf bool) string {
+	if !crlf || path.Ext(rel) == ".sh" || strings.HasPrefix(content, "#!") {
+		return content
+	}
+	return strings.ReplaceAll(toLF(content), "\n", "\r\n")
+}
+
+// toLF returns content with CRLF line endings replaced by LF ones, so that
+// files checked out or written with CRLF ones compare equal to what was
+// generated.
+func toLF(content string) string {
+	return strings.ReplaceAll(content, "\r\n", "\n")
+}
@@ -0,0 +1,11 @@
+package scaffold
+
+// mailerLayers returns the template layers of -mailer: pkg/mailer and
+// views/emails, and with authentication the welcome email Register sends.
+func (p *Project) mailerLayers() []string {
+	layers := []string{"mailer/base"}
+	if p.Auth != "" {
+		layers = append(layers, "mailer/auth")
+	}
+	return layers
+}
@@ -0,0 +1,390 @@
+package scaffold
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// manifestDir and manifestFile locate the manifest inside a generated
+// project. manifestBaseDir holds a copy of each file as it was generated,
+// the base Upgrade merges changes against.
+const (
+	manifestDir     = ".gomvc"
+	manifestFile    = ".gomvc/manifest.json"
+	manifestBaseDir = ".gomvc/base"
+)
+
+// ErrNoManifest is returned by Destroy when the project has no manifest and
+// Force is not set.
+var ErrNoManifest = errors.New("no gomvc manifest found")
+
+// manifest records every directory and file gomvc created in a project, so
+// that Destroy can remove exactly those and leave user files alone. Paths
+// are relative to the project root and use forward slashes. Manifests
+// written before layouts were added have no layout; they all used
+// DefaultLayout. GomvcVersion is the version of gomvc that created the
+// project, and is empty in manifests written before it was recorded.
+// Options and Hashes, which Upgrade needs, are missing from manifests
+// written before upgrades were added, and Generated from those written
+// before slices could be removed.
+type manifest struct {
+	Module       string   `json:"module"`
+	Framework    string   `json:"framework"`
+	Layout       string   `json:"layout,omitempty"`
+	GomvcVersion string   `json:"gomvc_version,omitempty"`
+	Dirs         []string `json:"dirs"`
+	Files        []string `json:"files"`
+	// Options are the options the project was created with.
+	Options *manifestOptions `json:"options,omitempty"`
+	// Hashes maps the files rendered from the templates to the SHA-256 of
+	// their generated content, to tell which ones were changed since.
+	Hashes map[string]string `json:"hashes,omitempty"`
+	// Generated maps the files the generate commands wrote to the SHA-256
+	// of their content, to tell which ones RemoveResource may delete.
+	Generated map[string]string `json:"generated,omitempty"`
+}
+
+// manifestOptions are the options of a Project its templates are rendered
+// with, as recorded in the manifest. The session secret is recorded so an
+// upgrade renders the files holding it unchanged; it is in the committed
+// .env.example too, and only meant for development.
+type manifestOptions struct {
+	Mode             string   `json:"mode,omitempty"`
+	API              string   `json:"api,omitempty"`
+	CSS              string   `json:"css,omitempty"`
+	Database         string   `json:"db,omitempty"`
+	ORM              string   `json:"orm,omitempty"`
+	Auth             string   `json:"auth,omitempty"`
+	SessionSecret    string   `json:"session_secret,omitempty"`
+	Config           string   `json:"config,omitempty"`
+	APIPrefix        string   `json:"api_prefix,omitempty"`
+	Port             string   `json:"port,omitempty"`
+	Swagger          bool     `json:"swagger,omitempty"`
+	Metrics          bool     `json:"metrics,omitempty"`
+	Tracing          bool     `json:"otel,omitempty"`
+	WebSocket        bool     `json:"ws,omitempty"`
+	Worker           bool     `json:"worker,omitempty"`
+	Cache            string   `json:"cache,omitempty"`
+	Messaging        string   `json:"messaging,omitempty"`
+	Mailer           bool     `json:"mailer,omitempty"`
+	Validation       bool     `json:"validation,omitempty"`
+	RateLimit        bool     `json:"ratelimit,omitempty"`
+	RateLimitScope   string   `json:"ratelimit_scope,omitempty"`
+	TLS              bool     `json:"tls,omitempty"`
+	VersionPkg       bool     `json:"version_pkg,omitempty"`
+	Middleware       []string `json:"middleware,omitempty"`
+	GRPC             bool     `json:"grpc,omitempty"`
+	GRPCIgnoreGen    bool     `json:"grpc_ignore_gen,omitempty"`
+	DI               string   `json:"di,omitempty"`
+	Docker           bool     `json:"docker,omitempty"`
+	CI               string   `json:"ci,omitempty"`
+	DevTools         bool     `json:"dev_tools,omitempty"`
+	WithTests        bool     `json:"with_tests,omitempty"`
+	IntegrationTests bool     `json:"integration_tests,omitempty"`
+	IntoExisting     bool     `json:"into_existing,omitempty"`
+	// LineEndings are those the files were written with, "lf" or "crlf",
+	// rather than "auto". Manifests without them were written with LF.
+	LineEndings string `json:"line_endings,omitempty"`
+	// OpenAPI is the path of the copy of the OpenAPI document in the
+	// project, rather than that of the document it was generated from.
+	OpenAPI string `json:"openapi,omitempty"`
+}
+
+// manifestOptions returns the options of p to record in its manifest.
+func (p *Project) manifestOptions(sessionSecret string) *manifestOptions {
+	o := &manifestOptions{
+		Mode: p.Mode, API: p.API, CSS: p.CSS,
+		Database: p.Database, ORM: p.ORM, Auth: p.Auth, SessionSecret: sessionSecret,
+		Config: p.Config, APIPrefix: p.APIPrefix, Port: p.Port,
+		Swagger: p.Swagger, Metrics: p.Metrics, Tracing: p.Tracing, WebSocket: p.WebSocket, Worker: p.Worker,
+		Cache: p.Cache, Messaging: p.Messaging, Mailer: p.Mailer, Validation: p.Validation,
+		RateLimit: p.RateLimit, RateLimitScope: p.RateLimitScope, TLS: p.TLS, VersionPkg: p.VersionPkg,
+		Middleware: p.Middleware, GRPC: p.GRPC, GRPCIgnoreGen: p.GRPCIgnoreGen, DI: p.DI,
+		Docker: p.Docker, CI: p.CI, DevTools: p.DevTools, WithTests: p.WithTests, IntegrationTests: p.IntegrationTests, IntoExisting: p.IntoExisting,
+		LineEndings: p.lineEndings(),
+	}
+	if p.OpenAPI != "" {
+		o.OpenAPI = openAPICopy(p.OpenAPI)
+	}
+	return o
+}
+
+// project returns a Project at root with the options o and the module,
+// framework and layout of m.
+func (o *manifestOptions) project(root string, m *manifest) *Project {
+	p := &Project{
+		Root: root, Module: m.Module, Framework: m.Framework, Layout: m.Layout,
+		Mode: o.Mode, API: o.API, CSS: o.CSS,
+		Database: o.Database, ORM: o.ORM, Auth: o.Auth,
+		Config: o.Config, APIPrefix: o.APIPrefix, Port: o.Port,
+		Swagger: o.Swagger, Metrics: o.Metrics, Tracing: o.Tracing, WebSocket: o.WebSocket, Worker: o.Worker,
+		Cache: o.Cache, Messaging: o.Messaging, Mailer: o.Mailer, Validation: o.Validation,
+		RateLimit: o.RateLimit, RateLimitScope: o.RateLimitScope, TLS: o.TLS, VersionPkg: o.VersionPkg,
+		Middleware: o.Middleware, GRPC: o.GRPC, GRPCIgnoreGen: o.GRPCIgnoreGen, DI: o.DI,
+		Docker: o.Docker, CI: o.CI, DevTools: o.DevTools, WithTests: o.WithTests, IntegrationTests: o.IntegrationTests, IntoExisting: o.IntoExisting,
+		LineEndings: o.lineEndings(),
+	}
+	if o.OpenAPI != "" {
+		p.OpenAPI = filepath.Join(root, filepath.FromSlash(o.OpenAPI))
+	}
+	return p
+}
+
+// lineEndings returns the line endings the files were written with, LF
+// unless recorded otherwise.
+func (o *manifestOptions) lineEndings() string {
+	if o == nil || o.LineEndings == "" {
+		return "lf"
+	}
+	return o.LineEndings
+}
+
+// contentHash returns the hex encoded SHA-256 of content, as recorded in
+// manifest.Hashes.
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeBases stores the generated content of files in the base directory
+// of the project at rootPath.
+func writeBases(fsys FS, rootPath string, files map[string]string) error {
+	for rel, content := range files {
+		path := filepath.Join(rootPath, manifestBaseDir, filepath.FromSlash(rel))
+		if err := fsys.MkdirAll(filepath.Dir(path), DefaultDirMode); err != nil {
+			return err
+		}
+		if err := fsys.WriteFile(path, []byte(content), DefaultFileMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBase returns the generated content of rel stored in the base
+// directory of the project at rootPath, if it is there and has hash.
+func readBase(fsys FS, rootPath, rel, hash string) (string, bool) {
+	data, err := fsys.ReadFile(filepath.Join(rootPath, manifestBaseDir, filepath.FromSlash(rel)))
+	if err != nil || contentHash(string(data)) != hash {
+		return "", false
+	}
+	return string(data), true
+}
+
+// readManifest loads the manifest of the project at rootPath.
+func readManifest(fsys FS, rootPath string) (*manifest, error) {
+	data, err := fsys.ReadFile(filepath.Join(rootPath, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoManifest
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", manifestFile, err)
+	}
+	m.slashPaths()
+	if err := m.checkPaths(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", manifestFile, err)
+	}
+	return &m, nil
+}
+
+// writeManifest stores m in the project at rootPath.
+func writeManifest(fsys FS, rootPath string, m *manifest) error {
+	m.slashPaths()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsys.MkdirAll(filepath.Join(rootPath, manifestDir), DefaultDirMode); err != nil {
+		return err
+	}
+	return fsys.WriteFile(filepath.Join(rootPath, manifestFile), append(data, '\n'), DefaultFileMode)
+}
+
+// slashPaths makes the paths recorded in m use slashes, so
+// that a manifest is the same whichever OS the project is generated on, and
+// one edited by hand on Windows, with backslashes, is still understood.
+func (m *manifest) slashPaths() {
+	slash := func(rel string) string {
+		return path.Clean(strings.ReplaceAll(rel, `\`, "/"))
+	}
+	for i, dir := range m.Dirs {
+		m.Dirs[i] = slash(dir)
+	}
+	for i, file := range m.Files {
+		m.Files[i] = slash(file)
+	}
+	for _, hashes := range []map[string]string{m.Hashes, m.Generated} {
+		for rel, hash := range hashes {
+			if s := slash(rel); s != rel {
+				delete(hashes, rel)
+				hashes[s] = hash
+			}
+		}
+	}
+}
+
+// checkPaths returns an error if a path recorded in m is not inside the
+// project, so that a manifest edited by hand, or crafted, cannot make
+// Destroy or Upgrade touch files elsewhere.
+func (m *manifest) checkPaths() error {
+	rels := append(append([]string(nil), m.Dirs...), m.Files...)
+	for _, hashes := range []map[string]string{m.Hashes, m.Generated} {
+		for rel := range hashes {
+			rels = append(rels, rel)
+		}
+	}
+	if m.Options != nil && m.Options.OpenAPI != "" {
+		rels = append(rels, m.Options.OpenAPI)
+	}
+	for _, rel := range rels {
+		if path.IsAbs(rel) || !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return fmt.Errorf("%s is outside the project", rel)
+		}
+	}
+	return nil
+}
+
+// hasDir reports whether dir is recorded in m.
+func (m *manifest) hasDir(dir string) bool {
+	for _, d := range m.Dirs {
+		if d == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFile reports whether file is recorded in m.
+func (m *manifest) hasFile(file string) bool {
+	for _, f := range m.Files {
+		if f == file {
+			return true
+		}
+	}
+	return false
+}
+
+// withPrevious returns m extended with the entries of prev, the manifest
+// of an earlier run, that m lacks. prev may be nil.
+func (m *manifest) withPrevious(prev *manifest) *manifest {
+	if prev == nil {
+		return m
+	}
+	merged := *m
+	merged.Dirs, merged.Files = nil, nil
+	for _, dir := range prev.Dirs {
+		if !m.hasDir(dir) {
+			merged.Dirs = append(merged.Dirs, dir)
+		}
+	}
+	for _, file := range prev.Files {
+		if !m.hasFile(file) {
+			merged.Files = append(merged.Files, file)
+		}
+	}
+	merged.Dirs = append(merged.Dirs, m.Dirs...)
+	merged.Files = append(merged.Files, m.Files...)
+	if merged.Options == nil {
+		merged.Options = prev.Options
+	}
+	if len(prev.Hashes) > 0 {
+		merged.Hashes = maps.Clone(prev.Hashes)
+		maps.Copy(merged.Hashes, m.Hashes)
+	}
+	if len(prev.Generated) > 0 {
+		merged.Generated = maps.Clone(prev.Generated)
+		maps.Copy(merged.Generated, m.Generated)
+	}
+	return &merged
+}
+
+// removeManifestEntries deletes the files listed in m and then every listed
+// directory that is left empty, deepest first. Files the user added inside
+// generated directories keep those directories alive.
+func (p *Project) removeManifestEntries(fsys FS, m *manifest) error {
+	// removed tracks what has been deleted so far, so that emptiness is
+	// judged correctly even when fsys only pretends to delete.
+	removed := make(map[string]bool)
+
+	// The copies of the generated files go with the manifest
+	base := filepath.Join(p.Root, manifestBaseDir)
+	if _, err := fsys.Stat(base); err == nil {
+		if err := fsys.RemoveAll(base); err != nil {
+			return err
+		}
+		removed[base] = true
+	}
+
+	for _, file := range append(m.Files, manifestFile) {
+		path := filepath.Join(p.Root, filepath.FromSlash(file))
+		if _, err := fsys.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := fsys.Remove(path); err != nil {
+			return err
+		}
+		removed[path] = true
+	}
+
+	dirs := append([]string(nil), m.Dirs...)
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], "/") > strings.Count(dirs[j], "/")
+	})
+	for _, dir := range append(dirs, manifestDir) {
+		if err := p.removeIfEmpty(fsys, filepath.Join(p.Root, filepath.FromSlash(dir)), removed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeIfEmpty removes dir if it exists and has no entries left besides
+// those in removed.
+func (p *Project) removeIfEmpty(fsys FS, dir string, removed map[string]bool) error {
+	entries, err := fsys.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !removed[filepath.Join(dir, entry.Name())] {
+			fmt.Fprintf(p.out(), "Kept %s: it contains files not generated by gomvc.\n", dir)
+			p.reportWarning(fmt.Sprintf("kept %s: it contains files not generated by gomvc", dir))
+			return nil
+		}
+	}
+	if err := fsys.Remove(dir); err != nil {
+		return err
+	}
+	removed[dir] = true
+	return nil
+}
+
+// missingDirs returns rel and each of its ancestors below rootPath that do
+// not exist yet, outermost first.
+func missingDirs(fsys FS, rootPath, rel string) []string {
+	var missing []string
+	for dir := rel; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, err := fsys.Stat(filepath.Join(rootPath, filepath.FromSlash(dir))); !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		missing = append([]string{dir}, missing...)
+	}
+	return missing
+}
@@ -0,0 +1,38 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// messagingBrokers lists the message brokers a project can publish events
+// to, in sorted order. Each adds pkg/events with that broker's client,
+// internal/consumers with a sample consumer, and the framework's
+// controller publishing an event when it creates an order.
+var messagingBrokers = []string{"nats"}
+
+// messagingRequires lists the modules of -messaging nats: the NATS client,
+// and the NATS server, which the tests of pkg/events embed.
+var messagingRequires = []string{"github.com/nats-io/nats.go@v1.54.0", "github.com/nats-io/nats-server/v2@v2.15.0"}
+
+// MessagingBrokers returns the supported message brokers in sorted order.
+func MessagingBrokers() []string {
+	return slices.Clone(messagingBrokers)
+}
+
+// ValidateMessaging returns an error unless name is a supported message
+// broker.
+func ValidateMessaging(name string) error {
+	if !slices.Contains(messagingBrokers, name) {
+		return fmt.Errorf("unknown message broker %q (supported: %s)", name, strings.Join(messagingBrokers, ", "))
+	}
+	return nil
+}
+
+// messagingLayers returns the template layers of -messaging: pkg/events
+// and internal/consumers, and the framework's controller publishing an
+// event.
+func (p *Project) messagingLayers() []string {
+	return []string{"messaging/" + p.Messaging, "messaging/" + p.framework()}
+}
@@ -0,0 +1,15 @@
+package scaffold
+
+// prometheusRequire provides the metrics registry and the /metrics handler.
+const prometheusRequire = "github.com/prometheus/client_golang@v1.20.5"
+
+// metricsLayers returns the template layers of -metrics: pkg/metrics, the
+// framework's middleware and, for projects with a docker-compose.yml, a
+// Prometheus service scraping the application.
+func (p *Project) metricsLayers() []string {
+	layers := []string{"metrics/base", "metrics/" + p.framework()}
+	if p.hasCompose() {
+		layers = append(layers, "metrics/compose")
+	}
+	return layers
+}
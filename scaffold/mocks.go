@@ -0,0 +1,119 @@
+package scaffold
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// mockTools lists the tools GenerateService can generate mocks of the
+// interfaces it writes with, in sorted order, and "none" to skip them.
+var mockTools = []string{"gomock", "mockery", "none"}
+
+// DefaultMockTool is the mock tool used when Project.Mocks is empty.
+const DefaultMockTool = "mockery"
+
+// mocksDir is the package the mocks are generated into.
+const mocksDir = "mocks"
+
+// mockRequires maps each mock tool to the module its mocks import.
+var mockRequires = map[string]string{
+	"gomock":  "go.uber.org/mock@v0.6.0",
+	"mockery": "github.com/stretchr/testify@v1.11.1",
+}
+
+// mockeryVersion is the version of mockery the go:generate directives run.
+// mockgen is run from the version of go.uber.org/mock in go.mod instead,
+// as its command is not at the root of the module.
+const mockeryVersion = "v2.53.7"
+
+// MockTools returns the supported mock tools in sorted order.
+func MockTools() []string {
+	return slices.Clone(mockTools)
+}
+
+// ValidateMockTool returns an error unless name is a supported mock tool.
+func ValidateMockTool(name string) error {
+	if !slices.Contains(mockTools, name) {
+		return fmt.Errorf("unknown mock tool %q (supported: %s)", name, strings.Join(mockTools, ", "))
+	}
+	return nil
+}
+
+// mockTool returns the tool in Mocks, or DefaultMockTool if it is empty.
+func (p *Project) mockTool() string {
+	if p.Mocks == "" {
+		return DefaultMockTool
+	}
+	return p.Mocks
+}
+
+// mocksTarget is the Makefile target regenerating the mocks.
+const mocksTarget = `
+# Regenerate the mocks in mocks/ from the go:generate directives of the
+# service and repository interfaces
+mocks:
+	go generate ./` + repositoryDir + `/... ./` + serviceDir + `/...
+`
+
+// addMocksTarget returns the Makefile src with a mocks target appended and
+// added to .PHONY, or nil if it has one.
+func addMocksTarget(src []byte) []byte {
+	lines := strings.Split(string(src), "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "mocks:") {
+			return nil
+		}
+	}
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".PHONY:") {
+			lines[i] = line + " mocks"
+			break
+		}
+	}
+	out := strings.Join(lines, "\n")
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return []byte(out + mocksTarget)
+}
+
+// generateMocks writes the mocks of the repository and service of name
+// with tool by running their go:generate directives, after adding the
+// module the mocks import to go.mod unless it is required already. The
+// last go get adds the checksums of the packages they import.
+func (p *Project) generateMocks(g *generator, name, tool string) error {
+	goMod, err := p.fs().ReadFile(filepath.Join(p.Root, "go.mod"))
+	if err != nil {
+		return err
+	}
+	require := mockRequires[tool]
+	if module, _, _ := strings.Cut(require, "@"); !strings.Contains(string(goMod), module+" ") {
+		if err := g.runGo([]string{"go.sum"}, "get", require); err != nil {
+			return err
+		}
+	}
+	for _, dir := range []string{repositoryDir, serviceDir} {
+		file := snakeCase(name) + "_" + path.Base(dir) + ".go"
+		if err := g.runGo([]string{mocksDir + "/" + file}, "generate", "./"+dir+"/"+file); err != nil {
+			return err
+		}
+	}
+	return g.runGo(nil, "get", "./...")
+}
+
+// mockDirective returns the command of the go:generate directive writing
+// the mock of the interface <name><kind>, a Repository or Service, with
+// tool, or "" if tool is "none". It runs in the directory of the interface.
+func mockDirective(tool, name, kind string) string {
+	file := snakeCase(name) + "_" + strings.ToLower(kind) + ".go"
+	switch tool {
+	case "mockery":
+		return fmt.Sprintf("go run github.com/vektra/mockery/v2@%s --name %s%s --output ../../%s --outpkg %s --filename %s", mockeryVersion, name, kind, mocksDir, mocksDir, file)
+	case "gomock":
+		return fmt.Sprintf("go run -mod=mod go.uber.org/mock/mockgen -source=%s -destination=../../%s/%s -package=%s", file, mocksDir, file, mocksDir)
+	}
+	return ""
+}
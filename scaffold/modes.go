@@ -0,0 +1,49 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultMode is the mode used when none is given: a JSON API.
+const DefaultMode = "api"
+
+// modes lists what a project can serve, in sorted order. "web" adds the
+// template layers "web/base" and "web/<framework>" with server-rendered
+// pages, and "htmx" those of "web" and then "htmx/base" and
+// "htmx/<framework>" with partials that htmx swaps into the pages.
+var modes = []string{"api", "htmx", "web"}
+
+// Modes returns the supported modes in sorted order.
+func Modes() []string {
+	return slices.Clone(modes)
+}
+
+// ValidateMode returns an error unless name is a supported mode.
+func ValidateMode(name string) error {
+	if !slices.Contains(modes, name) {
+		return fmt.Errorf("unknown mode %q (supported: %s)", name, strings.Join(modes, ", "))
+	}
+	return nil
+}
+
+// modeLayers returns the template layers of the project's mode for the
+// framework fw.
+func (p *Project) modeLayers(fw string) []string {
+	switch p.mode() {
+	case "web":
+		return []string{"web/base", "web/" + fw}
+	case "htmx":
+		return []string{"web/base", "web/" + fw, "htmx/base", "htmx/" + fw}
+	}
+	return nil
+}
+
+// mode returns the project's mode, applying the default.
+func (p *Project) mode() string {
+	if p.Mode == "" {
+		return DefaultMode
+	}
+	return p.Mode
+}
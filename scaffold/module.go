@@ -0,0 +1,145 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// DefaultModulePath derives a module path for local use from the base name
+// of dir, e.g. "my-app" for "./My App". It is "app" when nothing usable is
+// left of the name.
+func DefaultModulePath(dir string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(baseName(dir, "")) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	name := strings.Trim(b.String(), "-._")
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+	if ValidateModulePath(name) != nil {
+		return "app"
+	}
+	return name
+}
+
+// baseName returns the last element of the absolute path of dir, e.g.
+// "app" for "./app/" or `C:\src\app\`, or fallback for the root of a
+// volume, such as / or C:\, or of a share, such as \\server\share.
+func baseName(dir, fallback string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if rest := dir[len(filepath.VolumeName(dir)):]; rest == "" || rest == string(filepath.Separator) {
+		return fallback
+	}
+	return filepath.Base(dir)
+}
+
+// ErrExistingModule is returned by Create when Root is inside a Go module
+// already and IntoExisting is not set.
+var ErrExistingModule = errors.New("the project would be created inside an existing Go module")
+
+// ValidateModulePath checks that path is usable as a Go module path. Paths
+// whose first element has a dot, such as github.com/user/app, are checked
+// with the rules of module.CheckPath, so that they can also be fetched by
+// others. Those without one, such as myapp, are only fit for local
+// modules, but go mod init accepts them too, so they are checked as import
+// paths.
+func ValidateModulePath(path string) error {
+	if path == "" {
+		return errors.New("module path is empty")
+	}
+	first, _, _ := strings.Cut(path, "/")
+	if strings.Contains(first, ".") {
+		return module.CheckPath(path)
+	}
+	return module.CheckImportPath(path)
+}
+
+// CleanModulePath trims the white space and the quotes around path, which
+// creep in when a module path is pasted.
+func CleanModulePath(path string) string {
+	path = strings.TrimSpace(path)
+	for len(path) >= 2 && (path[0] == '"' || path[0] == '\'' || path[0] == '`') && path[len(path)-1] == path[0] {
+		path = strings.TrimSpace(path[1 : len(path)-1])
+	}
+	return path
+}
+
+// resolveModule returns the module path the packages of the project are
+// imported with. Unless IntoExisting is set, it is Module, and Root must
+// not be inside a Go module. With IntoExisting it is the path of the
+// module Root is inside, joined with the directory of Root within it.
+func (p *Project) resolveModule() (string, error) {
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return "", err
+	}
+	goMod := findGoMod(p.fs(), root)
+	switch {
+	case goMod == "" && p.IntoExisting:
+		return "", fmt.Errorf("there is no go.mod in %s or its parents to generate into", root)
+	case goMod == "":
+		return p.Module, ValidateModulePath(p.Module)
+	case !p.IntoExisting:
+		return "", fmt.Errorf("%w declared in %s", ErrExistingModule, goMod)
+	}
+
+	data, err := p.fs().ReadFile(goMod)
+	if err != nil {
+		return "", err
+	}
+	modPath := modfile.ModulePath(data)
+	if modPath == "" {
+		return "", fmt.Errorf("%s declares no module path", goMod)
+	}
+	if rel, err := filepath.Rel(filepath.Dir(goMod), root); err == nil && rel != "." {
+		modPath = path.Join(modPath, filepath.ToSlash(rel))
+	}
+	if err := ValidateModulePath(modPath); err != nil {
+		return "", err
+	}
+	if p.Module != "" && p.Module != modPath {
+		return "", fmt.Errorf("the packages of %s are imported as %s, not %s, as declared in %s", root, modPath, p.Module, goMod)
+	}
+	return modPath, nil
+}
+
+// FindGoMod returns the go.mod file of the Go module dir is in, or "" if
+// dir is not inside one. dir need not exist.
+func FindGoMod(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	return findGoMod(OSFS{}, abs)
+}
+
+// findGoMod returns the go.mod file of the module dir is in, looking in dir
+// and its parents like the go command does, or "" if there is none. dir
+// need not exist.
+func findGoMod(fsys FS, dir string) string {
+	for {
+		goMod := filepath.Join(dir, "go.mod")
+		if info, err := fsys.Stat(goMod); err == nil && !info.IsDir() {
+			return goMod
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,248 @@
+package scaffold
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// commonInitialisms are kept upper case in Go identifiers, as in "UserID".
+// They are those of golint.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "LHS": true, "QPS": true, "RAM": true, "RHS": true,
+	"RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "URI": true,
+	"URL": true, "UTF8": true, "UUID": true, "VM": true, "XML": true, "XMPP": true,
+	"XSRF": true, "XSS": true,
+}
+
+// splitWords splits a snake_case, kebab-case or CamelCase name into lower
+// case words.
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			// Start a new word at "aB" and at the "C" in "ABCd", but not
+			// at the "I" in "APIs"
+			prevLower := !unicode.IsUpper(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if nextLower && !prevLower && pluralInitialism(runes[i+1:], string(word)+string(r)) {
+				nextLower = false
+			}
+			if prevLower || nextLower {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// pluralInitialism reports whether the upper case initialism, followed by
+// rest, is in the plural, as in "APIs" and "UserIDs".
+func pluralInitialism(rest []rune, initialism string) bool {
+	return commonInitialisms[initialism] && rest[0] == 's' && (len(rest) == 1 || !unicode.IsLower(rest[1]))
+}
+
+// camelCase returns name as an exported Go identifier, e.g. "created_at"
+// becomes "CreatedAt", "user_id" "UserID" and "user_ids" "UserIDs".
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, w := range splitWords(name) {
+		if upper := strings.ToUpper(w); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		if upper := strings.ToUpper(strings.TrimSuffix(w, "s")); len(w) > 1 && commonInitialisms[upper] {
+			b.WriteString(upper + "s")
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+// snakeCase returns name in snake_case, e.g. "OrderItem" becomes
+// "order_item".
+func snakeCase(name string) string {
+	return strings.Join(splitWords(name), "_")
+}
+
+// lowerCamelCase returns name as an unexported Go identifier, e.g.
+// "order_items" becomes "orderItems" and "user_apis" "userAPIs".
+func lowerCamelCase(name string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return ""
+	}
+	return words[0] + camelCase(strings.Join(words[1:], "_"))
+}
+
+// irregularPlurals maps the singulars whose plural pluralize cannot derive
+// by rule to their plural, and irregularSingulars the other way round.
+var (
+	irregularPlurals = map[string]string{
+		"person": "people", "child": "children", "man": "men", "woman": "women",
+		"mouse": "mice", "goose": "geese", "foot": "feet", "tooth": "teeth", "ox": "oxen",
+		"leaf": "leaves", "life": "lives", "knife": "knives", "wife": "wives", "half": "halves",
+		"hero": "heroes", "potato": "potatoes", "tomato": "tomatoes", "echo": "echoes",
+		"quiz": "quizzes", "criterion": "criteria", "analysis": "analyses", "crisis": "crises",
+		"thesis": "theses", "medium": "media", "movie": "movies",
+		"cookie": "cookies", "cache": "caches",
+		// Those ending in "us", which singularize cannot tell from "abuses"
+		// and "causes"
+		"status": "statuses", "bus": "buses", "bonus": "bonuses", "campus": "campuses",
+		"census": "censuses", "circus": "circuses", "focus": "focuses", "virus": "viruses",
+	}
+	irregularSingulars = func() map[string]string {
+		m := make(map[string]string, len(irregularPlurals))
+		for singular, plural := range irregularPlurals {
+			m[plural] = singular
+		}
+		return m
+	}()
+)
+
+// uncountables are the words whose plural is the word itself.
+var uncountables = map[string]bool{
+	"data": true, "equipment": true, "feedback": true, "fish": true, "information": true,
+	"metadata": true, "money": true, "news": true, "rice": true, "series": true,
+	"sheep": true, "software": true, "species": true, "staff": true,
+}
+
+// pluralize returns the English plural of a lower case word, or of the last
+// word of a snake_case name, e.g. "order_item" becomes "order_items" and
+// "sales_person" "sales_people".
+func pluralize(name string) string {
+	i := strings.LastIndex(name, "_") + 1
+	word := name[i:]
+	if plural, ok := irregularPlurals[word]; ok {
+		return name[:i] + plural
+	}
+	switch {
+	case uncountables[word]:
+		return name
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return name + "es"
+	case len(word) > 1 && strings.HasSuffix(word, "y") && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
+
+// singularize returns the English singular of a lower case word, or of the
+// last word of a snake_case name, undoing pluralize.
+func singularize(name string) string {
+	i := strings.LastIndex(name, "_") + 1
+	word := name[i:]
+	if singular, ok := irregularSingulars[word]; ok {
+		return name[:i] + singular
+	}
+	switch {
+	case uncountables[word]:
+		return name
+	case len(word) > 3 && strings.HasSuffix(word, "ies"):
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return name[:len(name)-2]
+	case len(word) > 1 && commonInitialisms[strings.ToUpper(strings.TrimSuffix(word, "s"))]:
+		// "apis" and "ids"
+		return name[:len(name)-1]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return name
+	case len(word) > 1 && strings.HasSuffix(word, "s"):
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+// resourceNames are the names the generators derive from the name of a
+// resource, so that they agree on them. For "Person" they are the model
+// Person in person.go, the controller PersonController, the routes below
+// /people and the table people.
+type resourceNames struct {
+	// Model is the Go name, e.g. "OrderItem", File its snake_case form,
+	// e.g. "order_item", which files are named after, and Var its
+	// unexported form, e.g. "orderItem".
+	Model, File, Var string
+	// Plural is the snake_case plural, e.g. "order_items", which the
+	// routes are named after, and Vars its unexported form, e.g.
+	// "orderItems".
+	Plural, Vars string
+	// Table is the name of the table of the model, which defaults to
+	// Plural.
+	Table string
+}
+
+// names returns the names of the resource name, with the plural and table
+// of the project's Plural and Table overrides.
+func (p *Project) names(name string) resourceNames {
+	n := resourceNames{Model: camelCase(name), File: snakeCase(name), Var: lowerCamelCase(name)}
+	n.Plural = pluralize(n.File)
+	if p.Plural != "" {
+		n.Plural = snakeCase(p.Plural)
+	}
+	n.Vars = lowerCamelCase(n.Plural)
+	n.Table = n.Plural
+	if p.Table != "" {
+		n.Table = p.Table
+	}
+	return n
+}
+
+// validateNaming checks the Plural and Table overrides: the plural must be
+// a valid name, and the table a SQL identifier, optionally qualified with
+// its schema.
+func (p *Project) validateNaming() error {
+	if p.Plural != "" {
+		if err := validateName("plural", p.Plural); err != nil {
+			return err
+		}
+	}
+	if p.Table == "" {
+		return nil
+	}
+	invalid := func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' }
+	for _, part := range strings.Split(p.Table, ".") {
+		if part == "" || strings.IndexFunc(part, invalid) >= 0 {
+			return &ValidationError{Err: fmt.Errorf("invalid table name %q: must contain only letters, digits and underscores, optionally qualified by a schema", p.Table)}
+		}
+	}
+	return nil
+}
+
+// validateName checks that name can be turned into a Go identifier: it
+// must start with a letter and contain only letters, digits, underscores
+// and dashes.
+func validateName(kind, name string) error {
+	if name == "" {
+		return &ValidationError{Err: fmt.Errorf("%s name is empty", kind)}
+	}
+	for i, r := range name {
+		if unicode.IsLetter(r) && r < unicode.MaxASCII {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '_' || r == '-') {
+			continue
+		}
+		return &ValidationError{Err: fmt.Errorf("invalid %s name %q: must start with a letter and contain only letters, digits and underscores", kind, name)}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package scaffold
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"OrderItem", []string{"order", "item"}},
+		{"order_item", []string{"order", "item"}},
+		{"order-item", []string{"order", "item"}},
+		{"HTTPServer", []string{"http", "server"}},
+		{"UserID", []string{"user", "id"}},
+		{"UserAPIs", []string{"user", "apis"}},
+		{"UserIDsList", []string{"user", "ids", "list"}},
+		{"APIKey", []string{"api", "key"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitWords(tt.name); !slices.Equal(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCasing(t *testing.T) {
+	tests := []struct {
+		name                   string
+		camel, snake, lowCamel string
+	}{
+		{"order_item", "OrderItem", "order_item", "orderItem"},
+		{"OrderItem", "OrderItem", "order_item", "orderItem"},
+		{"created_at", "CreatedAt", "created_at", "createdAt"},
+		{"user_id", "UserID", "user_id", "userID"},
+		{"user_ids", "UserIDs", "user_ids", "userIDs"},
+		{"UserAPI", "UserAPI", "user_api", "userAPI"},
+		{"user_apis", "UserAPIs", "user_apis", "userAPIs"},
+		{"api_key", "APIKey", "api_key", "apiKey"},
+		{"HTTPServer", "HTTPServer", "http_server", "httpServer"},
+		{"status", "Status", "status", "status"},
+		{"", "", "", ""},
+	}
+	for _, tt := range tests {
+		if got := camelCase(tt.name); got != tt.camel {
+			t.Errorf("camelCase(%q) = %q, want %q", tt.name, got, tt.camel)
+		}
+		if got := snakeCase(tt.name); got != tt.snake {
+			t.Errorf("snakeCase(%q) = %q, want %q", tt.name, got, tt.snake)
+		}
+		if got := lowerCamelCase(tt.name); got != tt.lowCamel {
+			t.Errorf("lowerCamelCase(%q) = %q, want %q", tt.name, got, tt.lowCamel)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		singular, plural string
+	}{
+		{"user", "users"},
+		{"order_item", "order_items"},
+		{"sales_person", "sales_people"},
+		{"category", "categories"},
+		{"day", "days"},
+		{"box", "boxes"},
+		{"match", "matches"},
+		{"dish", "dishes"},
+		{"class", "classes"},
+		{"status", "statuses"},
+		{"order_status", "order_statuses"},
+		{"bus", "buses"},
+		{"abuse", "abuses"},
+		{"house", "houses"},
+		{"cause", "causes"},
+		{"analysis", "analyses"},
+		{"movie", "movies"},
+		{"cache", "caches"},
+		{"api", "apis"},
+		{"user_id", "user_ids"},
+		{"news", "news"},
+		{"user_data", "user_data"},
+	}
+	for _, tt := range tests {
+		if got := pluralize(tt.singular); got != tt.plural {
+			t.Errorf("pluralize(%q) = %q, want %q", tt.singular, got, tt.plural)
+		}
+		if got := singularize(tt.plural); got != tt.singular {
+			t.Errorf("singularize(%q) = %q, want %q", tt.plural, got, tt.singular)
+		}
+	}
+}
+
+func TestSingularize(t *testing.T) {
+	// Singulars, which singularize leaves as they are
+	for _, word := range []string{"status", "class", "analysis", "user", "person"} {
+		if got := singularize(word); got != word {
+			t.Errorf("singularize(%q) = %q, want it unchanged", word, got)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name          string
+		plural, table string
+		want          resourceNames
+	}{
+		{"Person", "", "", resourceNames{Model: "Person", File: "person", Var: "person", Plural: "people", Vars: "people", Table: "people"}},
+		{"OrderItem", "", "", resourceNames{Model: "OrderItem", File: "order_item", Var: "orderItem", Plural: "order_items", Vars: "orderItems", Table: "order_items"}},
+		{"UserAPI", "", "", resourceNames{Model: "UserAPI", File: "user_api", Var: "userAPI", Plural: "user_apis", Vars: "userAPIs", Table: "user_apis"}},
+		{"api_key", "", "", resourceNames{Model: "APIKey", File: "api_key", Var: "apiKey", Plural: "api_keys", Vars: "apiKeys", Table: "api_keys"}},
+		{"Abuse", "", "", resourceNames{Model: "Abuse", File: "abuse", Var: "abuse", Plural: "abuses", Vars: "abuses", Table: "abuses"}},
+		{"Cactus", "Cacti", "app.cacti", resourceNames{Model: "Cactus", File: "cactus", Var: "cactus", Plural: "cacti", Vars: "cacti", Table: "app.cacti"}},
+		{"UserAPI", "UserAPIs", "", resourceNames{Model: "UserAPI", File: "user_api", Var: "userAPI", Plural: "user_apis", Vars: "userAPIs", Table: "user_apis"}},
+	}
+	for _, tt := range tests {
+		p := &Project{Plural: tt.plural, Table: tt.table}
+		if got := p.names(tt.name); got != tt.want {
+			t.Errorf("names(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
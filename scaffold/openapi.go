@@ -0,0 +1,914 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// openAPIDir holds the copy of the OpenAPI document a project is created
+// from, which Upgrade renders the project from again.
+const openAPIDir = "api"
+
+// openAPIMethods are the operations of a path item, in the order their
+// handlers are generated. TRACE is not supported by every framework.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// openAPIDoc is the OpenAPI document of Project.OpenAPI.
+type openAPIDoc struct {
+	// rel is the path of its copy in the project, e.g. "api/openapi.yaml".
+	rel  string
+	src  []byte
+	root *docNode
+}
+
+// openAPICopy returns the path of the copy of the OpenAPI document at
+// name in the project, keeping its format.
+func openAPICopy(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		return openAPIDir + "/openapi.json"
+	}
+	return openAPIDir + "/openapi.yaml"
+}
+
+// readOpenAPI reads and parses the document of p.OpenAPI, which must be an
+// OpenAPI 3 document in JSON or YAML.
+func (p *Project) readOpenAPI() (*openAPIDoc, error) {
+	src, err := p.fs().ReadFile(p.OpenAPI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the OpenAPI document: %w", err)
+	}
+	root, err := parseDocument(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.OpenAPI, err)
+	}
+	if root.kind != docMapping {
+		return nil, fmt.Errorf("%s is not an OpenAPI document", p.OpenAPI)
+	}
+	if root.get("swagger") != nil {
+		return nil, fmt.Errorf("%s is a Swagger 2.0 document: convert it to OpenAPI 3 first, e.g. with swagger2openapi", p.OpenAPI)
+	}
+	if version := root.str("openapi"); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("%s: unsupported OpenAPI version %q: only OpenAPI 3 documents are supported", p.OpenAPI, version)
+	}
+	return &openAPIDoc{rel: openAPICopy(p.OpenAPI), src: src, root: root}, nil
+}
+
+// apiModelFile is passed to the template of a model generated from a
+// schema.
+type apiModelFile struct {
+	Imports []string
+	Types   []apiType
+}
+
+// apiType is a type declared by an apiModelFile: a struct of Fields, or a
+// type of Type, the underlying type of the constants of Values.
+type apiType struct {
+	Name   string
+	Doc    []string
+	Struct bool
+	Fields []apiField
+	Type   string
+	Values []apiValue
+}
+
+// apiField is a field of a struct generated from a schema.
+type apiField struct {
+	Name, Type, Tag string
+	Doc             []string
+}
+
+// apiValue is a constant of an enum generated from a schema, with its Go
+// literal.
+type apiValue struct {
+	Name, Value string
+}
+
+// apiControllerFile is passed to the controller template of the handlers
+// of one tag.
+type apiControllerFile struct {
+	Handlers []apiHandler
+}
+
+// apiHandler is a handler of an operation.
+type apiHandler struct {
+	Name string
+	Doc  []string
+}
+
+// apiGenerator generates the files of a project from its OpenAPI
+// document.
+type apiGenerator struct {
+	doc      *openAPIDoc
+	schemas  *docNode
+	warnings []string
+	// names are the Go names of the component schemas, and models the
+	// names declared in package models.
+	names  map[string]string
+	models map[string]bool
+	// file is the model being generated, imports the packages it needs and
+	// self the name of the type of its schema.
+	file    *apiModelFile
+	imports map[string]bool
+	self    string
+}
+
+func (g *apiGenerator) warn(format string, args ...any) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, args...))
+}
+
+// addOpenAPI adds the files generated from the OpenAPI document of data to
+// files: its copy, a model per component schema, a controller per tag with
+// a handler answering 501 for each operation, and the routes of the
+// operations in the router. What the document has that cannot be
+// generated, such as callbacks, is reported as warnings.
+func (p *Project) addOpenAPI(files []templateFile, data TemplateData) ([]templateFile, []string, error) {
+	doc := data.openAPI
+	g := &apiGenerator{
+		doc:     doc,
+		schemas: doc.root.get("components").get("schemas"),
+		names:   make(map[string]string),
+		models:  renderedDecls(files, "models"),
+	}
+	files = append(files, templateFile{path: doc.rel, content: string(doc.src)})
+
+	models, err := g.modelFiles(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = append(files, models...)
+	if files, err = g.addOperations(files, data); err != nil {
+		return nil, nil, err
+	}
+	return files, g.warnings, nil
+}
+
+// modelFiles returns models/<name>.go for each component schema.
+func (g *apiGenerator) modelFiles(files []templateFile) ([]templateFile, error) {
+	if g.schemas == nil {
+		return nil, nil
+	}
+	for _, key := range g.schemas.keys {
+		name := g.unique(goName(key))
+		if name != goName(key) {
+			g.warn("models.%s is declared already, so the %s schema is generated as models.%s", goName(key), key, name)
+		}
+		g.names[key] = name
+	}
+
+	exists := make(map[string]bool)
+	for _, f := range files {
+		exists[f.path] = true
+	}
+	var models []templateFile
+	for _, key := range g.schemas.keys {
+		s, name := g.schemas.fields[key], g.names[key]
+		g.file, g.imports, g.self = &apiModelFile{}, make(map[string]bool), name
+		where := fmt.Sprintf("the %s schema of %s", key, g.doc.rel)
+		doc := wrapComment(strings.Join(append([]string{fmt.Sprintf("%s is %s.", name, where)}, describe(s)...), " "))
+
+		switch resolved := g.resolve(s); {
+		case isStruct(s):
+			g.structType(name, s, doc)
+		case len(resolved.get("enum").list()) > 0 && s.get("$ref") == nil:
+			g.enumType(name, s, doc)
+		default:
+			g.file.Types = append(g.file.Types, apiType{Name: name, Doc: doc, Type: strings.TrimPrefix(g.goType(s, name, where), "*")})
+		}
+		for path := range g.imports {
+			g.file.Imports = append(g.file.Imports, path)
+		}
+		sort.Strings(g.file.Imports)
+
+		rel := "models/" + snakeCase(name) + ".go"
+		if exists[rel] {
+			rel = "models/" + snakeCase(name) + "_schema.go"
+		}
+		exists[rel] = true
+		content, err := renderGoTemplate("templates/openapi/model.go.tmpl", g.file)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, templateFile{path: rel, content: content})
+	}
+	return models, nil
+}
+
+// unique returns name, or name with a suffix if package models declares
+// it already, and reserves it.
+func (g *apiGenerator) unique(name string) string {
+	unique := name
+	if g.models[unique] {
+		unique = name + "Schema"
+	}
+	for i := 2; g.models[unique]; i++ {
+		unique = name + "Schema" + strconv.Itoa(i)
+	}
+	g.models[unique] = true
+	return unique
+}
+
+// resolve follows the reference of schema s to a component schema, if it
+// is one.
+func (g *apiGenerator) resolve(s *docNode) *docNode {
+	for i := 0; i < 10 && s != nil; i++ {
+		name, ok := strings.CutPrefix(s.str("$ref"), "#/components/schemas/")
+		if !ok {
+			return s
+		}
+		s = g.schemas.get(name)
+	}
+	return s
+}
+
+// isStruct reports whether schema s is generated as a struct: an object
+// with properties, or a composition with allOf.
+func isStruct(s *docNode) bool {
+	return s.get("$ref") == nil && (s.get("properties") != nil || len(s.get("allOf").list()) > 1)
+}
+
+// schemaType returns the type of schema s, and whether it allows null, as
+// OpenAPI 3.1 lists it among the types and 3.0 says with nullable.
+func schemaType(s *docNode) (typ string, null bool) {
+	null = s.str("nullable") == "true"
+	switch v := s.get("type"); {
+	case v == nil:
+	case v.kind == docScalar:
+		typ = v.value
+	case v.kind == docSequence:
+		for _, item := range v.items {
+			if item.value == "null" {
+				null = true
+			} else if typ == "" {
+				typ = item.value
+			}
+		}
+	}
+	return typ, null
+}
+
+// goType returns the Go type of schema s, declaring the structs of inline
+// objects as hint. where describes s in warnings.
+func (g *apiGenerator) goType(s *docNode, hint, where string) string {
+	if s == nil || s.kind != docMapping {
+		return "any"
+	}
+	if ref := s.str("$ref"); ref != "" {
+		if name, ok := strings.CutPrefix(ref, "#/components/schemas/"); ok && g.names[name] != "" {
+			return g.names[name]
+		}
+		g.warn("%s: the reference %s is not to a component schema of the document, so it is generated as any", where, ref)
+		return "any"
+	}
+	if isStruct(s) {
+		name := g.unique(hint)
+		return g.structType(name, s, []string{fmt.Sprintf("%s is %s.", name, where)})
+	}
+	if all := s.get("allOf").list(); len(all) == 1 {
+		return g.goType(all[0], hint, where)
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		alts := s.get(key).list()
+		if len(alts) == 0 {
+			continue
+		}
+		var rest []*docNode
+		null := false
+		for _, alt := range alts {
+			if typ, _ := schemaType(alt); typ == "null" {
+				null = true
+			} else {
+				rest = append(rest, alt)
+			}
+		}
+		if len(rest) == 1 {
+			if t := g.goType(rest[0], hint, where); null {
+				return nullable(t)
+			} else {
+				return t
+			}
+		}
+		g.warn("%s: %s with %d schemas is not supported, so it is generated as any", where, key, len(rest))
+		return "any"
+	}
+
+	typ, null := schemaType(s)
+	var t string
+	switch format := s.str("format"); typ {
+	case "string":
+		switch format {
+		case "date-time":
+			g.imports["time"] = true
+			t = "time.Time"
+		case "byte":
+			t = "[]byte"
+		default:
+			t = "string"
+		}
+	case "integer":
+		t = "int"
+		if format == "int32" || format == "int64" {
+			t = format
+		}
+	case "number":
+		t = "float64"
+		if format == "float" {
+			t = "float32"
+		}
+	case "boolean":
+		t = "bool"
+	case "array":
+		t = "[]" + g.goType(s.get("items"), hint+"Item", "an item of "+where)
+	default:
+		t = "any"
+		if ap := s.get("additionalProperties"); ap != nil && ap.kind == docMapping {
+			t = "map[string]" + g.goType(ap, hint+"Value", "a value of "+where)
+		} else if typ == "object" {
+			t = "map[string]any"
+		}
+	}
+	if null {
+		return nullable(t)
+	}
+	return t
+}
+
+// nullable returns the type of a value of type t that may be null.
+func nullable(t string) string {
+	if t == "any" || strings.HasPrefix(t, "*") || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") {
+		return t
+	}
+	return "*" + t
+}
+
+// apiProperty is a property of an object schema.
+type apiProperty struct {
+	key    string
+	schema *docNode
+}
+
+// properties returns the properties of the object schema s, with those of
+// the schemas it composes with allOf first, and the names of those it
+// requires.
+func (g *apiGenerator) properties(s *docNode, seen map[*docNode]bool) ([]apiProperty, map[string]bool) {
+	var props []apiProperty
+	required := make(map[string]bool)
+	s = g.resolve(s)
+	if s == nil || seen[s] {
+		return nil, required
+	}
+	seen[s] = true
+	add := func(p apiProperty) {
+		for i := range props {
+			if props[i].key == p.key {
+				props[i] = p
+				return
+			}
+		}
+		props = append(props, p)
+	}
+	for _, part := range s.get("allOf").list() {
+		partProps, partRequired := g.properties(part, seen)
+		for _, p := range partProps {
+			add(p)
+		}
+		for key := range partRequired {
+			required[key] = true
+		}
+	}
+	if p := s.get("properties"); p != nil {
+		for _, key := range p.keys {
+			add(apiProperty{key, p.fields[key]})
+		}
+	}
+	for _, item := range s.get("required").list() {
+		required[item.value] = true
+	}
+	return props, required
+}
+
+// structType declares name as a struct of the properties of schema s, and
+// returns name.
+func (g *apiGenerator) structType(name string, s *docNode, doc []string) string {
+	// Structs of inline objects are declared after the struct they are in
+	i := len(g.file.Types)
+	g.file.Types = append(g.file.Types, apiType{})
+	t := apiType{Name: name, Doc: doc, Struct: true}
+	props, required := g.properties(s, make(map[*docNode]bool))
+	used := make(map[string]bool)
+	for _, p := range props {
+		where := fmt.Sprintf("the %s property of %s", p.key, name)
+		if strings.ContainsAny(p.key, "\"`,\\") {
+			g.warn("%s is left out: its name cannot be a json tag", where)
+			continue
+		}
+		field := goName(p.key)
+		for n := 2; used[field]; n++ {
+			field = goName(p.key) + strconv.Itoa(n)
+		}
+		used[field] = true
+		typ := g.goType(p.schema, name+field, where)
+		if typ == g.self || typ == name {
+			// A struct cannot contain itself
+			typ = "*" + typ
+		}
+		tag := fmt.Sprintf(`json:"%s"`, p.key)
+		if !required[p.key] {
+			tag = fmt.Sprintf(`json:"%s,omitempty"`, p.key)
+		}
+		if rules := validateRules(g.resolve(p.schema), typ, required[p.key]); rules != "" {
+			tag += fmt.Sprintf(` validate:"%s"`, rules)
+		}
+		t.Fields = append(t.Fields, apiField{Name: field, Type: typ, Tag: tag, Doc: describe(p.schema)})
+	}
+	g.file.Types[i] = t
+	return name
+}
+
+// enumType declares name as a type of the enum of schema s, with a
+// constant of each value.
+func (g *apiGenerator) enumType(name string, s *docNode, doc []string) {
+	typ, _ := schemaType(s)
+	t := apiType{Name: name, Doc: doc, Type: strings.TrimPrefix(g.goType(s, name, "the "+name+" schema"), "*")}
+	for i, v := range s.get("enum").list() {
+		if v.kind != docScalar {
+			continue
+		}
+		value := strconv.Quote(v.value)
+		if typ == "integer" || typ == "number" {
+			if _, err := strconv.ParseFloat(v.value, 64); err != nil {
+				g.warn("the %s schema: the enum value %q is not a number, so it has no constant", name, v.value)
+				continue
+			}
+			value = v.value
+		}
+		suffix := camelCase(identWords(v.value))
+		if suffix == "" || !token.IsIdentifier(name+suffix) {
+			suffix = "Value" + strconv.Itoa(i+1)
+		}
+		t.Values = append(t.Values, apiValue{Name: g.unique(name + suffix), Value: value})
+	}
+	g.file.Types = append(g.file.Types, t)
+}
+
+// validateRules returns the go-playground/validator rules of a field of
+// type typ with the schema s: required if it is, and the constraints of
+// the schema the validator has rules for.
+func validateRules(s *docNode, typ string, required bool) string {
+	var rules []string
+	if required && validateRule(typ) != "" && typ != "any" {
+		rules = append(rules, "required")
+	}
+	number := func(key string) (string, bool) {
+		v := s.str(key)
+		_, err := strconv.ParseFloat(v, 64)
+		return v, v != "" && err == nil
+	}
+	bound := func(key, inclusive, exclusive string) {
+		if v, ok := number(key); ok {
+			if s.str("exclusive"+strings.ToUpper(key[:1])+key[1:]) == "true" {
+				rules = append(rules, exclusive+"="+v)
+			} else {
+				rules = append(rules, inclusive+"="+v)
+			}
+		} else if v, ok := number("exclusive" + strings.ToUpper(key[:1]) + key[1:]); ok {
+			rules = append(rules, exclusive+"="+v)
+		}
+	}
+	kind, _ := schemaType(s)
+	switch kind {
+	case "string":
+		if v, ok := number("minLength"); ok {
+			rules = append(rules, "min="+v)
+		}
+		if v, ok := number("maxLength"); ok {
+			rules = append(rules, "max="+v)
+		}
+		switch s.str("format") {
+		case "email":
+			rules = append(rules, "email")
+		case "uuid":
+			rules = append(rules, "uuid")
+		case "uri", "url":
+			rules = append(rules, "url")
+		case "ipv4", "ipv6":
+			rules = append(rules, s.str("format"))
+		}
+	case "integer", "number":
+		bound("minimum", "gte", "gt")
+		bound("maximum", "lte", "lt")
+	case "array":
+		if v, ok := number("minItems"); ok {
+			rules = append(rules, "min="+v)
+		}
+		if v, ok := number("maxItems"); ok {
+			rules = append(rules, "max="+v)
+		}
+	}
+	if enum := s.get("enum").list(); len(enum) > 0 && (kind == "string" || kind == "integer") {
+		var values []string
+		for _, v := range enum {
+			if v.kind != docScalar || v.value == "" || strings.ContainsAny(v.value, " ,|\"'`\\") {
+				values = nil
+				break
+			}
+			values = append(values, v.value)
+		}
+		if values != nil {
+			rules = append(rules, "oneof="+strings.Join(values, " "))
+		}
+	}
+	if len(rules) > 0 && !required {
+		rules = append([]string{"omitempty"}, rules...)
+	}
+	return strings.Join(rules, ",")
+}
+
+// describe returns the first paragraph of the description of schema s as
+// comment lines.
+func describe(s *docNode) []string {
+	text := strings.TrimSpace(s.str("description"))
+	if text == "" {
+		return nil
+	}
+	if i := strings.Index(text, "\n\n"); i >= 0 {
+		text = text[:i]
+	}
+	return wrapComment(text)
+}
+
+// wrapComment splits text into lines of comment of at most about 70
+// characters.
+func wrapComment(text string) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > 70 {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// identWords returns name with anything but letters and digits replaced
+// by spaces, separating the words of an identifier.
+func identWords(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, name)
+}
+
+// goName returns name as an exported Go identifier.
+func goName(name string) string {
+	name = camelCase(identWords(name))
+	if name != "" && !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// renderedDecls returns the names declared at the top level of the
+// rendered Go files in dir.
+func renderedDecls(files []templateFile, dir string) map[string]bool {
+	names := make(map[string]bool)
+	for _, f := range files {
+		if path.Dir(f.path) != dir || !strings.HasSuffix(f.path, ".go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), f.path, f.content, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					names[decl.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						names[spec.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							names[id.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// apiOperation is an operation of the document.
+type apiOperation struct {
+	method, path, name, tag string
+	// route is the path the operation is registered at, relative to the
+	// base path of the server, in the syntax of validateRoutePath, or
+	// empty if it cannot be registered.
+	route string
+}
+
+// addOperations adds a controller for each tag of the operations of the
+// document to files, and registers the operations in the router.
+func (g *apiGenerator) addOperations(files []templateFile, data TemplateData) ([]templateFile, error) {
+	router := -1
+	for i, f := range files {
+		if f.path == routerPath {
+			router = i
+		}
+	}
+	if router < 0 {
+		return nil, fmt.Errorf("%s not found", routerPath)
+	}
+	root := g.doc.root
+	if root.get("webhooks") != nil {
+		g.warn("the webhooks of %s are not generated: they are requests the API sends", g.doc.rel)
+	}
+	base := g.basePath()
+
+	// Routers need the parameters at the same position of paths sharing
+	// their prefix to have the same name
+	params := make(map[string]string)
+	taken := renderedDecls(files, "controller")
+	var ops []apiOperation
+	var tags []string
+	handlers := make(map[string]*apiControllerFile)
+	paths := root.get("paths")
+	if paths == nil {
+		return files, nil
+	}
+	for _, p := range paths.keys {
+		item := paths.fields[p]
+		if item.get("$ref") != nil {
+			g.warn("%s: path items referencing another are not supported; add its routes by hand", p)
+			continue
+		}
+		if item.get("trace") != nil {
+			g.warn("TRACE %s is not generated: not every framework routes TRACE requests", p)
+		}
+		for _, method := range openAPIMethods {
+			op := item.get(method)
+			if op == nil {
+				continue
+			}
+			upper := strings.ToUpper(method)
+			where := upper + " " + p
+			if op.get("callbacks") != nil {
+				g.warn("%s: callbacks are not generated: they are requests the API sends", where)
+			}
+			o := apiOperation{method: upper, path: p, tag: "api"}
+			if tags := op.get("tags").list(); len(tags) > 0 && goName(tags[0].value) != "" {
+				o.tag = tags[0].value
+			}
+			o.name = operationName(method, p, op.str("operationId"))
+			for n := 2; taken[o.name]; n++ {
+				o.name = operationName(method, p, op.str("operationId")) + strconv.Itoa(n)
+			}
+			taken[o.name] = true
+			o.route = g.route(p, where, params)
+			ops = append(ops, o)
+
+			file := handlers[o.tag]
+			if file == nil {
+				file = &apiControllerFile{}
+				handlers[o.tag] = file
+				tags = append(tags, o.tag)
+			}
+			file.Handlers = append(file.Handlers, apiHandler{Name: o.name, Doc: handlerDoc(o.name, upper, joinRoutePath(base, p), op, g.doc.rel)})
+		}
+	}
+
+	exists := make(map[string]bool)
+	for _, f := range files {
+		exists[f.path] = true
+	}
+	for _, tag := range tags {
+		rel := "controller/" + snakeCase(goName(tag)) + "_controller.go"
+		if exists[rel] {
+			rel = "controller/" + snakeCase(goName(tag)) + "_api_controller.go"
+		}
+		exists[rel] = true
+		content, err := renderGoTemplate("templates/openapi/controller/"+data.Framework+".go.tmpl", handlers[tag])
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, templateFile{path: rel, content: content})
+	}
+
+	src, err := g.registerOperations(files, router, ops, base, data)
+	if err != nil {
+		return nil, err
+	}
+	files[router].content = src
+	return files, nil
+}
+
+// basePath returns the path of the URL of the first server of the
+// document, without a trailing slash, which the paths are relative to.
+func (g *apiGenerator) basePath() string {
+	servers := g.doc.root.get("servers").list()
+	if len(servers) == 0 {
+		return ""
+	}
+	url := servers[0].str("url")
+	if vars := servers[0].get("variables"); vars != nil {
+		for _, key := range vars.keys {
+			url = strings.ReplaceAll(url, "{"+key+"}", vars.fields[key].str("default"))
+		}
+	}
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = ""
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			url = rest[i:]
+		}
+	}
+	url = strings.TrimSuffix(url, "/")
+	if url == "" {
+		return ""
+	}
+	if err := validateRoutePath(url); err != nil || strings.ContainsAny(url, "{}:*") {
+		g.warn("the path of the server URL %s is not supported, so the routes are registered without it", servers[0].str("url"))
+		return ""
+	}
+	return url
+}
+
+// route returns the path the operation at path p is registered at, with
+// the parameters named like Go identifiers and like those at the same
+// position of the paths registered before, or "" if p cannot be
+// registered.
+func (g *apiGenerator) route(p, where string, params map[string]string) string {
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		if !strings.ContainsAny(seg, "{}") {
+			continue
+		}
+		name, ok := strings.CutPrefix(seg, "{")
+		if name, ok = strings.CutSuffix(name, "}"); !ok || strings.ContainsAny(name, "{}") {
+			g.warn("%s is not registered: the routers only match parameters that are whole segments; register it by hand", where)
+			return ""
+		}
+		param := name
+		if !token.IsIdentifier(param) {
+			param = lowerCamelCase(identWords(name))
+		}
+		if !token.IsIdentifier(param) {
+			param = "param" + strconv.Itoa(i)
+		}
+		key := routeKey(strings.Join(segs[:i+1], "/"))
+		if prev, ok := params[key]; ok {
+			param = prev
+		}
+		params[key] = param
+		if param != name {
+			g.warn("%s: the path parameter %s is named %s in the route", where, name, param)
+		}
+		segs[i] = "{" + param + "}"
+	}
+	route := strings.Join(segs, "/")
+	if err := validateRoutePath(route); err != nil {
+		g.warn("%s is not registered: %v; register it by hand", where, err)
+		return ""
+	}
+	return route
+}
+
+// registerOperations returns the source of the router in files[router]
+// with the routes of ops added: to AddV1Routes if base is the path of the
+// v1 group, and to InitializeRoutes otherwise. Routes the router has
+// already are skipped with a warning.
+func (g *apiGenerator) registerOperations(files []templateFile, router int, ops []apiOperation, base string, data TemplateData) (string, error) {
+	src := files[router].content
+	rf, err := parseRouterSource([]byte(src))
+	if err != nil {
+		return "", err
+	}
+	fn, group := rf.setup, false
+	if rf.v1 != nil && base != "" && base == rf.v1Path() {
+		fn, group = rf.v1, true
+	}
+	v, err := rf.routerVar(fn)
+	if err != nil {
+		return "", err
+	}
+	registered := renderedRoutes(files, data.Module)
+	requestID := renderedDecls(files, "middleware")["RequestID"]
+
+	var stmts strings.Builder
+	for _, o := range ops {
+		if o.route == "" {
+			continue
+		}
+		full := frameworkPath(data.Framework, joinRoutePath(base, o.route))
+		dup := false
+		for _, r := range registered {
+			if routeKey(r.Path) == routeKey(full) && (r.Method == "ANY" || r.Method == o.method) {
+				g.warn("%s %s is not registered: the router has %s %s already, to %s", o.method, o.path, r.Method, r.Path, r.Handler)
+				dup = true
+				break
+			}
+		}
+		if dup {
+			continue
+		}
+		registered = append(registered, Route{Method: o.method, Path: full, Handler: "controller." + o.name})
+
+		path := full
+		if group {
+			path = frameworkPath(data.Framework, o.route)
+		}
+		rd := routeData{Name: o.name, Method: o.method, MethodName: o.method[:1] + strings.ToLower(o.method[1:]), Path: path, FullPath: full, Router: v, RequestID: requestID, Group: group}
+		stmt, err := renderTemplate(templates, "templates/generate/route/routes/"+data.Framework+".go.tmpl", rd)
+		if err != nil {
+			return "", err
+		}
+		stmts.WriteString(strings.Trim(stmt, "\n") + "\n")
+	}
+	if stmts.Len() == 0 {
+		return src, nil
+	}
+	imports := []string{data.Module + "/controller"}
+	if data.Framework == "stdlib" && !group {
+		imports = append(imports, "net/http", data.Module+"/middleware")
+	}
+	out, err := rf.appendTo(fn, "\t// The operations of "+g.doc.rel+"\n"+stmts.String(), imports...)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// operationName returns the name of the handler of the operation method
+// at path p: its operationId, or else the method and the path, e.g.
+// GetPetsByPetID for GET /pets/{petId}.
+func operationName(method, p, operationID string) string {
+	if name := goName(operationID); name != "" {
+		return name
+	}
+	name := goName(method)
+	for _, seg := range strings.Split(p, "/") {
+		if param, ok := strings.CutPrefix(seg, "{"); ok {
+			name += "By" + goName(strings.TrimSuffix(param, "}"))
+		} else {
+			name += goName(seg)
+		}
+	}
+	return name
+}
+
+// handlerDoc returns the doc comment of the handler name of the operation
+// op of method and path, declared in the document rel.
+func handlerDoc(name, method, path string, op *docNode, rel string) []string {
+	summary := strings.TrimSpace(op.str("summary"))
+	if summary == "" {
+		summary = strings.TrimSpace(op.str("description"))
+		if i := strings.IndexAny(summary, "\n."); i >= 0 {
+			summary = summary[:i]
+		}
+	}
+	first := fmt.Sprintf("%s handles %s %s", name, method, path)
+	if summary != "" {
+		first += ": " + strings.TrimSuffix(summary, ".") + "."
+	}
+	doc := wrapComment(first)
+
+	var responses []string
+	if r := op.get("responses"); r != nil {
+		for _, code := range r.keys {
+			desc := strings.TrimSpace(r.fields[code].str("description"))
+			if i := strings.IndexByte(desc, '\n'); i >= 0 {
+				desc = desc[:i]
+			}
+			if desc != "" {
+				code += " (" + strings.TrimSuffix(desc, ".") + ")"
+			}
+			responses = append(responses, code)
+		}
+	}
+	if len(responses) > 0 {
+		doc = append(doc, "")
+		doc = append(doc, wrapComment(fmt.Sprintf("%s declares the responses %s. Until it is implemented, it answers 501 Not Implemented.", rel, strings.Join(responses, ", ")))...)
+	}
+	return doc
+}
@@ -0,0 +1,382 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Choice is one of the values of an Option.
+type Choice struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Option is an option of a project taking one of a fixed set of values,
+// named after the flag of 'gomvc new' setting it.
+type Option struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Default is the value used when none is given, empty if the option
+	// is off by default.
+	Default string   `json:"default,omitempty"`
+	Choices []Choice `json:"choices"`
+}
+
+// Incompatibility is a combination of options that cannot be generated.
+type Incompatibility struct {
+	Description string `json:"description"`
+	// check returns an error naming the combination if p has it.
+	check func(p *Project) error
+}
+
+// option is an entry of the options registry.
+type option struct {
+	name, description, def string
+	// names returns the values of the option, each described in choices.
+	names   func() []string
+	choices map[string]string
+	// value returns the value of the option in p, empty if it is not set,
+	// and validate checks it.
+	value    func(p *Project) string
+	validate func(p *Project, value string) error
+}
+
+// options is the registry of the options of a project with a fixed set of
+// values. CheckOptions validates projects against it and Options lists it,
+// so what gomvc accepts and what it documents cannot disagree.
+var options = []option{
+	{
+		name: "framework", description: "Web framework the project is generated for", def: DefaultFramework,
+		names: Frameworks,
+		choices: map[string]string{
+			"chi":    "chi router, compatible with net/http",
+			"echo":   "Echo framework",
+			"fiber":  "Fiber framework, built on fasthttp",
+			"gin":    "Gin framework, with CORS and pprof middleware",
+			"stdlib": "net/http only, without a framework",
+		},
+		value:    func(p *Project) string { return p.Framework },
+		validate: func(_ *Project, v string) error { return ValidateFramework(v) },
+	},
+	{
+		name: "layout", description: "How the packages of the project are arranged", def: DefaultLayout,
+		names: Layouts,
+		choices: map[string]string{
+			"clean":     "internal/domain, service, repository and handler packages",
+			"hexagonal": "internal/core with HTTP and storage adapters",
+			"minimal":   "a single main package, without config or middleware",
+			"mvc":       "controller, models, router and middleware packages",
+		},
+		value:    func(p *Project) string { return p.Layout },
+		validate: func(_ *Project, v string) error { return ValidateLayout(v) },
+	},
+	{
+		name: "mode", description: "What the project serves", def: DefaultMode,
+		names: Modes,
+		choices: map[string]string{
+			"api":  "a JSON API",
+			"htmx": "HTML pages updated with htmx, static files and the API",
+			"web":  "HTML pages, static files and the API",
+		},
+		value:    func(p *Project) string { return p.Mode },
+		validate: func(_ *Project, v string) error { return ValidateMode(v) },
+	},
+	{
+		name: "api", description: "Style of the API", def: DefaultAPI,
+		names: APIStyles,
+		choices: map[string]string{
+			"graphql": "a GraphQL API generated by gqlgen next to the REST routes",
+			"rest":    "REST routes only",
+		},
+		value:    func(p *Project) string { return p.API },
+		validate: func(_ *Project, v string) error { return ValidateAPI(v) },
+	},
+	{
+		name: "css", description: "How the pages of the web and htmx modes are styled", def: DefaultCSS,
+		names: CSSSetups,
+		choices: map[string]string{
+			"plain":    "a plain stylesheet",
+			"tailwind": "a stylesheet built with Tailwind CSS",
+		},
+		value:    func(p *Project) string { return p.CSS },
+		validate: func(_ *Project, v string) error { return ValidateCSS(v) },
+	},
+	{
+		name: "db", description: "Database wired into the project",
+		names: Databases,
+		choices: map[string]string{
+			"mongo":    "MongoDB",
+			"postgres": "PostgreSQL",
+			"sqlite":   "SQLite, in a file next to the project",
+		},
+		value:    func(p *Project) string { return p.Database },
+		validate: func(_ *Project, v string) error { return ValidateDatabase(v, "") },
+	},
+	{
+		name: "orm", description: "Library used to access the database, by default the first of its ORMs",
+		names: allORMs,
+		choices: map[string]string{
+			"driver": "the official driver (mongo)",
+			"gorm":   "GORM (postgres)",
+			"sql":    "database/sql (sqlite)",
+			"sqlx":   "sqlx and handwritten SQL (postgres)",
+		},
+		value: func(p *Project) string { return p.ORM },
+		validate: func(p *Project, v string) error {
+			if p.Database == "" {
+				// Reported as an incompatibility
+				return nil
+			}
+			return ValidateDatabase(p.Database, v)
+		},
+	},
+	{
+		name: "auth", description: "Authentication generated, with register and login routes",
+		names: AuthSchemes,
+		choices: map[string]string{
+			"jwt":     "JSON Web Tokens",
+			"session": "cookie sessions",
+		},
+		value:    func(p *Project) string { return p.Auth },
+		validate: func(_ *Project, v string) error { return ValidateAuth(v) },
+	},
+	{
+		name: "config", description: "How the project reads its settings", def: defaultConfig,
+		names: Configs,
+		choices: map[string]string{
+			"env":   "environment variables and an optional .env file",
+			"viper": "Viper, reading a config file and environment variables",
+		},
+		value:    func(p *Project) string { return p.Config },
+		validate: func(_ *Project, v string) error { return ValidateConfig(v) },
+	},
+	{
+		name: "cache", description: "Shared cache of responses, kept in memory in development",
+		names: Caches,
+		choices: map[string]string{
+			"redis": "Redis",
+		},
+		value:    func(p *Project) string { return p.Cache },
+		validate: func(_ *Project, v string) error { return ValidateCache(v) },
+	},
+	{
+		name: "messaging", description: "Message broker events are published to",
+		names: MessagingBrokers,
+		choices: map[string]string{
+			"nats": "NATS, with an embedded server in development",
+		},
+		value:    func(p *Project) string { return p.Messaging },
+		validate: func(_ *Project, v string) error { return ValidateMessaging(v) },
+	},
+	{
+		name: "ratelimit-scope", description: "Routes -ratelimit limits", def: DefaultRateLimitScope,
+		names: RateLimitScopes,
+		choices: map[string]string{
+			"api":    "only the versioned API",
+			"global": "every route",
+		},
+		value:    func(p *Project) string { return p.RateLimitScope },
+		validate: func(_ *Project, v string) error { return ValidateRateLimitScope(v) },
+	},
+	{
+		name: "line-endings", description: "Line endings of the files written", def: DefaultLineEndings,
+		names: LineEndings,
+		choices: map[string]string{
+			"auto": "those of the OS gomvc runs on",
+			"crlf": "Windows line endings, CRLF",
+			"lf":   "Unix line endings, LF",
+		},
+		value:    func(p *Project) string { return p.LineEndings },
+		validate: func(_ *Project, v string) error { return ValidateLineEndings(v) },
+	},
+	{
+		name: "di", description: "Where the dependencies of the server are composed, by default in main.go",
+		names: DIModes,
+		choices: map[string]string{
+			"app":  "internal/app, constructing them by hand",
+			"wire": "internal/app, with an injector generated by Wire",
+		},
+		value:    func(p *Project) string { return p.DI },
+		validate: func(_ *Project, v string) error { return ValidateDI(v) },
+	},
+	{
+		name: "ci", description: "CI pipeline building, vetting and testing the project",
+		names: CIProviders,
+		choices: map[string]string{
+			"github": "GitHub Actions",
+			"gitlab": "GitLab CI",
+		},
+		value:    func(p *Project) string { return p.CI },
+		validate: func(_ *Project, v string) error { return ValidateCI(v) },
+	},
+}
+
+// incompatibilities is the registry of the combinations of options that
+// cannot be generated, checked by CheckOptions and listed by
+// Incompatibilities.
+var incompatibilities = []Incompatibility{
+	{
+		Description: mvcOnlyDescription(),
+		check:       (*Project).checkLayoutOptions,
+	},
+	{
+		Description: "-orm needs -db",
+		check: func(p *Project) error {
+			if p.ORM != "" && p.Database == "" {
+				return errors.New("an ORM can only be chosen with a database")
+			}
+			return nil
+		},
+	},
+	{
+		Description: "-css other than " + DefaultCSS + " needs -mode web or htmx",
+		check: func(p *Project) error {
+			if p.mode() == DefaultMode && p.css() != DefaultCSS {
+				return fmt.Errorf("the %s CSS setup needs the web or htmx mode", p.css())
+			}
+			return nil
+		},
+	},
+	{
+		Description: "-mode htmx does not support -auth session yet",
+		check: func(p *Project) error {
+			if p.mode() == "htmx" && p.Auth == "session" {
+				return errors.New("the htmx mode does not support session authentication yet")
+			}
+			return nil
+		},
+	},
+	{
+		Description: "-ratelimit-scope needs -ratelimit",
+		check: func(p *Project) error {
+			if !p.RateLimit && p.rateLimitScope() != DefaultRateLimitScope {
+				return errors.New("the rate limit scope can only be set with rate limiting")
+			}
+			return nil
+		},
+	},
+	{
+		Description: "-grpc-ignore-gen needs -grpc",
+		check: func(p *Project) error {
+			if !p.GRPC && p.GRPCIgnoreGen {
+				return errors.New("the generated gRPC code can only be kept out of git with gRPC")
+			}
+			return nil
+		},
+	},
+	{
+		Description: "-grpc cannot be used with -port " + grpcPort + ", which the gRPC server listens on",
+		check: func(p *Project) error {
+			if p.GRPC && p.port() == grpcPort {
+				return fmt.Errorf("port %s is taken by the gRPC server", grpcPort)
+			}
+			return nil
+		},
+	},
+	{
+		Description: "-framework stdlib cannot use -port " + pprofPort + ", which its pprof listener takes, except with -layout minimal",
+		check: func(p *Project) error {
+			if p.framework() == "stdlib" && p.layout() != "minimal" && p.port() == pprofPort {
+				return fmt.Errorf("port %s is taken by the pprof listener of stdlib projects", pprofPort)
+			}
+			return nil
+		},
+	},
+	{
+		Description: middlewareDescription(),
+		check: func(p *Project) error {
+			if _, ok := middlewareUse[p.framework()]; len(p.Middleware) > 0 && !ok {
+				return fmt.Errorf("middleware cannot be registered in %s projects yet", p.framework())
+			}
+			return nil
+		},
+	},
+}
+
+// Options returns the options of a project with a fixed set of values, in
+// the order of the help of 'gomvc new', each with its values in sorted
+// order.
+func Options() []Option {
+	list := make([]Option, 0, len(options))
+	for _, o := range options {
+		opt := Option{Name: o.name, Description: o.description, Default: o.def, Choices: []Choice{}}
+		for _, name := range o.names() {
+			opt.Choices = append(opt.Choices, Choice{Name: name, Description: o.choices[name]})
+		}
+		list = append(list, opt)
+	}
+	return list
+}
+
+// Incompatibilities returns the combinations of options that cannot be
+// generated.
+func Incompatibilities() []Incompatibility {
+	return append([]Incompatibility(nil), incompatibilities...)
+}
+
+// CheckOptions returns an error if an option of p with a fixed set of
+// values has another value, or if p has options that cannot be combined.
+// Create calls it before anything else; callers can use it to report
+// mistakes before asking for anything.
+func (p *Project) CheckOptions() error {
+	for _, o := range options {
+		if v := o.value(p); v != "" {
+			if err := o.validate(p, v); err != nil {
+				return err
+			}
+		}
+	}
+	for _, inc := range incompatibilities {
+		if err := inc.check(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allORMs returns the ORMs supported with any database in sorted order.
+func allORMs() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, db := range Databases() {
+		for _, orm := range ORMs(db) {
+			if !seen[orm] {
+				seen[orm] = true
+				names = append(names, orm)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mvcOnlyDescription describes the options only the default layout
+// implements.
+func mvcOnlyDescription() string {
+	flags := make([]string, 0, len(mvcOnlyOptions))
+	for _, o := range mvcOnlyOptions {
+		flags = append(flags, o.flag)
+	}
+	var others []string
+	for _, name := range Layouts() {
+		if name != DefaultLayout {
+			others = append(others, name)
+		}
+	}
+	last := len(others) - 1
+	return fmt.Sprintf("-layout %s and %s support none of %s yet", strings.Join(others[:last], ", "), others[last], strings.Join(flags, ", "))
+}
+
+// middlewareDescription describes the frameworks -middleware cannot
+// register middleware in.
+func middlewareDescription() string {
+	var unsupported []string
+	for _, name := range Frameworks() {
+		if _, ok := middlewareUse[name]; !ok {
+			unsupported = append(unsupported, name)
+		}
+	}
+	return "-middleware cannot be used with -framework " + strings.Join(unsupported, ", ") + " yet"
+}
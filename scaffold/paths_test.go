@@ -0,0 +1,181 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSlashPaths(t *testing.T) {
+	m := &manifest{
+		Dirs:      []string{`controller\admin`, "models/", "./views", `pkg\sse\..\cache`},
+		Files:     []string{`controller\admin\user.go`, "main.go", "./router/router.go"},
+		Hashes:    map[string]string{`controller\admin\user.go`: "a", "main.go": "b"},
+		Generated: map[string]string{`router\router.go`: "c"},
+	}
+	m.slashPaths()
+	if want := []string{"controller/admin", "models", "views", "pkg/cache"}; !slices.Equal(m.Dirs, want) {
+		t.Errorf("Dirs = %q, want %q", m.Dirs, want)
+	}
+	if want := []string{"controller/admin/user.go", "main.go", "router/router.go"}; !slices.Equal(m.Files, want) {
+		t.Errorf("Files = %q, want %q", m.Files, want)
+	}
+	if want := map[string]string{"controller/admin/user.go": "a", "main.go": "b"}; !maps.Equal(m.Hashes, want) {
+		t.Errorf("Hashes = %q, want %q", m.Hashes, want)
+	}
+	if want := map[string]string{"router/router.go": "c"}; !maps.Equal(m.Generated, want) {
+		t.Errorf("Generated = %q, want %q", m.Generated, want)
+	}
+}
+
+// TestManifestSlashes checks that a manifest written with backslashes, as
+// by hand on Windows, is read with slashes, and that the manifest of a
+// project records slash paths.
+func TestManifestSlashes(t *testing.T) {
+	p := createdProject(t, nil)
+	data, err := p.FS.ReadFile(filepath.Join(p.Root, manifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(`\\`)) {
+		t.Errorf("manifest has backslashes:\n%s", data)
+	}
+
+	edited := []byte(`{"module": "example.com/app", "dirs": ["controller\\admin"], "files": ["controller\\admin\\user.go"]}`)
+	if err := p.FS.WriteFile(filepath.Join(p.Root, manifestFile), edited, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := readManifest(p.FS, p.Root)
+	if err != nil {
+		t.Fatalf("readManifest: %v", err)
+	}
+	if !m.hasDir("controller/admin") || !m.hasFile("controller/admin/user.go") {
+		t.Errorf("manifest read as %q and %q, want slash paths", m.Dirs, m.Files)
+	}
+}
+
+func TestBaseName(t *testing.T) {
+	tests := []struct {
+		dir, want string
+	}{
+		{"app", "app"},
+		{"app/", "app"},
+		{filepath.Join("src", "my app"), "my app"},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests, []struct{ dir, want string }{
+			{`C:\src\app`, "app"},
+			{`C:\src\app\`, "app"},
+			{`C:/src/app`, "app"},
+			{`C:\`, "fallback"},
+			{`\\server\share`, "fallback"},
+			{`\\server\share\`, "fallback"},
+			{`\\server\share\app`, "app"},
+		}...)
+	} else {
+		tests = append(tests, []struct{ dir, want string }{
+			{"/src/app", "app"},
+			{"/src/app/", "app"},
+			{"/src/app/../web", "web"},
+			{"/", "fallback"},
+			{"//", "fallback"},
+		}...)
+	}
+	for _, tt := range tests {
+		if got := baseName(tt.dir, "fallback"); got != tt.want {
+			t.Errorf("baseName(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultModulePath(t *testing.T) {
+	root := "/"
+	if runtime.GOOS == "windows" {
+		root = `C:\`
+	}
+	tests := []struct {
+		dir, want string
+	}{
+		{filepath.Join(root, "src", "app"), "app"},
+		{filepath.Join(root, "src", "My App"), "my-app"},
+		{filepath.Join(root, "src", "Web_Shop"), "web_shop"},
+		{filepath.Join(root, "src", "--Shop  API--"), "shop-api"},
+		{filepath.Join(root, "src", "!!!"), "app"},
+		{root, "app"},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests, []struct{ dir, want string }{
+			{`C:\Users\me\My App\`, "my-app"},
+			{`\\server\share`, "app"},
+		}...)
+	}
+	for _, tt := range tests {
+		if got := DefaultModulePath(tt.dir); got != tt.want {
+			t.Errorf("DefaultModulePath(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestChangeLogRel(t *testing.T) {
+	root := filepath.FromSlash("/work/app")
+	if runtime.GOOS == "windows" {
+		root = `C:\work\app`
+	}
+	tests := []struct {
+		path, want string
+	}{
+		{filepath.Join(root, "main.go"), "main.go"},
+		{filepath.Join(root, "controller", "user.go"), filepath.FromSlash("controller/user.go")},
+		{root, "."},
+		{filepath.Join(filepath.Dir(root), "web"), filepath.FromSlash("../web")},
+	}
+	if runtime.GOOS == "windows" {
+		// On another volume, there is no relative path
+		tests = append(tests, struct{ path, want string }{`D:\work\app\main.go`, `D:\work\app\main.go`})
+	}
+	l := changeLog{root: root}
+	for _, tt := range tests {
+		if got := l.rel(tt.path); got != tt.want {
+			t.Errorf("rel(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWithLineEndings(t *testing.T) {
+	tests := []struct {
+		rel, content string
+		crlf         bool
+		want         string
+	}{
+		{"main.go", "package main\n", false, "package main\n"},
+		{"main.go", "package main\n\nfunc main() {}\n", true, "package main\r\n\r\nfunc main() {}\r\n"},
+		{"README.md", "a\r\nb\n", true, "a\r\nb\r\n"},
+		{"scripts/setup.sh", "echo hi\n", true, "echo hi\n"},
+		{"bin/run", "#!/bin/sh\necho hi\n", true, "#!/bin/sh\necho hi\n"},
+		{"Makefile", "", true, ""},
+	}
+	for _, tt := range tests {
+		if got := withLineEndings(tt.rel, tt.content, tt.crlf); got != tt.want {
+			t.Errorf("withLineEndings(%q, %q, %v) = %q, want %q", tt.rel, tt.content, tt.crlf, got, tt.want)
+		}
+	}
+}
+
+// TestPrintedPaths checks that the generators print paths the way the OS
+// writes them.
+func TestPrintedPaths(t *testing.T) {
+	var out strings.Builder
+	p := createdProject(t, func(p *Project) { p.Out = &out })
+	out.Reset()
+	if err := p.GenerateController(context.Background(), "Order", false); err != nil {
+		t.Fatalf("GenerateController: %v", err)
+	}
+	if want := "Created " + filepath.FromSlash("controller/order_controller.go"); !strings.Contains(out.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,91 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Open returns the project containing dir. It looks for go.mod in dir and
+// its parents and reads the module path from it. The framework is taken
+// from the gomvc manifest when there is one and detected from the go.mod
+// requirements otherwise, and so is the layout, from the directories of
+// the project.
+func Open(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for root := dir; ; root = filepath.Dir(root) {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if errors.Is(err, os.ErrNotExist) {
+			if filepath.Dir(root) == root {
+				return nil, fmt.Errorf("no go.mod found in %s or any parent directory", dir)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		module := modfile.ModulePath(data)
+		if module == "" {
+			return nil, fmt.Errorf("no module path found in %s", filepath.Join(root, "go.mod"))
+		}
+		p := &Project{Root: root, Module: module, Framework: detectFramework(data), Layout: detectLayout(root)}
+		if m, err := readManifest(OSFS{}, root); err == nil {
+			if m.Framework != "" {
+				p.Framework = m.Framework
+			}
+			if m.Layout != "" {
+				p.Layout = m.Layout
+			}
+		}
+		return p, nil
+	}
+}
+
+// detectLayout returns the layout whose directories the project at root
+// has, for projects without a manifest, or "" for the default layout.
+func detectLayout(root string) string {
+	isDir := func(rel string) bool {
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+		return err == nil && info.IsDir()
+	}
+	switch {
+	case isDir("controller"):
+		return ""
+	case isDir("internal/core") && isDir("internal/adapters"):
+		return "hexagonal"
+	case isDir("internal/domain") || isDir("internal/handler"):
+		return "clean"
+	}
+	if _, err := os.Stat(filepath.Join(root, "handlers.go")); err == nil {
+		return "minimal"
+	}
+	return ""
+}
+
+// detectFramework returns the framework whose module is required by the
+// go.mod file in data, or "stdlib" if there is none.
+func detectFramework(data []byte) string {
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return "stdlib"
+	}
+	for _, req := range f.Require {
+		for name, fw := range frameworks {
+			for _, r := range fw.requires {
+				if mod, _, _ := strings.Cut(r, "@"); mod == req.Mod.Path {
+					return name
+				}
+			}
+		}
+	}
+	return "stdlib"
+}
@@ -0,0 +1,52 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// timeRequire provides the token buckets of the in-memory rate limiter.
+const timeRequire = "golang.org/x/time@v0.16.0"
+
+// DefaultRateLimitScope is used when Project.RateLimitScope is empty.
+const DefaultRateLimitScope = "global"
+
+// rateLimitScopes lists the routes the rate limiter can guard, in sorted
+// order: "api" for those of the versioned API only, "global" for all of
+// them.
+var rateLimitScopes = []string{"api", "global"}
+
+// RateLimitScopes returns the supported rate limit scopes in sorted order.
+func RateLimitScopes() []string {
+	return slices.Clone(rateLimitScopes)
+}
+
+// ValidateRateLimitScope returns an error unless name is a supported rate
+// limit scope.
+func ValidateRateLimitScope(name string) error {
+	if !slices.Contains(rateLimitScopes, name) {
+		return fmt.Errorf("unknown rate limit scope %q (supported: %s)", name, strings.Join(rateLimitScopes, ", "))
+	}
+	return nil
+}
+
+// rateLimitScope returns the scope of the rate limiter, defaulting to
+// DefaultRateLimitScope.
+func (p *Project) rateLimitScope() string {
+	if p.RateLimitScope == "" {
+		return DefaultRateLimitScope
+	}
+	return p.RateLimitScope
+}
+
+// rateLimitLayers returns the template layers of -ratelimit: the limiters
+// of the middleware package, the framework's middleware and, with a cache,
+// the limiter keeping its buckets in Redis.
+func (p *Project) rateLimitLayers() []string {
+	layers := []string{"ratelimit/base", "ratelimit/" + p.framework()}
+	if p.Cache != "" {
+		layers = append(layers, "ratelimit/redis")
+	}
+	return layers
+}
@@ -0,0 +1,233 @@
+package scaffold
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TemplateManifestFile is the file at the root of a repository of
+// templates describing them. Repositories without it are refused, so that
+// a mistyped path does not render an unrelated repository into projects.
+const TemplateManifestFile = "gomvc-template.json"
+
+// TemplateManifest is the content of TemplateManifestFile.
+type TemplateManifest struct {
+	// Name identifies the templates in messages. It is required.
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Dir is the directory of the repository holding the templates, laid
+	// out like a -templates directory. It defaults to the root.
+	Dir string `json:"dir,omitempty"`
+}
+
+// RemoteTemplates fetches templates from a git repository into a cache, to
+// be used as Project.Templates.
+type RemoteTemplates struct {
+	// Source is the repository, optionally followed by @ and the tag,
+	// branch or commit to use, e.g. "github.com/org/templates@v1.2.0".
+	// Repositories without a scheme are fetched over HTTPS; URLs and
+	// local paths are passed to git as they are. Without a ref the
+	// default branch is used.
+	Source string
+	// CacheDir holds the fetched repositories, by default the
+	// gomvc/templates directory of os.UserCacheDir.
+	CacheDir string
+	// Update fetches Source again even if it is cached. If that fails, the
+	// cached copy is used with a warning.
+	Update bool
+	// Runner runs git, by default an ExecRunner.
+	Runner Runner
+	// Out receives progress messages and warnings; nil discards them.
+	Out io.Writer
+}
+
+// commitRef matches refs that are abbreviated or full commit hashes, which
+// git clone --branch cannot fetch.
+var commitRef = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// splitSource splits a template source into the repository to clone and
+// the ref, which is empty for the default branch.
+func splitSource(source string) (repo, ref string) {
+	repo = source
+	if i := strings.LastIndex(source, "@"); i > strings.LastIndexAny(source, "/:") && i > 0 {
+		repo, ref = source[:i], source[i+1:]
+	}
+	switch {
+	case strings.Contains(repo, "://"), strings.HasPrefix(repo, "git@"),
+		filepath.IsAbs(repo), strings.HasPrefix(repo, "."):
+	default:
+		repo = "https://" + repo
+	}
+	return repo, ref
+}
+
+// cachePath returns the directory source is cached in.
+func (r *RemoteTemplates) cachePath() (string, error) {
+	dir := r.CacheDir
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("no cache directory for templates: %w", err)
+		}
+		dir = filepath.Join(cache, "gomvc", "templates")
+	}
+	name := strings.Map(func(c rune) rune {
+		if c == '/' || c == ':' || c == '\\' {
+			return '_'
+		}
+		return c
+	}, strings.TrimSuffix(r.Source, "/"))
+	if _, ref := splitSource(r.Source); ref == "" {
+		name += "@HEAD"
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Fetch returns the templates of Source, cloning the repository unless it
+// is cached or Update is set, and checks its TemplateManifestFile.
+func (r *RemoteTemplates) Fetch(ctx context.Context) (fs.FS, *TemplateManifest, error) {
+	out := r.Out
+	if out == nil {
+		out = io.Discard
+	}
+	dir, err := r.cachePath()
+	if err != nil {
+		return nil, nil, err
+	}
+	_, statErr := os.Stat(dir)
+	cached := statErr == nil
+
+	if !cached || r.Update {
+		fmt.Fprintf(out, "Fetching templates from %s\n", r.Source)
+		err := r.clone(ctx, dir)
+		switch {
+		case err != nil && cached:
+			fmt.Fprintf(out, "Warning: fetching %s failed, using the cached copy in %s: %v\n", r.Source, dir, err)
+		case err != nil:
+			return nil, nil, fmt.Errorf("fetching templates from %s: %w", r.Source, err)
+		}
+	} else {
+		fmt.Fprintf(out, "Using the cached templates of %s in %s (pass -template-update to fetch them again)\n", r.Source, dir)
+	}
+
+	m, err := readTemplateManifest(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	templates := os.DirFS(filepath.Join(dir, filepath.FromSlash(m.Dir)))
+	if m.Dir == "." {
+		// The manifest describes the templates but is not one of them
+		templates = withoutFile{templates, TemplateManifestFile}
+	}
+	return templates, m, nil
+}
+
+// withoutFile is an FS without the file name at its root.
+type withoutFile struct {
+	fs.FS
+	name string
+}
+
+func (w withoutFile) Open(name string) (fs.File, error) {
+	if name == w.name {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return w.FS.Open(name)
+}
+
+func (w withoutFile) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(w.FS, name)
+	if name != "." {
+		return entries, err
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Name() != w.name {
+			kept = append(kept, e)
+		}
+	}
+	return kept, err
+}
+
+// clone clones Source into a new directory next to dir, checks its
+// manifest and then replaces dir with it, so that a failed fetch leaves
+// the cached copy intact.
+func (r *RemoteTemplates) clone(ctx context.Context, dir string) error {
+	runner := r.Runner
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), DefaultDirMode); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	repo, ref := splitSource(r.Source)
+	args := []string{"clone", "--quiet"}
+	if ref != "" && !commitRef.MatchString(ref) {
+		args = append(args, "--depth", "1", "--branch", ref)
+	} else if ref == "" {
+		args = append(args, "--depth", "1")
+	}
+	if err := runner.Run(ctx, "", "git", append(args, repo, tmp)...); err != nil {
+		return err
+	}
+	if commitRef.MatchString(ref) {
+		if err := runner.Run(ctx, tmp, "git", "checkout", "--quiet", ref); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(filepath.Join(tmp, ".git")); err != nil {
+		return err
+	}
+	if _, err := readTemplateManifest(tmp); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+// readTemplateManifest reads and checks the TemplateManifestFile of the
+// repository in dir.
+func readTemplateManifest(dir string) (*TemplateManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, TemplateManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s not found: the repository does not hold gomvc templates", TemplateManifestFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m TemplateManifest
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", TemplateManifestFile, err)
+	}
+	if m.Name == "" {
+		return nil, fmt.Errorf("invalid %s: no name", TemplateManifestFile)
+	}
+	m.Dir = filepath.ToSlash(filepath.Clean(filepath.FromSlash(m.Dir)))
+	if m.Dir == ".." || strings.HasPrefix(m.Dir, "../") || filepath.IsAbs(m.Dir) {
+		return nil, fmt.Errorf("invalid %s: dir %s is outside the repository", TemplateManifestFile, m.Dir)
+	}
+	info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(m.Dir)))
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("invalid %s: dir %s is not a directory of the repository", TemplateManifestFile, m.Dir)
+	}
+	return &m, nil
+}
@@ -0,0 +1,413 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// RemoveResource undoes what GenerateResource, GenerateModel,
+// GenerateController and GenerateDTO generated for the resource name: the
+// files they created that the manifest records are deleted, and the
+// statements registering the routes of the resource in the router are
+// removed with the controller variable they used, and the controller
+// import if nothing else uses it. Files changed since they were generated,
+// or whose generated content the manifest does not record, are kept with
+// a warning unless Force is set, and so are the files they need to build:
+// the model and DTO of a kept controller, and the routes of a kept
+// controller. The routes are found by the plural of name, or by Plural if
+// set.
+//
+// Every change is listed, with a diff of the router. DryRun only lists
+// them.
+func (p *Project) RemoveResource(ctx context.Context, name string) error {
+	if err := validateName("resource", name); err != nil {
+		return err
+	}
+	if err := p.validateNaming(); err != nil {
+		return err
+	}
+	if name := p.layout(); name != DefaultLayout {
+		return fmt.Errorf("gomvc remove resource only supports the %s layout, not %s", DefaultLayout, name)
+	}
+	fsys := p.fs()
+	m, err := readManifest(fsys, p.Root)
+	if err != nil {
+		return err
+	}
+
+	names := p.names(name)
+	fmt.Fprintf(p.out(), "Removing resource %s from %s\n", names.Model, p.Root)
+	model := "models/" + names.File + ".go"
+	ctrl := "controller/" + names.File + "_controller.go"
+	test := "controller/" + names.File + "_controller_test.go"
+	dto := dtoDir + "/" + names.File + ".go"
+	// needs maps the files of the resource to those they do not build
+	// without
+	needs := map[string][]string{
+		ctrl: {model, dto},
+		test: {ctrl},
+		dto:  {model},
+	}
+	reasons := make(map[string]string)
+	var present []string
+	for _, rel := range []string{model, ctrl, test, dto} {
+		current, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(rel)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		present = append(present, rel)
+		reason := m.changed(rel, string(current))
+		if !m.hasFile(rel) {
+			reason = "not generated by gomvc"
+		} else if p.Force {
+			reason = ""
+		}
+		if reason != "" {
+			reasons[rel] = reason
+		}
+	}
+	// Removing what a kept file needs would break the build, so it is kept
+	// too, following needs from each kept file in turn
+	var queue []string
+	for rel := range reasons {
+		queue = append(queue, rel)
+	}
+	sort.Strings(queue)
+	for len(queue) > 0 {
+		rel := queue[0]
+		queue = queue[1:]
+		for _, dep := range needs[rel] {
+			if _, kept := reasons[dep]; !kept && slices.Contains(present, dep) {
+				reasons[dep] = "needed by " + rel + ", which is kept"
+				queue = append(queue, dep)
+			}
+		}
+	}
+	var remove []string
+	var kept int
+	for _, rel := range present {
+		if reason, ok := reasons[rel]; ok {
+			p.keep(rel, reason)
+			kept++
+			continue
+		}
+		remove = append(remove, rel)
+	}
+
+	rf, err := parseRouter(fsys, p.Root)
+	if err != nil {
+		return err
+	}
+	fullPath := rf.v1Path() + "/" + names.Plural
+	var routes []byte
+	var n int
+	if _, ok := reasons[ctrl]; ok {
+		p.keep(routerPath, "the routes for "+fullPath+" use "+ctrl+", which is kept")
+	} else if routes, n, err = rf.removeRoutes("/"+names.Plural, p.Module+"/controller", p.Module+"/middleware", "net/http"); err != nil {
+		return err
+	}
+	if len(remove)+kept == 0 && n == 0 {
+		return fmt.Errorf("nothing to remove: %s has no files of resource %s, nor routes for %s", p.Root, names.Model, fullPath)
+	}
+	rel := serviceDir + "/" + names.File + "_service.go"
+	if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(rel))); err == nil {
+		p.keep(rel, "services are not removed with their resource, remove it by hand")
+	}
+
+	err = p.generate(ctx, func(g *generator) error {
+		for _, rel := range remove {
+			if !p.DryRun {
+				fmt.Fprintf(p.out(), "  remove    %s\n", filepath.FromSlash(rel))
+			}
+			if err := g.removeFile(rel); err != nil {
+				return err
+			}
+		}
+		if routes == nil {
+			return nil
+		}
+		fmt.Fprintf(p.out(), "  update    %s: %d %s for %s\n", filepath.FromSlash(routerPath), n, plural(n, "route", "routes"), fullPath)
+		fmt.Fprint(p.out(), unifiedDiff(routerPath, string(rf.src), string(routes)))
+		return g.updateFile(routerPath, string(routes))
+	})
+	if err != nil {
+		return err
+	}
+
+	verb := "Removed"
+	if p.DryRun {
+		verb = "Would remove"
+	}
+	fmt.Fprintf(p.out(), "%s resource %s: %d %s removed, %d kept, %d %s unregistered.\n", verb, names.Model, len(remove), plural(len(remove), "file", "files"), kept, n, plural(n, "route", "routes"))
+	return nil
+}
+
+// removableFeatures maps the features RemoveFeature can turn off, named
+// after the flags of gomvc new, to functions turning them off in the
+// options of a project and reporting whether they were on.
+var removableFeatures = map[string]func(o *manifestOptions) bool{
+	"auth":                   func(o *manifestOptions) bool { return turnOff(&o.Auth) },
+	"cache":                  func(o *manifestOptions) bool { return turnOff(&o.Cache) },
+	"ci":                     func(o *manifestOptions) bool { return turnOff(&o.CI) },
+	"docker":                 func(o *manifestOptions) bool { return turnOff(&o.Docker) },
+	"dev-tools":              func(o *manifestOptions) bool { return turnOff(&o.DevTools) },
+	"grpc":                   func(o *manifestOptions) bool { o.GRPCIgnoreGen = false; return turnOff(&o.GRPC) },
+	"mailer":                 func(o *manifestOptions) bool { return turnOff(&o.Mailer) },
+	"messaging":              func(o *manifestOptions) bool { return turnOff(&o.Messaging) },
+	"metrics":                func(o *manifestOptions) bool { return turnOff(&o.Metrics) },
+	"otel":                   func(o *manifestOptions) bool { return turnOff(&o.Tracing) },
+	"ratelimit":              func(o *manifestOptions) bool { o.RateLimitScope = ""; return turnOff(&o.RateLimit) },
+	"swagger":                func(o *manifestOptions) bool { return turnOff(&o.Swagger) },
+	"tls":                    func(o *manifestOptions) bool { return turnOff(&o.TLS) },
+	"validation":             func(o *manifestOptions) bool { return turnOff(&o.Validation) },
+	"version-pkg":            func(o *manifestOptions) bool { return turnOff(&o.VersionPkg) },
+	"with-integration-tests": func(o *manifestOptions) bool { return turnOff(&o.IntegrationTests) },
+	"with-tests":             func(o *manifestOptions) bool { return turnOff(&o.WithTests) },
+	"worker":                 func(o *manifestOptions) bool { return turnOff(&o.Worker) },
+	"ws":                     func(o *manifestOptions) bool { return turnOff(&o.WebSocket) },
+}
+
+// turnOff sets the option v to its zero value and reports whether it was
+// set.
+func turnOff[T comparable](v *T) bool {
+	var zero T
+	on := *v != zero
+	*v = zero
+	return on
+}
+
+// RemovableFeatures returns the names of the features RemoveFeature can
+// remove, sorted.
+func RemovableFeatures() []string {
+	names := make([]string, 0, len(removableFeatures))
+	for name := range removableFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RemoveFeature turns the feature name, one of RemovableFeatures, off in
+// the project at Root. The templates are rendered with the options
+// recorded in the manifest, with and without the feature, and the
+// difference is undone:
+//
+//   - files only the feature has are deleted, unless they were changed
+//     since they were generated;
+//   - files the feature changed, such as main.go and router/router.go,
+//     get the changes of the feature reverted by a merge, which keeps the
+//     changes of the user's, or are kept with a warning when both changed
+//     the same lines.
+//
+// The manifest records the feature as off, and go mod tidy drops the
+// modules it required unless SkipVerify is set. Every change is listed,
+// with a diff of the files updated. DryRun only lists them. Force deletes
+// changed files too. Templates must be those the project was created
+// with, if any; the options of the project are taken from the manifest.
+func (p *Project) RemoveFeature(ctx context.Context, name string) error {
+	off, ok := removableFeatures[name]
+	if !ok {
+		return &ValidationError{Err: fmt.Errorf("unknown feature %q (supported: %s)", name, strings.Join(RemovableFeatures(), ", "))}
+	}
+	m, err := readManifest(p.fs(), p.Root)
+	if err != nil {
+		return err
+	}
+	if m.Options == nil {
+		version := m.GomvcVersion
+		if version == "" {
+			version = "an older version"
+		}
+		return fmt.Errorf("%w: it was created by gomvc %s, before features could be removed", ErrNoUpgradeOptions, version)
+	}
+	options := *m.Options
+	if !off(&options) {
+		return &ValidationError{Err: fmt.Errorf("%s is not enabled in %s", name, p.Root)}
+	}
+
+	// Both renders use the version the project was generated by, so that
+	// they only differ by the feature
+	render := func(o *manifestOptions) ([]templateFile, error) {
+		q := o.project(p.Root, m)
+		q.Templates, q.GomvcVersion = p.Templates, m.GomvcVersion
+		if err := q.CheckOptions(); err != nil {
+			return nil, err
+		}
+		layers, _, data, err := q.plan(m.Module)
+		if err != nil {
+			return nil, err
+		}
+		data.SessionSecret = o.SessionSecret
+		return q.renderFiles(layers, data)
+	}
+	with, err := render(m.Options)
+	if err != nil {
+		return &ValidationError{Err: fmt.Errorf("the options recorded in the manifest: %w", err)}
+	}
+	without, err := render(&options)
+	if err != nil {
+		return &ValidationError{Err: fmt.Errorf("cannot remove %s: %w", name, err)}
+	}
+	withoutFiles := make(map[string]string, len(without))
+	for _, f := range without {
+		withoutFiles[f.path] = f.content
+	}
+
+	fmt.Fprintf(p.out(), "Removing %s from %s\n", name, p.Root)
+	fsys, runner := p.effects()
+	g := &generator{
+		ctx:      ctx,
+		root:     p.Root,
+		fs:       fsys,
+		runner:   runner,
+		manifest: *m,
+		dirMode:  p.dirMode(),
+		fileMode: p.fileMode(),
+		crlf:     m.Options.lineEndings() == "crlf",
+		bases:    make(map[string]string),
+		backup:   p.newBackup(),
+	}
+	defer g.backup.report(p.out())
+	g.manifest.Options = &options
+	g.manifest.Hashes = make(map[string]string, len(m.Hashes))
+	for rel, hash := range m.Hashes {
+		g.manifest.Hashes[rel] = hash
+	}
+	if !p.DryRun {
+		g.baseFS = p.fs()
+	}
+
+	var removed, updated, kept int
+	for _, f := range with {
+		current, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(f.path)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		current = []byte(toLF(string(current)))
+		content, stays := withoutFiles[f.path]
+		switch {
+		case !stays:
+			if string(current) != f.content && m.changed(f.path, string(current)) != "" && !p.Force {
+				p.keep(f.path, "changed since it was generated")
+				kept++
+				continue
+			}
+			if !p.DryRun {
+				fmt.Fprintf(p.out(), "  remove    %s\n", filepath.FromSlash(f.path))
+			}
+			if err := g.removeFile(f.path); err != nil {
+				return err
+			}
+			removed++
+		case content != f.content:
+			merged, ok := merge3(f.content, string(current), content)
+			if !ok {
+				p.keep(f.path, "changed on the lines "+name+" needs, remove them by hand")
+				kept++
+				continue
+			}
+			if merged == string(current) {
+				continue
+			}
+			fmt.Fprintf(p.out(), "  update    %s\n", filepath.FromSlash(f.path))
+			fmt.Fprint(p.out(), unifiedDiff(f.path, string(current), merged))
+			if err := g.updateFile(f.path, merged); err != nil {
+				return err
+			}
+			g.track(f.path, content)
+			updated++
+		}
+	}
+
+	if removed+updated > 0 && !p.SkipVerify && kept == 0 {
+		if err := g.verify(); err != nil {
+			return err
+		}
+	}
+	if !p.DryRun {
+		if err := g.saveManifest(nil); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+
+	verb := "Removed"
+	if p.DryRun {
+		verb = "Would remove"
+	}
+	fmt.Fprintf(p.out(), "%s %s from %s: %d removed, %d updated, %d kept.\n", verb, name, p.Root, removed, updated, kept)
+	return nil
+}
+
+// changed returns why content, the content of the file rel, cannot be
+// taken for what gomvc generated, or "" if it can.
+func (m *manifest) changed(rel, content string) string {
+	hash, ok := m.Generated[rel]
+	if !ok {
+		hash, ok = m.Hashes[rel]
+	}
+	switch {
+	case !ok:
+		return "its generated content is not recorded, so changes cannot be told (use -force to remove it)"
+	case contentHash(toLF(content)) != hash:
+		return "changed since it was generated"
+	}
+	return ""
+}
+
+// keep reports that the file rel is kept, and why.
+func (p *Project) keep(rel, reason string) {
+	fmt.Fprintf(p.out(), "  kept      %s: %s\n", filepath.FromSlash(rel), reason)
+	p.reportWarning("kept " + rel + ": " + reason)
+}
+
+// removeFile deletes the file rel, and the directories gomvc created for
+// it that are left empty, and drops them from the manifest.
+func (g *generator) removeFile(rel string) error {
+	if g.removed == nil {
+		g.removed = make(map[string]bool)
+	}
+	if err := g.backup.add(rel); err != nil {
+		return err
+	}
+	file := filepath.Join(g.root, filepath.FromSlash(rel))
+	if err := g.fs.Remove(file); err != nil {
+		return err
+	}
+	g.removed[file] = true
+	g.manifest.Files = slices.DeleteFunc(g.manifest.Files, func(f string) bool { return f == rel })
+	delete(g.manifest.Hashes, rel)
+	delete(g.manifest.Generated, rel)
+	g.changed = true
+
+	for dir := path.Dir(rel); dir != "." && g.manifest.hasDir(dir); dir = path.Dir(dir) {
+		abs := filepath.Join(g.root, filepath.FromSlash(dir))
+		entries, err := g.fs.ReadDir(abs)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !g.removed[filepath.Join(abs, entry.Name())] {
+				return nil
+			}
+		}
+		if err := g.fs.Remove(abs); err != nil {
+			return err
+		}
+		g.removed[abs] = true
+		g.manifest.Dirs = slices.DeleteFunc(g.manifest.Dirs, func(d string) bool { return d == dir })
+	}
+	return nil
+}
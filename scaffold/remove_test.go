@@ -0,0 +1,103 @@
+package scaffold
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRemoveResourceKeepsDependencies checks that the files a kept file of
+// a resource needs to build are kept with it.
+func TestRemoveResourceKeepsDependencies(t *testing.T) {
+	tests := []struct {
+		name       string
+		edit       string
+		wantKept   []string
+		wantRoutes bool
+	}{
+		{"nothing edited", "", nil, false},
+		{"controller edited", "controller/post_controller.go", []string{"controller/post_controller.go", "models/post.go"}, true},
+		{"model edited", "models/post.go", []string{"models/post.go"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := createdProject(t, nil)
+			ctx := context.Background()
+			if err := p.GenerateResource(ctx, "Post", []Field{{Name: "title", Type: "string"}}); err != nil {
+				t.Fatalf("GenerateResource: %v", err)
+			}
+			if tt.edit != "" {
+				name := filepath.Join(p.Root, filepath.FromSlash(tt.edit))
+				content, err := p.FS.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := p.FS.WriteFile(name, append(content, "\n// Edited.\n"...), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := p.RemoveResource(ctx, "Post"); err != nil {
+				t.Fatalf("RemoveResource: %v", err)
+			}
+			for _, rel := range []string{"models/post.go", "controller/post_controller.go"} {
+				_, err := p.FS.Stat(filepath.Join(p.Root, filepath.FromSlash(rel)))
+				want := false
+				for _, k := range tt.wantKept {
+					want = want || k == rel
+				}
+				if kept := err == nil; kept != want {
+					t.Errorf("%s kept = %v, want %v", rel, kept, want)
+				}
+			}
+			router, err := p.FS.ReadFile(filepath.Join(p.Root, filepath.FromSlash(routerPath)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if routes := strings.Contains(string(router), "/posts"); routes != tt.wantRoutes {
+				t.Errorf("routes for /posts kept = %v, want %v:\n%s", routes, tt.wantRoutes, router)
+			}
+		})
+	}
+}
+
+// TestRemoveResourceBuilds removes a resource whose controller was edited
+// and checks that the project still builds. stdlib projects need no
+// module to be downloaded.
+func TestRemoveResourceBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated project")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	root := filepath.Join(t.TempDir(), "app")
+	p := &Project{Root: root, Module: "example.com/app", Framework: "stdlib", SkipVerify: true, Out: io.Discard}
+	ctx := context.Background()
+	if err := p.Create(ctx); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := p.GenerateResource(ctx, "Post", []Field{{Name: "title", Type: "string"}}); err != nil {
+		t.Fatalf("GenerateResource: %v", err)
+	}
+	ctrl := filepath.Join(root, "controller", "post_controller.go")
+	content, err := p.fs().ReadFile(ctrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.fs().WriteFile(ctrl, append(content, "\n// Edited.\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.RemoveResource(ctx, "Post"); err != nil {
+		t.Fatalf("RemoveResource: %v", err)
+	}
+	cmd := exec.Command("go", "build", "./...")
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,272 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// renameMaxScan is the size above which files other than Go files are not
+// searched for the old module path.
+const renameMaxScan = 1 << 20
+
+// RenameModule changes the module path of the project at Root from Module
+// to module: the module directive of go.mod, and the import paths starting
+// with the old path in every Go file of the module. Imports are located
+// with go/parser and only their paths are replaced, so comments and
+// strings are left as they are; the files changed are gofmt-formatted.
+// The manifest, if any, records the new path, and the generated content
+// it keeps for upgrades is renamed too. Files still mentioning the old
+// path, such as a Makefile or a comment, are reported but not changed.
+//
+// A summary of the changes is printed, and the project is built unless
+// SkipVerify is set. DryRun prints the changes as diffs instead.
+func (p *Project) RenameModule(ctx context.Context, module string) error {
+	old := p.Module
+	if err := ValidateModulePath(module); err != nil {
+		return &ValidationError{Err: err}
+	}
+	if module == old {
+		return &ValidationError{Err: fmt.Errorf("the module path is %s already", old)}
+	}
+
+	fsys := p.fs()
+	goMod, err := fsys.ReadFile(filepath.Join(p.Root, "go.mod"))
+	if err != nil {
+		return err
+	}
+	newMod, n, err := renameInSource("go.mod", goMod, old, module)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("go.mod in %s does not declare module %s", p.Root, old)
+	}
+
+	changes := map[string]string{"go.mod": newMod}
+	imports := map[string]int{}
+	var mentions []string
+	if err := p.scanModule(fsys, ".", old, module, changes, imports, &mentions); err != nil {
+		return err
+	}
+	rels := make([]string, 0, len(changes))
+	for rel := range changes {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	m, err := readManifest(fsys, p.Root)
+	if err != nil && !errors.Is(err, ErrNoManifest) {
+		return err
+	}
+
+	fmt.Fprintf(p.out(), "Renaming module %s to %s\n", old, module)
+	effects, runner := p.effects()
+	g := &generator{
+		ctx:      ctx,
+		root:     p.Root,
+		fs:       effects,
+		runner:   runner,
+		dirMode:  p.dirMode(),
+		fileMode: p.fileMode(),
+		bases:    make(map[string]string),
+	}
+	if !p.DryRun {
+		g.baseFS = fsys
+	}
+	if m != nil {
+		g.crlf = m.Options.lineEndings() == "crlf"
+	}
+	for _, rel := range rels {
+		current, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		if rel == "go.mod" {
+			fmt.Fprintf(p.out(), "  update    %s: module directive\n", filepath.FromSlash(rel))
+		} else {
+			fmt.Fprintf(p.out(), "  update    %s: %d %s\n", filepath.FromSlash(rel), imports[rel], plural(imports[rel], "import", "imports"))
+		}
+		if p.DryRun {
+			fmt.Fprint(p.out(), unifiedDiff(rel, string(current), changes[rel]))
+		}
+		if err := g.updateFile(rel, changes[rel]); err != nil {
+			return err
+		}
+	}
+
+	if m != nil {
+		g.manifest = *m
+		g.manifest.Module = module
+		g.manifest.Hashes = make(map[string]string, len(m.Hashes))
+		for rel, hash := range m.Hashes {
+			g.manifest.Hashes[rel] = hash
+			base, ok := readBase(fsys, p.Root, rel, hash)
+			if !ok {
+				// Without the generated content, a file unchanged since it
+				// was generated is taken to be generated that way
+				if current, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(rel))); err == nil && contentHash(toLF(string(current))) == hash {
+					if content, ok := changes[rel]; ok {
+						g.manifest.Hashes[rel] = contentHash(toLF(content))
+					}
+				}
+				continue
+			}
+			renamed, n, err := renameInSource(rel, []byte(base), old, module)
+			if err != nil || n == 0 {
+				continue
+			}
+			g.bases[rel] = renamed
+			g.manifest.Hashes[rel] = contentHash(renamed)
+		}
+		if len(m.Generated) > 0 {
+			g.manifest.Generated = make(map[string]string, len(m.Generated))
+			for rel, hash := range m.Generated {
+				g.manifest.Generated[rel] = hash
+				current, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(rel)))
+				if content, ok := changes[rel]; ok && err == nil && contentHash(toLF(string(current))) == hash {
+					g.manifest.Generated[rel] = contentHash(toLF(content))
+				}
+			}
+		}
+		if err := g.saveManifest(nil); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+
+	for _, rel := range mentions {
+		fmt.Fprintf(p.out(), "  check     %s: mentions %s, update it by hand\n", filepath.FromSlash(rel), old)
+	}
+	if !p.DryRun && !p.SkipVerify {
+		if err := g.runGo(nil, "build", "./..."); err != nil {
+			return fmt.Errorf("%w: %w", ErrVerify, err)
+		}
+	}
+
+	verb := "Renamed"
+	if p.DryRun {
+		verb = "Would rename"
+	}
+	fmt.Fprintf(p.out(), "%s module %s to %s: %d %s changed, %d to check by hand.\n", verb, old, module, len(rels), plural(len(rels), "file", "files"), len(mentions))
+	return nil
+}
+
+// scanModule walks the directory rel of the module, recording the new
+// content of the Go files importing packages of old in changes and the
+// number of imports renamed in imports, and the files still mentioning old
+// in mentions. Nested modules, hidden directories, vendor and testdata are
+// skipped.
+func (p *Project) scanModule(fsys FS, rel, old, module string, changes map[string]string, imports map[string]int, mentions *[]string) error {
+	entries, err := fsys.ReadDir(filepath.Join(p.Root, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		child := path.Join(rel, name)
+		if entry.IsDir() {
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata" || name == "node_modules" {
+				continue
+			}
+			if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(child), "go.mod")); err == nil {
+				continue
+			}
+			if err := p.scanModule(fsys, child, old, module, changes, imports, mentions); err != nil {
+				return err
+			}
+			continue
+		}
+		if child == "go.mod" || child == "go.sum" {
+			continue
+		}
+		src, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(child)))
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(name, ".go") {
+			if len(src) <= renameMaxScan && !bytes.Contains(src, []byte{0}) && bytes.Contains(src, []byte(old)) {
+				*mentions = append(*mentions, child)
+			}
+			continue
+		}
+		renamed, n, err := renameInSource(child, src, old, module)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			changes[child], imports[child] = renamed, n
+		}
+		if strings.Contains(renamed, old) {
+			*mentions = append(*mentions, child)
+		}
+	}
+	return nil
+}
+
+// renameInSource returns the source of the file rel with the import paths
+// of packages of the module old changed to module, and the number of
+// imports changed, or of go.mod with its module directive changed. Other
+// files are returned unchanged.
+func renameInSource(rel string, src []byte, old, module string) (string, int, error) {
+	if rel == "go.mod" {
+		f, err := modfile.ParseLax(rel, src, nil)
+		if err != nil || f.Module == nil || f.Module.Mod.Path != old {
+			return string(src), 0, err
+		}
+		start, end := f.Module.Syntax.Start.Byte, f.Module.Syntax.End.Byte
+		return string(src[:start]) + "module " + modfile.AutoQuote(module) + string(src[end:]), 1, nil
+	}
+	if !strings.HasSuffix(rel, ".go") {
+		return string(src), 0, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return "", 0, err
+	}
+	var edits []struct{ start, end int }
+	var paths []string
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || p != old && !strings.HasPrefix(p, old+"/") {
+			continue
+		}
+		edits = append(edits, struct{ start, end int }{fset.Position(imp.Path.Pos()).Offset, fset.Position(imp.Path.End()).Offset})
+		paths = append(paths, strconv.Quote(module+strings.TrimPrefix(p, old)))
+	}
+	if len(edits) == 0 {
+		return string(src), 0, nil
+	}
+	var out bytes.Buffer
+	last := 0
+	for i, e := range edits {
+		out.Write(src[last:e.start])
+		out.WriteString(paths[i])
+		last = e.end
+	}
+	out.Write(src[last:])
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return "", 0, fmt.Errorf("format %s: %v", rel, err)
+	}
+	return string(formatted), len(edits), nil
+}
+
+// plural returns one if n is 1, and other otherwise.
+func plural(n int, one, other string) string {
+	if n == 1 {
+		return one
+	}
+	return other
+}
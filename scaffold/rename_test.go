@@ -0,0 +1,137 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestRenameModule checks that RenameModule rewrites go.mod and the
+// imports of the module in every Go file, and leaves the imports of other
+// modules, even one whose path starts with the same text, and the files
+// other than Go files alone.
+func TestRenameModule(t *testing.T) {
+	p := createdProject(t, nil)
+	ctx := context.Background()
+	const extra = `package controller
+
+import (
+	"net/http"
+
+	"example.com/app/model"
+	"example.com/appendix/util"
+	"github.com/gin-gonic/gin"
+)
+
+// modelPackage is "example.com/app/model" but is not an import.
+const modelPackage = "example.com/app/model"
+
+func Extra(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"util": util.Name, "user": model.User{}})
+}
+`
+	if err := p.FS.WriteFile(filepath.Join(p.Root, "controller", "extra.go"), []byte(extra), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	const notes = "Install with go install example.com/app/cmd/api@latest.\n"
+	if err := p.FS.WriteFile(filepath.Join(p.Root, "NOTES.md"), []byte(notes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := p.FS.(*memFS)
+	before := make(map[string][]byte, len(fsys.files))
+	for name, f := range fsys.files {
+		before[name] = f.data
+	}
+
+	var out strings.Builder
+	p.Out = &out
+	if err := p.RenameModule(ctx, "example.com/shop"); err != nil {
+		t.Fatalf("RenameModule: %v", err)
+	}
+
+	goMod, err := p.FS.ReadFile(filepath.Join(p.Root, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(goMod), "module example.com/shop\n") {
+		t.Errorf("go.mod =\n%s\nwant module example.com/shop", goMod)
+	}
+
+	renamed := 0
+	for name, old := range before {
+		rel, _ := filepath.Rel(p.Root, name)
+		rel = filepath.ToSlash(rel)
+		if rel == "go.mod" || strings.HasPrefix(rel, manifestDir+"/") {
+			continue
+		}
+		data, err := p.FS.ReadFile(name)
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+			continue
+		}
+		if !strings.HasSuffix(rel, ".go") {
+			if !bytes.Equal(data, old) {
+				t.Errorf("%s changed:\n%s", rel, data)
+			}
+			continue
+		}
+		// gofmt sorts the renamed imports again, so only the sets compare
+		var want []string
+		for _, imp := range importPaths(t, rel, old) {
+			if imp == "example.com/app" || strings.HasPrefix(imp, "example.com/app/") {
+				imp = "example.com/shop" + strings.TrimPrefix(imp, "example.com/app")
+				renamed++
+			}
+			want = append(want, imp)
+		}
+		got := importPaths(t, rel, data)
+		slices.Sort(want)
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s imports %q, want %q", rel, got, want)
+		}
+	}
+	if renamed == 0 {
+		t.Error("no import of the module renamed")
+	}
+
+	extraGo, _ := p.FS.ReadFile(filepath.Join(p.Root, "controller", "extra.go"))
+	if !strings.Contains(string(extraGo), `const modelPackage = "example.com/app/model"`) {
+		t.Errorf("controller/extra.go: string literal changed:\n%s", extraGo)
+	}
+	for _, rel := range []string{"NOTES.md", "controller/extra.go"} {
+		if want := "check     " + filepath.FromSlash(rel) + ": mentions example.com/app"; !strings.Contains(out.String(), want) {
+			t.Errorf("output does not report the mention of the old path in %s:\n%s", rel, out.String())
+		}
+	}
+
+	m, err := readManifest(p.FS, p.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Module != "example.com/shop" {
+		t.Errorf("manifest records module %s, want example.com/shop", m.Module)
+	}
+}
+
+// importPaths returns the import paths of the Go file src, in order.
+func importPaths(t *testing.T, rel string, src []byte) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), rel, src, parser.ImportsOnly)
+	if err != nil {
+		t.Fatalf("%s does not parse: %v", rel, err)
+	}
+	var paths []string
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		paths = append(paths, p)
+	}
+	return paths
+}
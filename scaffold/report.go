@@ -0,0 +1,66 @@
+package scaffold
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// Report describes what Create did, for tools that drive gomvc. Set
+// Project.Report to have Create fill one in.
+type Report struct {
+	// Module is the module path the packages are imported with.
+	Module string `json:"module"`
+	// Root is the absolute path of the project.
+	Root      string `json:"root"`
+	Framework string `json:"framework"`
+	// Files lists the files created, in the order they were created.
+	Files []ReportFile `json:"files"`
+	// Commands lists the command lines of the external commands run.
+	Commands []string `json:"commands"`
+	// Warnings lists what did not go as asked without failing Create, such
+	// as existing files that were skipped.
+	Warnings []string `json:"warnings"`
+}
+
+// ReportFile is a file created by Create.
+type ReportFile struct {
+	// Path is relative to Root, with slashes.
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// reportWarning adds msg to the report, if one is filled in.
+func (p *Project) reportWarning(msg string) {
+	if p.Report != nil {
+		p.Report.Warnings = append(p.Report.Warnings, msg)
+	}
+}
+
+// reportingRunner adds the command line of every command it runs to
+// report.
+type reportingRunner struct {
+	Runner
+	report *Report
+}
+
+func (r reportingRunner) Run(ctx context.Context, dir, name string, args ...string) error {
+	r.report.Commands = append(r.report.Commands, commandLine(name, args))
+	return r.Runner.Run(ctx, dir, name, args...)
+}
+
+// reportFiles adds the files g created, and has not removed again, to the
+// report.
+func (p *Project) reportFiles(g *generator) {
+	if p.Report == nil {
+		return
+	}
+	for _, rel := range g.manifest.Files {
+		size, written := g.sizes[rel]
+		if info, err := g.fs.Stat(filepath.Join(g.root, filepath.FromSlash(rel))); err == nil {
+			size = info.Size()
+		} else if !written || !p.DryRun {
+			continue
+		}
+		p.Report.Files = append(p.Report.Files, ReportFile{Path: rel, Size: size})
+	}
+}
@@ -0,0 +1,277 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// routeData is passed to the templates of AddRoute.
+type routeData struct {
+	// Name is the handler, a function of package controller.
+	Name string
+	// Method is the HTTP method, e.g. "GET", and MethodName the name of
+	// the router method registering it in chi and fiber, e.g. "Get".
+	Method, MethodName string
+	// Path is the path relative to the router, in the syntax of the
+	// framework, and FullPath the path the route is served at.
+	Path, FullPath string
+	// Router is the variable the route is registered on.
+	Router string
+	// RequestID and Group are as in routesData.
+	RequestID, Group bool
+}
+
+// AddRoute registers handler, a function of package controller named
+// "controller.Name" or "Name", for method and path in InitializeRoutes,
+// and writes a stub of it to controller/<name>.go if the package does not
+// declare it yet. Path parameters are written ":id" or "{id}" and a final
+// wildcard "*", "*name" or "{name...}"; they are converted to the syntax
+// of the framework.
+//
+// With group, the route is registered in the group served at that path:
+// AddV1Routes for the v1 group, or a group assigned in InitializeRoutes.
+// Without a group of that path, group is prepended to path. Routes already
+// registered for the same method and path, as found by Routes, are
+// rejected. In dry runs the router change is printed as a diff.
+func (p *Project) AddRoute(ctx context.Context, method, path, handler, group string) error {
+	if err := ValidateFramework(p.framework()); err != nil {
+		return err
+	}
+	method = strings.ToUpper(method)
+	if !slices.Contains(routeMethods, method) {
+		return fmt.Errorf("invalid method %q: must be one of %s", method, strings.Join(routeMethods, ", "))
+	}
+	if err := validateRoutePath(path); err != nil {
+		return err
+	}
+	if group != "" {
+		if err := validatePath("group", group); err != nil {
+			return err
+		}
+		group = strings.TrimSuffix(group, "/")
+	}
+	name, err := handlerName(handler)
+	if err != nil {
+		return err
+	}
+
+	fsys := p.fs()
+	rf, err := parseRouter(fsys, p.Root)
+	if err != nil {
+		return err
+	}
+	fn := rf.setup
+	router, err := rf.routerVar(fn)
+	if err != nil {
+		return err
+	}
+	data := routeData{Name: name, Method: method, MethodName: method[:1] + strings.ToLower(method[1:])}
+	full := joinRoutePath(group, path)
+	switch v, found := rf.groupVar(router, group); {
+	case group == "":
+	case group == rf.v1Path():
+		fn = rf.v1
+		if router, err = rf.routerVar(fn); err != nil {
+			return err
+		}
+		data.Group = true
+	case found:
+		router = v
+		data.Group = true
+	default:
+		path = full
+	}
+	data.Router, data.Path, data.FullPath = router, frameworkPath(p.framework(), path), frameworkPath(p.framework(), full)
+	_, data.RequestID = declaredIn(fsys, filepath.Join(p.Root, "middleware"), "RequestID")
+
+	registered, _, err := p.Routes()
+	if err != nil {
+		return err
+	}
+	for _, r := range registered {
+		if routeKey(r.Path) == routeKey(data.FullPath) && (r.Method == "ANY" || r.Method == method) {
+			return &ValidationError{Err: fmt.Errorf("%s %s is already registered in %s:%d, to %s", method, data.FullPath, r.File, r.Line, r.Handler)}
+		}
+	}
+
+	stmt, err := renderTemplate(templates, "templates/generate/route/routes/"+p.framework()+".go.tmpl", data)
+	if err != nil {
+		return err
+	}
+	imports := []string{p.Module + "/controller"}
+	if p.framework() == "stdlib" && !data.Group {
+		imports = append(imports, "net/http", p.Module+"/middleware")
+	}
+	routes, err := rf.appendTo(fn, stmt, imports...)
+	if err != nil {
+		return err
+	}
+
+	var stub string
+	rel := "controller/" + snakeCase(name) + ".go"
+	if _, ok := declaredIn(fsys, filepath.Join(p.Root, "controller"), name); !ok {
+		if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(rel))); err == nil {
+			return &ValidationError{Err: fmt.Errorf("%s already exists but does not declare %s: declare it there or in another file of package controller", rel, name)}
+		}
+		if stub, err = renderGoTemplate("templates/generate/route/handler/"+p.framework()+".go.tmpl", data); err != nil {
+			return err
+		}
+	}
+
+	return p.generate(ctx, func(g *generator) error {
+		if stub != "" {
+			if err := p.generateFile(g, rel, stub); err != nil {
+				return err
+			}
+		}
+		if p.DryRun {
+			fmt.Fprint(p.out(), unifiedDiff(routerPath, string(rf.src), string(routes)))
+		}
+		if err := g.updateFile(routerPath, string(routes)); err != nil {
+			return err
+		}
+		if !p.DryRun {
+			fmt.Fprintf(p.out(), "Registered %s %s in %s\n", method, data.FullPath, routerPath)
+		}
+		return nil
+	})
+}
+
+// handlerName returns the name of the function handler, "controller.Name"
+// or "Name", names in package controller.
+func handlerName(handler string) (string, error) {
+	name := handler
+	if pkg, rest, ok := strings.Cut(handler, "."); ok {
+		if pkg != "controller" {
+			return "", fmt.Errorf("invalid handler %q: must be a function of package controller", handler)
+		}
+		name = rest
+	}
+	if !token.IsIdentifier(name) || !unicode.IsUpper(rune(name[0])) {
+		return "", fmt.Errorf("invalid handler %q: must be an exported Go name such as controller.ListProducts", handler)
+	}
+	return name, nil
+}
+
+// groupVar returns the variable InitializeRoutes assigns the group at path
+// created on router to, e.g. admin for admin := r.Group("/admin").
+func (rf *routerFile) groupVar(router, path string) (string, bool) {
+	for _, s := range rf.setup.Body.List {
+		assign, ok := s.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			continue
+		}
+		id, ok := assign.Lhs[0].(*ast.Ident)
+		call, isCall := assign.Rhs[0].(*ast.CallExpr)
+		if !ok || !isCall || len(call.Args) == 0 {
+			continue
+		}
+		if recv, method := selectorCall(call); recv != router || method != "Group" {
+			continue
+		}
+		if value, ok := stringLit(call.Args[0]); ok && strings.TrimSuffix(value, "/") == path {
+			return id.Name, true
+		}
+	}
+	return "", false
+}
+
+// validateRoutePath checks a path of AddRoute: a path as accepted by
+// validatePath whose segments may also be parameters, ":name" or
+// "{name}", and whose last segment may be a wildcard, "*", "*name" or
+// "{name...}".
+func validateRoutePath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("invalid path %q: must start with /", path)
+	}
+	segs := strings.Split(strings.TrimSuffix(path[1:], "/"), "/")
+	for i, seg := range segs {
+		if name, ok := routeParam(seg); ok {
+			if !token.IsIdentifier(name) {
+				return fmt.Errorf("invalid path %q: parameter %q must be named like a Go identifier", path, seg)
+			}
+			continue
+		}
+		if name, ok := routeWildcard(seg); ok {
+			if name != "" && !token.IsIdentifier(name) {
+				return fmt.Errorf("invalid path %q: wildcard %q must be named like a Go identifier", path, seg)
+			}
+			if i != len(segs)-1 {
+				return fmt.Errorf("invalid path %q: the wildcard %q must be the last segment", path, seg)
+			}
+			continue
+		}
+		if seg == "" && len(segs) == 1 {
+			continue
+		}
+		if seg == "" || seg == "." || seg == ".." || strings.TrimFunc(seg, isPathRune) != "" {
+			return fmt.Errorf("invalid path %q: segments may only hold letters, digits, '-', '_', '.' and '~', or be parameters such as :id or {id}", path)
+		}
+	}
+	return nil
+}
+
+// routeParam returns the name of seg if it is a parameter, ":name" or
+// "{name}".
+func routeParam(seg string) (string, bool) {
+	if strings.HasPrefix(seg, ":") {
+		return seg[1:], true
+	}
+	if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") && !strings.HasSuffix(seg, "...}") {
+		return seg[1 : len(seg)-1], true
+	}
+	return "", false
+}
+
+// routeWildcard returns the name of seg if it is a wildcard, "*", "*name"
+// or "{name...}", empty for "*".
+func routeWildcard(seg string) (string, bool) {
+	if strings.HasPrefix(seg, "*") {
+		return seg[1:], true
+	}
+	if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}") {
+		return seg[1 : len(seg)-4], true
+	}
+	return "", false
+}
+
+// frameworkPath returns path, checked by validateRoutePath, in the syntax
+// of framework: parameters are ":name" in gin, echo and fiber and "{name}"
+// in chi and stdlib. Unnamed wildcards are named "path" where a name is
+// required, and the root path of stdlib matches only itself.
+func frameworkPath(framework, path string) string {
+	colon := framework == "gin" || framework == "echo" || framework == "fiber"
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if name, ok := routeParam(seg); ok {
+			if colon {
+				segs[i] = ":" + name
+			} else {
+				segs[i] = "{" + name + "}"
+			}
+		} else if name, ok := routeWildcard(seg); ok {
+			if name == "" {
+				name = "path"
+			}
+			switch framework {
+			case "gin":
+				segs[i] = "*" + name
+			case "stdlib":
+				segs[i] = "{" + name + "...}"
+			default:
+				segs[i] = "*"
+			}
+		}
+	}
+	path = strings.Join(segs, "/")
+	if framework == "stdlib" && path == "/" {
+		return "/{$}"
+	}
+	return path
+}
@@ -0,0 +1,87 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// funcSource returns the source of the function name declared in src.
+func funcSource(t *testing.T, src []byte, name string) string {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, routerPath, src, parser.SkipObjectResolution)
+	if err != nil {
+		t.Fatalf("router.go does not parse: %v", err)
+	}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == name {
+			return string(src[fset.Position(fn.Pos()).Offset:fset.Position(fn.End()).Offset])
+		}
+	}
+	t.Fatalf("router.go does not declare %s", name)
+	return ""
+}
+
+func TestAddRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler string
+		group   string
+		// fn is the function of router.go the route must be registered in.
+		fn string
+	}{
+		{"v1 group", "controller.ShowWidget", "/api/v1", "AddV1Routes"},
+		{"no group", "controller.ShowWidget", "", "InitializeRoutes"},
+		{"unknown group", "ShowWidget", "/admin", "InitializeRoutes"},
+	}
+	for _, fw := range Frameworks() {
+		for _, tt := range tests {
+			t.Run(fw+"/"+tt.name, func(t *testing.T) {
+				p := createdProject(t, func(p *Project) { p.Framework = fw })
+				ctx := context.Background()
+				name := filepath.Join(p.Root, filepath.FromSlash(routerPath))
+				before, err := p.FS.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.Contains(funcSource(t, before, tt.fn), "ShowWidget") {
+					t.Fatalf("ShowWidget registered in %s before AddRoute", tt.fn)
+				}
+
+				if err := p.AddRoute(ctx, "get", "/widgets/:id", tt.handler, tt.group); err != nil {
+					t.Fatalf("AddRoute: %v", err)
+				}
+				router, err := p.FS.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if formatted, err := format.Source(router); err != nil || !bytes.Equal(formatted, router) {
+					t.Errorf("router.go is not gofmt-formatted (%v):\n%s", err, router)
+				}
+				if body := funcSource(t, router, tt.fn); !strings.Contains(body, "ShowWidget") {
+					t.Errorf("ShowWidget not registered in %s:\n%s", tt.fn, body)
+				}
+				if _, err := p.FS.Stat(filepath.Join(p.Root, "controller", "show_widget.go")); err != nil {
+					t.Errorf("handler stub not written: %v", err)
+				}
+
+				err = p.AddRoute(ctx, "GET", "/widgets/{id}", tt.handler, tt.group)
+				var validation *ValidationError
+				if !errors.As(err, &validation) || !strings.Contains(err.Error(), "already registered") {
+					t.Errorf("AddRoute of the same route again = %v, want it rejected", err)
+				}
+				if again, _ := p.FS.ReadFile(name); !bytes.Equal(again, router) {
+					t.Errorf("router.go changed by the rejected route:\n%s", again)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// routerPath is the file holding InitializeRoutes, relative to the root.
+const routerPath = "router/router.go"
+
+// routerFile is a parsed router/router.go. Edits are located through the
+// syntax tree but applied to the source text, so user code and comments
+// are left exactly as they are; the result is gofmt-formatted.
+type routerFile struct {
+	src   []byte
+	fset  *token.FileSet
+	file  *ast.File
+	setup *ast.FuncDecl
+	// v1 is AddV1Routes, or nil in projects generated before it existed.
+	v1 *ast.FuncDecl
+}
+
+// parseRouter reads and parses the project's router file.
+func parseRouter(fsys FS, root string) (*routerFile, error) {
+	src, err := fsys.ReadFile(filepath.Join(root, filepath.FromSlash(routerPath)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s not found", routerPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRouterSource(src)
+}
+
+// parseRouterSource parses src as the project's router file.
+func parseRouterSource(src []byte) (*routerFile, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, routerPath, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	rf := &routerFile{src: src, fset: fset, file: file}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Body == nil {
+			continue
+		}
+		switch fn.Name.Name {
+		case "InitializeRoutes":
+			rf.setup = fn
+		case "AddV1Routes":
+			rf.v1 = fn
+		}
+	}
+	if rf.setup == nil {
+		return nil, fmt.Errorf("no InitializeRoutes function found in %s", routerPath)
+	}
+	return rf, nil
+}
+
+// resourceFunc returns the function resource routes are registered in:
+// AddV1Routes, or InitializeRoutes in projects without it.
+func (rf *routerFile) resourceFunc() *ast.FuncDecl {
+	if rf.v1 != nil {
+		return rf.v1
+	}
+	return rf.setup
+}
+
+// routerVar returns the name of fn's first parameter, the engine, echo
+// instance, app, group or mux routes are registered on.
+func (rf *routerFile) routerVar(fn *ast.FuncDecl) (string, error) {
+	params := fn.Type.Params.List
+	if len(params) == 0 || len(params[0].Names) == 0 {
+		return "", fmt.Errorf("%s in %s has no router parameter", fn.Name.Name, routerPath)
+	}
+	return params[0].Names[0].Name, nil
+}
+
+// v1Path returns the path InitializeRoutes serves the routes of
+// AddV1Routes below, e.g. "/api/v1", or "" if the router has no v1 group.
+func (rf *routerFile) v1Path() string {
+	if rf.v1 == nil {
+		return ""
+	}
+	var path string
+	ast.Inspect(rf.setup.Body, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return path == ""
+		}
+		value, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		if _, pattern, ok := strings.Cut(value, " "); ok {
+			value = pattern
+		}
+		if value = strings.TrimSuffix(value, "/"); strings.HasSuffix(value, "/v1") {
+			path = value
+		}
+		return path == ""
+	})
+	return path
+}
+
+// hasRoute reports whether fn registers path or a path below it, with or
+// without a method prefix as used by http.ServeMux.
+func (rf *routerFile) hasRoute(fn *ast.FuncDecl, path string) bool {
+	return registers(fn.Body, path)
+}
+
+// registers reports whether node mentions path or a path below it, with
+// or without a method prefix as used by http.ServeMux, in a string
+// literal.
+func registers(node ast.Node, path string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return !found
+		}
+		value, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		if _, pattern, ok := strings.Cut(value, " "); ok {
+			value = pattern
+		}
+		if value == path || strings.HasPrefix(value, path+"/") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// hasImport reports whether the router file imports path.
+func (rf *routerFile) hasImport(path string) bool {
+	return fileImports(rf.file, path)
+}
+
+// fileImports reports whether file imports path.
+func fileImports(file *ast.File, path string) bool {
+	for _, imp := range file.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err == nil && p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// missingImports returns the quoted paths file does not import yet.
+func missingImports(file *ast.File, paths []string) []string {
+	var missing []string
+	for _, path := range paths {
+		if !fileImports(file, path) {
+			missing = append(missing, strconv.Quote(path))
+		}
+	}
+	return missing
+}
+
+// references reports whether InitializeRoutes refers to pkg.name.
+func (rf *routerFile) references(pkg, name string) bool {
+	found := false
+	ast.Inspect(rf.setup.Body, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == name {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == pkg {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// textEdit inserts text at a byte offset of the source.
+type textEdit struct {
+	offset int
+	text   string
+}
+
+// appendTo returns the formatted source with stmts added at the end of fn
+// and imports added where missing.
+func (rf *routerFile) appendTo(fn *ast.FuncDecl, stmts string, imports ...string) ([]byte, error) {
+	end := rf.fset.Position(fn.Body.Rbrace).Offset
+	text := "\n" + strings.TrimRight(stmts, "\n") + "\n"
+	if len(fn.Body.List) == 0 {
+		// Keep the first statement of an empty function on the line after
+		// the opening brace
+		text = strings.TrimLeft(text, "\n")
+	}
+	edits := []textEdit{{end, text}}
+
+	if missing := missingImports(rf.file, imports); len(missing) > 0 {
+		edits = append(edits, importEdit(rf.fset, rf.file, missing))
+	}
+	return applyEdits(routerPath, rf.src, edits)
+}
+
+// insertMiddleware returns the formatted source with stmt added after the
+// last <router>.Use call at the top of InitializeRoutes, or as its first
+// statement if there is none, so that the middleware applies to every
+// route. Imports are added where missing.
+func (rf *routerFile) insertMiddleware(router, stmt string, imports ...string) ([]byte, error) {
+	pos := rf.setup.Body.Lbrace + 1
+	for _, s := range rf.setup.Body.List {
+		if isUseCall(s, router) {
+			pos = s.End()
+		}
+	}
+	edits := []textEdit{{rf.fset.Position(pos).Offset, "\n\t" + stmt}}
+
+	if missing := missingImports(rf.file, imports); len(missing) > 0 {
+		edits = append(edits, importEdit(rf.fset, rf.file, missing))
+	}
+	return applyEdits(routerPath, rf.src, edits)
+}
+
+// isUseCall reports whether s is a call of router.Use.
+func isUseCall(s ast.Stmt, router string) bool {
+	expr, ok := s.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := expr.X.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Use" {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == router
+}
+
+// importEdit returns the edit adding the quoted import paths to file.
+func importEdit(fset *token.FileSet, file *ast.File, paths []string) textEdit {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Lparen.IsValid() {
+			return textEdit{fset.Position(gen.Rparen).Offset, "\t" + strings.Join(paths, "\n\t") + "\n"}
+		}
+		return textEdit{fset.Position(gen.End()).Offset, "\nimport " + strings.Join(paths, "\nimport ")}
+	}
+	return textEdit{fset.Position(file.Name.End()).Offset, "\n\nimport (\n\t" + strings.Join(paths, "\n\t") + "\n)"}
+}
+
+// applyEdits inserts edits into src, the source of the Go file rel, and
+// formats the result.
+func applyEdits(rel string, src []byte, edits []textEdit) ([]byte, error) {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].offset > edits[j].offset })
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.offset], append([]byte(e.text), out[e.offset:]...)...)
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("update %s: %v", rel, err)
+	}
+	return formatted, nil
+}
+
+// removeRoutes returns the formatted source without the statements of
+// InitializeRoutes and AddV1Routes registering path or a path below it,
+// the variables only they used, such as their controller, and the imports
+// among imports left unused, with the number of statements registering
+// routes removed. Other statements are left as they are.
+func (rf *routerFile) removeRoutes(path string, imports ...string) ([]byte, int, error) {
+	var stmts []ast.Node
+	routes := 0
+	for _, fn := range []*ast.FuncDecl{rf.setup, rf.v1} {
+		if fn == nil {
+			continue
+		}
+		removed := make(map[ast.Stmt]bool)
+		vars := make(map[string]bool)
+		for _, s := range fn.Body.List {
+			if _, ok := s.(*ast.ExprStmt); ok && registers(s, path) {
+				removed[s] = true
+				routes++
+				ast.Inspect(s, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok {
+						vars[id.Name] = true
+					}
+					return true
+				})
+			}
+		}
+		// The variables declared for the routes, unless used elsewhere
+		for _, s := range fn.Body.List {
+			assign, ok := s.(*ast.AssignStmt)
+			if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != 1 {
+				continue
+			}
+			id, ok := assign.Lhs[0].(*ast.Ident)
+			if !ok || !vars[id.Name] {
+				continue
+			}
+			used := false
+			for _, other := range fn.Body.List {
+				if other != s && !removed[other] && usesIdent(other, id.Name) {
+					used = true
+				}
+			}
+			if !used {
+				removed[s] = true
+			}
+		}
+		for _, s := range fn.Body.List {
+			if removed[s] {
+				stmts = append(stmts, s)
+			}
+		}
+	}
+	if routes == 0 {
+		return nil, 0, nil
+	}
+	src, err := cutLines(routerPath, rf.src, rf.fset, stmts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Drop the imports the removed statements were the last to use
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, routerPath, src, parser.ParseComments)
+	if err != nil {
+		return nil, 0, err
+	}
+	var unused []ast.Node
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || !slices.Contains(imports, p) {
+			continue
+		}
+		name := importName(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if !usesPackage(file, name) {
+			unused = append(unused, imp)
+		}
+	}
+	if len(unused) > 0 {
+		if src, err = cutLines(routerPath, src, fset, unused); err != nil {
+			return nil, 0, err
+		}
+	}
+	return src, routes, nil
+}
+
+// usesIdent reports whether node refers to the identifier name, other
+// than as the selector of a field or method.
+func usesIdent(node ast.Node, name string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			ast.Inspect(n.X, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && id.Name == name {
+					found = true
+				}
+				return !found
+			})
+			return false
+		case *ast.Ident:
+			if n.Name == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// usesPackage reports whether file refers to a member of the package it
+// imports as name.
+func usesPackage(file *ast.File, name string) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// cutLines returns src, the source of the Go file rel, formatted without
+// the lines of nodes, which must not share lines with other code.
+func cutLines(rel string, src []byte, fset *token.FileSet, nodes []ast.Node) ([]byte, error) {
+	type span struct{ start, end int }
+	spans := make([]span, 0, len(nodes))
+	for _, n := range nodes {
+		start, end := fset.Position(n.Pos()).Offset, fset.Position(n.End()).Offset
+		for start > 0 && src[start-1] != '\n' {
+			start--
+		}
+		for end < len(src) && src[end] != '\n' {
+			end++
+		}
+		if end < len(src) {
+			end++
+		}
+		spans = append(spans, span{start, end})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+	out := append([]byte(nil), src...)
+	for _, s := range spans {
+		out = append(out[:s.start], out[s.end:]...)
+		// Leave no blank line at the top of a block, or two in a row
+		if s.start > 1 && s.start < len(out) && out[s.start] == '\n' && (out[s.start-2] == '\n' || out[s.start-2] == '{') {
+			out = append(out[:s.start], out[s.start+1:]...)
+		}
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("update %s: %v", rel, err)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,759 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Route is a route a project registers, as found by Routes.
+type Route struct {
+	// Method is the HTTP method, or "ANY" if the route matches every
+	// method.
+	Method string `json:"method"`
+	// Path is the full path, in the syntax of the framework.
+	Path string `json:"path"`
+	// Handler is the source of the handler, e.g. "controller.HomeController".
+	Handler string `json:"handler"`
+	// Middleware is the source of the middleware the route is served
+	// through, outermost first: that of its groups, then its own.
+	Middleware []string `json:"middleware"`
+	// File and Line locate the registration, File relative to the root.
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// RouteWarning reports code registering routes that Routes cannot
+// analyze, so that its routes are missing from the list.
+type RouteWarning struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (w RouteWarning) String() string {
+	return fmt.Sprintf("%s:%d: %s", w.File, w.Line, w.Message)
+}
+
+// routeMethods are the HTTP methods routes can be registered for.
+var routeMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// routerPackage is a package whose routers Routes follows.
+type routerPackage struct {
+	framework string
+	// types are the router types, and constructors the functions returning
+	// a new router.
+	types, constructors []string
+	// middleware is the middleware constructors add, by constructor.
+	middleware map[string][]string
+}
+
+// routerPackages maps import paths to the packages of the frameworks.
+var routerPackages = map[string]routerPackage{
+	"github.com/gin-gonic/gin": {
+		framework: "gin", types: []string{"Engine", "RouterGroup", "IRouter", "IRoutes"}, constructors: []string{"New", "Default"},
+		middleware: map[string][]string{"Default": {"gin.Logger()", "gin.Recovery()"}},
+	},
+	"github.com/labstack/echo/v4": {framework: "echo", types: []string{"Echo", "Group"}, constructors: []string{"New"}},
+	"github.com/go-chi/chi/v5":    {framework: "chi", types: []string{"Mux", "Router"}, constructors: []string{"NewRouter", "NewMux"}},
+	"github.com/gofiber/fiber/v2": {framework: "fiber", types: []string{"App", "Router"}, constructors: []string{"New"}},
+	"net/http":                    {framework: "stdlib", types: []string{"ServeMux"}, constructors: []string{"NewServeMux"}},
+}
+
+// Routes lists the routes the project at Root registers, by analyzing its
+// Go files without building them: routers created with the constructors of
+// gin, echo, chi, fiber and net/http or received as parameters are
+// followed through groups, mounted routers and the functions of the
+// project they are passed to, in the idioms gomvc generates. Routes are
+// listed in the order they are registered. Registrations that cannot be
+// analyzed, such as paths that are not string literals or routers passed
+// to other modules, are returned as warnings. Test files are ignored.
+func (p *Project) Routes() ([]Route, []RouteWarning, error) {
+	a := newRouteAnalyzer()
+	if err := a.parseDir(p.fs(), p.Root, "."); err != nil {
+		return nil, nil, err
+	}
+	return a.list(p.Module), a.warnings, nil
+}
+
+// renderedRoutes lists the routes the rendered files of a new project
+// register, as Routes does for the files on disk.
+func renderedRoutes(files []templateFile, module string) []Route {
+	a := newRouteAnalyzer()
+	for _, f := range files {
+		if strings.HasSuffix(f.path, ".go") && !strings.HasSuffix(f.path, "_test.go") {
+			a.parseFile(path.Dir(f.path), f.path, []byte(f.content))
+		}
+	}
+	return a.list(module)
+}
+
+func newRouteAnalyzer() *routeAnalyzer {
+	return &routeAnalyzer{
+		fset:    token.NewFileSet(),
+		funcs:   make(map[string]*routeFunc),
+		methods: make(map[string][]*routeFunc),
+		active:  make(map[*routeFunc]bool),
+	}
+}
+
+// list analyzes the parsed files, of the module module, and returns the
+// routes they register.
+func (a *routeAnalyzer) list(module string) []Route {
+	a.index(module)
+
+	// Start from the functions the project does not call itself, such as
+	// main, then analyze those no caller passed a router to, such as
+	// functions creating and returning one, with routers of their own
+	called := a.called()
+	for _, fn := range a.order {
+		if !called[fn] {
+			a.analyze(fn, nil)
+		}
+	}
+	for _, fn := range a.order {
+		if !fn.analyzed {
+			a.analyze(fn, nil)
+		}
+	}
+
+	routes := make([]Route, 0, len(a.routes))
+	for _, r := range a.routes {
+		pos := a.fset.Position(r.pos)
+		routes = append(routes, Route{
+			Method:     r.method,
+			Path:       r.group.fullPath(r.path),
+			Handler:    r.handler,
+			Middleware: append(append([]string{}, r.group.fullMiddleware()...), r.middleware...),
+			File:       pos.Filename,
+			Line:       pos.Line,
+		})
+	}
+	return routes
+}
+
+// routeFile is a parsed Go file of the project.
+type routeFile struct {
+	src  []byte
+	file *ast.File
+	// pkg is the import path of its package, and imports maps the names
+	// of its imports to their paths.
+	pkg     string
+	imports map[string]string
+}
+
+// routeFunc is a function or method of the project.
+type routeFunc struct {
+	decl     *ast.FuncDecl
+	file     *routeFile
+	analyzed bool
+}
+
+// routeGroup is a router, or a group of routes of one. Its path and
+// middleware apply to the routes registered on it, after those of its
+// parent.
+type routeGroup struct {
+	framework  string
+	parent     *routeGroup
+	prefix     string
+	middleware []string
+}
+
+// fullPath returns path registered in g.
+func (g *routeGroup) fullPath(path string) string {
+	for ; g != nil; g = g.parent {
+		path = joinRoutePath(g.prefix, path)
+	}
+	return path
+}
+
+// fullMiddleware returns the middleware of g and its parents, outermost
+// first.
+func (g *routeGroup) fullMiddleware() []string {
+	if g == nil {
+		return nil
+	}
+	return append(g.parent.fullMiddleware(), g.middleware...)
+}
+
+// foundRoute is a route found by routeAnalyzer, whose full path and
+// middleware are known once every group is.
+type foundRoute struct {
+	method, path, handler string
+	group                 *routeGroup
+	middleware            []string
+	pos                   token.Pos
+}
+
+// routeAnalyzer finds the routes of Routes.
+type routeAnalyzer struct {
+	fset  *token.FileSet
+	files []*routeFile
+	// funcs are the functions of the project by package and name, and
+	// methods its methods by name; order lists both as declared.
+	funcs   map[string]*routeFunc
+	methods map[string][]*routeFunc
+	order   []*routeFunc
+	// active holds the functions being analyzed, so that recursion ends.
+	active   map[*routeFunc]bool
+	routes   []foundRoute
+	warnings []RouteWarning
+}
+
+// parseDir parses the Go files below rel, skipping tests, hidden
+// directories, vendor and testdata.
+func (a *routeAnalyzer) parseDir(fsys FS, root, rel string) error {
+	entries, err := fsys.ReadDir(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		child := path.Join(rel, name)
+		if entry.IsDir() {
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata" || name == "node_modules" {
+				continue
+			}
+			if err := a.parseDir(fsys, root, child); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := fsys.ReadFile(filepath.Join(root, filepath.FromSlash(child)))
+		if err != nil {
+			return err
+		}
+		a.parseFile(rel, child, src)
+	}
+	return nil
+}
+
+// parseFile parses src, the file name of the package in the directory
+// rel, and records a warning if it does not parse.
+func (a *routeAnalyzer) parseFile(rel, name string, src []byte) {
+	file, err := parser.ParseFile(a.fset, name, src, parser.SkipObjectResolution)
+	if err != nil {
+		a.warnings = append(a.warnings, RouteWarning{File: name, Message: "not analyzed: " + err.Error()})
+		return
+	}
+	a.files = append(a.files, &routeFile{src: src, file: file, pkg: rel})
+}
+
+// index records the imports of the files and their functions, with
+// package paths below module.
+func (a *routeAnalyzer) index(module string) {
+	for _, f := range a.files {
+		if f.pkg == "." {
+			f.pkg = module
+		} else {
+			f.pkg = module + "/" + f.pkg
+		}
+		f.imports = make(map[string]string)
+		for _, imp := range f.file.Imports {
+			p, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			name := importName(p)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			f.imports[name] = p
+		}
+		for _, decl := range f.file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			rf := &routeFunc{decl: fn, file: f}
+			if fn.Recv != nil {
+				a.methods[fn.Name.Name] = append(a.methods[fn.Name.Name], rf)
+			} else {
+				a.funcs[f.pkg+"."+fn.Name.Name] = rf
+			}
+			a.order = append(a.order, rf)
+		}
+	}
+}
+
+// importName returns the name a package is imported as by default: the
+// last element of its path, skipping major version suffixes.
+func importName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elems[len(elems)-2]
+	}
+	return strings.TrimPrefix(name, "go-")
+}
+
+// called returns the functions of the project called by one of its own.
+func (a *routeAnalyzer) called() map[*routeFunc]bool {
+	called := make(map[*routeFunc]bool)
+	for _, fn := range a.order {
+		ast.Inspect(fn.decl.Body, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if callee := a.callee(fn.file, call); callee != nil && callee != fn {
+					called[callee] = true
+				}
+			}
+			return true
+		})
+	}
+	return called
+}
+
+// callee returns the function of the project call calls, if known.
+// Methods are found by name, if only one method has it.
+func (a *routeAnalyzer) callee(f *routeFile, call *ast.CallExpr) *routeFunc {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return a.funcs[f.pkg+"."+fun.Name]
+	case *ast.SelectorExpr:
+		if id, ok := fun.X.(*ast.Ident); ok {
+			if pkg, ok := f.imports[id.Name]; ok {
+				return a.funcs[pkg+"."+fun.Sel.Name]
+			}
+		}
+		if methods := a.methods[fun.Sel.Name]; len(methods) == 1 {
+			return methods[0]
+		}
+	}
+	return nil
+}
+
+// routerType returns the framework of the router type expr, if it is one.
+func routerType(f *routeFile, expr ast.Expr) (string, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	pkg, ok := routerPackages[f.imports[id.Name]]
+	if !ok || !slices.Contains(pkg.types, sel.Sel.Name) {
+		return "", false
+	}
+	return pkg.framework, true
+}
+
+// analyze follows the routers of fn: args, the routers passed to its
+// parameters by name, and new routers for its other router parameters.
+func (a *routeAnalyzer) analyze(fn *routeFunc, args map[string]*routeGroup) {
+	if a.active[fn] {
+		return
+	}
+	a.active[fn] = true
+	defer delete(a.active, fn)
+	fn.analyzed = true
+
+	vars := make(map[string]*routeGroup)
+	for _, field := range fn.decl.Type.Params.List {
+		framework, ok := routerType(fn.file, field.Type)
+		for _, name := range field.Names {
+			if g, passed := args[name.Name]; passed {
+				vars[name.Name] = g
+			} else if ok {
+				vars[name.Name] = &routeGroup{framework: framework}
+			}
+		}
+	}
+	a.walk(fn.file, fn.decl.Body, vars)
+}
+
+// walk follows the routers of vars through body.
+func (a *routeAnalyzer) walk(f *routeFile, body ast.Node, vars map[string]*routeGroup) {
+	skip := make(map[ast.Node]bool)
+	statement := make(map[*ast.CallExpr]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil || skip[n] {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.ExprStmt:
+			if call, ok := n.X.(*ast.CallExpr); ok {
+				statement[call] = true
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				break
+			}
+			for i, lhs := range n.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok {
+					if g := a.group(f, n.Rhs[i], vars); g != nil {
+						vars[id.Name] = g
+					}
+				}
+			}
+		case *ast.CallExpr:
+			a.call(f, n, vars, statement[n], skip)
+		}
+		return true
+	})
+}
+
+// group returns the router expr evaluates to, if it is one: a variable
+// holding one, a new router, a group of one or, for chi, a router with
+// middleware added by With.
+func (a *routeAnalyzer) group(f *routeFile, expr ast.Expr, vars map[string]*routeGroup) *routeGroup {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return vars[expr.Name]
+	case *ast.ParenExpr:
+		return a.group(f, expr.X, vars)
+	case *ast.CallExpr:
+		sel, ok := expr.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return nil
+		}
+		if id, ok := sel.X.(*ast.Ident); ok && vars[id.Name] == nil {
+			pkg, ok := routerPackages[f.imports[id.Name]]
+			if ok && slices.Contains(pkg.constructors, sel.Sel.Name) {
+				return &routeGroup{framework: pkg.framework, middleware: pkg.middleware[sel.Sel.Name]}
+			}
+			return nil
+		}
+		parent := a.group(f, sel.X, vars)
+		if parent == nil {
+			return nil
+		}
+		switch sel.Sel.Name {
+		case "Group":
+			if len(expr.Args) == 0 {
+				return nil
+			}
+			prefix, ok := stringLit(expr.Args[0])
+			if !ok {
+				return nil
+			}
+			return &routeGroup{framework: parent.framework, parent: parent, prefix: prefix, middleware: a.sources(f, expr.Args[1:])}
+		case "With":
+			return &routeGroup{framework: parent.framework, parent: parent, middleware: a.sources(f, expr.Args)}
+		}
+	}
+	return nil
+}
+
+// call follows call: a registration of a route, middleware or group on a
+// router, or a call passing routers to a function of the project. Calls
+// that are statements passing routers elsewhere are reported as warnings.
+func (a *routeAnalyzer) call(f *routeFile, call *ast.CallExpr, vars map[string]*routeGroup, statement bool, skip map[ast.Node]bool) {
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		if g := a.group(f, sel.X, vars); g != nil {
+			a.method(f, call, g, sel.Sel.Name, vars, skip)
+			return
+		}
+	}
+
+	passed := make(map[int]*routeGroup)
+	for i, arg := range call.Args {
+		if g := a.group(f, arg, vars); g != nil {
+			passed[i] = g
+		}
+	}
+	if len(passed) == 0 {
+		return
+	}
+	callee := a.callee(f, call)
+	if callee == nil {
+		if statement {
+			a.warn(call, fmt.Sprintf("routes registered by %s are not listed", a.source(f, call)))
+		}
+		return
+	}
+	args := make(map[string]*routeGroup)
+	i := 0
+	for _, field := range callee.decl.Type.Params.List {
+		for _, name := range field.Names {
+			if g, ok := passed[i]; ok {
+				args[name.Name] = g
+			}
+			i++
+		}
+		if len(field.Names) == 0 {
+			i++
+		}
+	}
+	a.analyze(callee, args)
+}
+
+// method follows a call of method on the router g.
+func (a *routeAnalyzer) method(f *routeFile, call *ast.CallExpr, g *routeGroup, method string, vars map[string]*routeGroup, skip map[ast.Node]bool) {
+	args := call.Args
+	switch method {
+	case "Use", "Pre":
+		for _, arg := range args {
+			if _, ok := stringLit(arg); !ok {
+				g.middleware = append(g.middleware, a.source(f, arg))
+			}
+		}
+		return
+	case "Route", "Group":
+		// chi's Route("/path", func(r chi.Router) {...}) and
+		// Group(func(r chi.Router) {...}), and fiber's Route
+		i := slices.IndexFunc(args, func(arg ast.Expr) bool {
+			_, ok := arg.(*ast.FuncLit)
+			return ok
+		})
+		if i < 0 {
+			return
+		}
+		lit := args[i].(*ast.FuncLit)
+		skip[lit] = true
+		child := &routeGroup{framework: g.framework, parent: g}
+		if i > 0 {
+			var ok bool
+			if child.prefix, ok = stringLit(args[0]); !ok {
+				a.warn(call, fmt.Sprintf("the path of %s is not a string literal; its routes are not listed", a.source(f, call.Fun)))
+				return
+			}
+		}
+		inner := make(map[string]*routeGroup, len(vars)+1)
+		for name, v := range vars {
+			inner[name] = v
+		}
+		if params := lit.Type.Params.List; len(params) == 1 && len(params[0].Names) == 1 {
+			inner[params[0].Names[0].Name] = child
+		}
+		a.walk(f, lit.Body, inner)
+		return
+	case "Mount":
+		// chi's and fiber's Mount("/path", router)
+		if len(args) != 2 {
+			return
+		}
+		prefix, ok := stringLit(args[0])
+		if !ok {
+			a.warn(call, fmt.Sprintf("the path of %s is not a string literal; its routes are not listed", a.source(f, call.Fun)))
+			return
+		}
+		if a.mount(f, args[1], g, prefix, vars) {
+			return
+		}
+		a.found(f, call, g, "ANY", joinRoutePath(prefix, "/*"), args[1], nil)
+		return
+	case "Static", "StaticFS", "StaticFile", "File":
+		if len(args) >= 2 {
+			if prefix, ok := stringLit(args[0]); ok {
+				if method != "StaticFile" && method != "File" {
+					prefix = joinRoutePath(prefix, "/*")
+				}
+				a.routes = append(a.routes, foundRoute{method: "GET", path: prefix, handler: a.source(f, call), group: g, pos: call.Pos()})
+			}
+		}
+		return
+	}
+
+	var httpMethod string
+	var handlers []ast.Expr
+	upper := strings.ToUpper(method)
+	switch {
+	case slices.Contains(routeMethods, upper) && (method == upper || method[1:] == strings.ToLower(method[1:])):
+		// r.GET("/path", h) or r.Get("/path", h)
+		httpMethod = upper
+	case method == "Any" || method == "All":
+		httpMethod = "ANY"
+	case method == "Handle" || method == "HandleFunc" || method == "Method" || method == "MethodFunc":
+		// gin's Handle("GET", "/path", h) and chi's Method("GET", "/path",
+		// h) name the method first
+		if len(args) == 3 {
+			if m, ok := stringLit(args[0]); ok && !strings.Contains(m, "/") {
+				httpMethod, args = strings.ToUpper(m), args[1:]
+			}
+		}
+	default:
+		return
+	}
+	if len(args) < 2 {
+		return
+	}
+	path, ok := stringLit(args[0])
+	if !ok {
+		a.warn(call, fmt.Sprintf("the path of %s is not a string literal; the route is not listed", a.source(f, call.Fun)))
+		return
+	}
+	if httpMethod == "" {
+		// net/http patterns start with the method, if any
+		httpMethod = "ANY"
+		if m, rest, ok := strings.Cut(path, " "); ok {
+			httpMethod, path = m, strings.TrimSpace(rest)
+		}
+		if !strings.HasPrefix(path, "/") {
+			a.warn(call, fmt.Sprintf("the pattern %q has a host; the route is not listed", path))
+			return
+		}
+		if a.mount(f, args[1], g, "", vars) {
+			return
+		}
+	}
+
+	// Route middleware comes before the handler, except in echo
+	handlers = args[1:]
+	handler, middleware := handlers[len(handlers)-1], handlers[:len(handlers)-1]
+	if g.framework == "echo" {
+		handler, middleware = handlers[0], handlers[1:]
+	}
+	a.found(f, call, g, httpMethod, path, handler, a.sources(f, middleware))
+}
+
+// found records the route registered by call. With chi and net/http,
+// whose middleware wraps handlers, the middleware the handler is wrapped
+// in is unwrapped, e.g. middleware.RequestID(h).
+func (a *routeAnalyzer) found(f *routeFile, call *ast.CallExpr, g *routeGroup, method, path string, handler ast.Expr, middleware []string) {
+	for g.framework == "chi" || g.framework == "stdlib" {
+		wrap, ok := handler.(*ast.CallExpr)
+		if !ok || len(wrap.Args) != 1 {
+			break
+		}
+		if _, ok := wrap.Args[0].(*ast.BasicLit); ok {
+			break
+		}
+		if !a.isHTTP(f, wrap.Fun, "HandlerFunc") {
+			middleware = append(middleware, a.source(f, wrap.Fun))
+		}
+		handler = wrap.Args[0]
+	}
+	source := a.source(f, handler)
+	if lit, ok := handler.(*ast.FuncLit); ok {
+		source = a.source(f, lit.Type) + " {...}"
+	}
+	a.routes = append(a.routes, foundRoute{
+		method:     method,
+		path:       path,
+		handler:    source,
+		group:      g,
+		middleware: middleware,
+		pos:        call.Pos(),
+	})
+}
+
+// mount reports whether handler is a router of vars, possibly wrapped in
+// middleware and http.StripPrefix, and if so makes it a group of parent
+// at prefix.
+func (a *routeAnalyzer) mount(f *routeFile, handler ast.Expr, parent *routeGroup, prefix string, vars map[string]*routeGroup) bool {
+	var middleware []string
+	for {
+		if id, ok := handler.(*ast.Ident); ok {
+			g := vars[id.Name]
+			if g == nil || g == parent || g.parent != nil {
+				return false
+			}
+			g.parent, g.prefix = parent, joinRoutePath(prefix, g.prefix)
+			g.middleware = append(middleware, g.middleware...)
+			return true
+		}
+		call, ok := handler.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return false
+		}
+		switch {
+		case a.isHTTP(f, call.Fun, "StripPrefix"):
+			if len(call.Args) == 2 {
+				if stripped, ok := stringLit(call.Args[0]); ok {
+					prefix = joinRoutePath(prefix, stripped)
+				}
+			}
+		case a.isHTTP(f, call.Fun, "HandlerFunc"):
+		default:
+			middleware = append(middleware, a.source(f, call.Fun))
+		}
+		handler = call.Args[len(call.Args)-1]
+	}
+}
+
+// isHTTP reports whether expr is http.<name> of net/http.
+func (a *routeAnalyzer) isHTTP(f *routeFile, expr ast.Expr, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && f.imports[id.Name] == "net/http"
+}
+
+// sources returns the source of exprs.
+func (a *routeAnalyzer) sources(f *routeFile, exprs []ast.Expr) []string {
+	var list []string
+	for _, expr := range exprs {
+		list = append(list, a.source(f, expr))
+	}
+	return list
+}
+
+// source returns the source text of node, a node of f, on one line.
+func (a *routeAnalyzer) source(f *routeFile, node ast.Node) string {
+	file := a.fset.File(node.Pos())
+	return strings.Join(strings.Fields(string(f.src[file.Offset(node.Pos()):file.Offset(node.End())])), " ")
+}
+
+// warn records a warning about node.
+func (a *routeAnalyzer) warn(node ast.Node, message string) {
+	pos := a.fset.Position(node.Pos())
+	a.warnings = append(a.warnings, RouteWarning{File: pos.Filename, Line: pos.Line, Message: message})
+}
+
+// stringLit returns the value of expr if it is a string literal.
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+// selectorCall returns the receiver and name of a call of the form
+// recv.name().
+func selectorCall(call *ast.CallExpr) (recv, name string) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", ""
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", ""
+	}
+	return id.Name, sel.Sel.Name
+}
+
+// joinRoutePath returns path registered in a group at prefix.
+func joinRoutePath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	return strings.TrimSuffix(prefix, "/") + path
+}
+
+// routeKey returns path with the syntax of the frameworks removed, so that
+// paths matching the same requests compare equal: parameters become {},
+// wildcards *, and trailing slashes and stdlib's {$} are dropped.
+func routeKey(path string) string {
+	path = strings.TrimSuffix(path, "{$}")
+	var segs []string
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		switch {
+		case seg == "":
+			continue
+		case strings.HasPrefix(seg, "*") || strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}"):
+			seg = "*"
+		case strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			seg = "{}"
+		}
+		segs = append(segs, seg)
+	}
+	return "/" + strings.Join(segs, "/")
+}
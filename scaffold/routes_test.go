@@ -0,0 +1,230 @@
+package scaffold
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		router string
+		want   []Route
+		// warning is part of the one warning expected, if any.
+		warning string
+	}{
+		{
+			name: "gin groups",
+			router: `package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"example.com/app/controller"
+	"example.com/app/middleware"
+)
+
+func InitializeRoutes() *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.GET("/", controller.HomeController)
+	api := r.Group("/api", middleware.Auth())
+	AddV1Routes(api.Group("/v1/"))
+	return r
+}
+
+func AddV1Routes(v1 *gin.RouterGroup) {
+	v1.GET("/users/:id", controller.ShowUser)
+	v1.DELETE("/users/:id", middleware.Admin(), controller.DeleteUser)
+}
+`,
+			want: []Route{
+				{Method: "GET", Path: "/", Handler: "controller.HomeController", Middleware: []string{"middleware.RequestID()"}, Line: 13},
+				{Method: "GET", Path: "/api/v1/users/:id", Handler: "controller.ShowUser", Middleware: []string{"middleware.RequestID()", "middleware.Auth()"}, Line: 20},
+				{Method: "DELETE", Path: "/api/v1/users/:id", Handler: "controller.DeleteUser", Middleware: []string{"middleware.RequestID()", "middleware.Auth()", "middleware.Admin()"}, Line: 21},
+			},
+		},
+		{
+			name: "chi subrouters",
+			router: `package router
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"example.com/app/controller"
+	"example.com/app/middleware"
+)
+
+func InitializeRoutes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.Admin)
+		r.Get("/", controller.AdminHome)
+	})
+	r.With(middleware.Auth).Post("/posts", controller.CreatePost)
+	r.Handle("/files/*", controller.Files())
+}
+`,
+			want: []Route{
+				{Method: "GET", Path: "/admin/", Handler: "controller.AdminHome", Middleware: []string{"middleware.Admin"}, Line: 13},
+				{Method: "POST", Path: "/posts", Handler: "controller.CreatePost", Middleware: []string{"middleware.Auth"}, Line: 15},
+				{Method: "ANY", Path: "/files/*", Handler: "controller.Files()", Middleware: []string{}, Line: 16},
+			},
+		},
+		{
+			name: "stdlib patterns",
+			router: `package router
+
+import (
+	"net/http"
+
+	"example.com/app/controller"
+	"example.com/app/middleware"
+)
+
+func InitializeRoutes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", controller.HomeController)
+	mux.Handle("POST /uploads", middleware.Auth(http.HandlerFunc(controller.Upload)))
+	return mux
+}
+`,
+			want: []Route{
+				{Method: "GET", Path: "/{$}", Handler: "controller.HomeController", Middleware: []string{}, Line: 12},
+				{Method: "POST", Path: "/uploads", Handler: "controller.Upload", Middleware: []string{"middleware.Auth"}, Line: 13},
+			},
+		},
+		{
+			name: "path not a literal",
+			router: `package router
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"example.com/app/controller"
+)
+
+const usersPath = "/users"
+
+func InitializeRoutes(e *echo.Echo) {
+	e.GET(usersPath, controller.ListUsers)
+	e.GET("/healthz", controller.Healthz)
+}
+`,
+			want: []Route{
+				{Method: "GET", Path: "/healthz", Handler: "controller.Healthz", Middleware: []string{}, Line: 13},
+			},
+			warning: "router/router.go:12:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := memProject()
+			if err := p.FS.MkdirAll(filepath.Join(p.Root, "router"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.FS.WriteFile(filepath.Join(p.Root, "router", "router.go"), []byte(tt.router), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			routes, warnings, err := p.Routes()
+			if err != nil {
+				t.Fatalf("Routes: %v", err)
+			}
+			for i := range tt.want {
+				tt.want[i].File = routerPath
+			}
+			if !reflect.DeepEqual(routes, tt.want) {
+				t.Errorf("Routes =\n%+v\nwant\n%+v", routes, tt.want)
+			}
+			switch {
+			case tt.warning == "" && len(warnings) != 0:
+				t.Errorf("warnings = %v, want none", warnings)
+			case tt.warning != "" && (len(warnings) != 1 || !strings.HasPrefix(warnings[0].String(), tt.warning)):
+				t.Errorf("warnings = %v, want one at %s", warnings, tt.warning)
+			}
+		})
+	}
+}
+
+// TestRoutesGenerated checks that the routes of a new project and those
+// added by the generators are listed, for every framework.
+func TestRoutesGenerated(t *testing.T) {
+	for _, fw := range Frameworks() {
+		t.Run(fw, func(t *testing.T) {
+			p := createdProject(t, func(p *Project) { p.Framework = fw })
+			ctx := context.Background()
+			if err := p.GenerateResource(ctx, "Post", []Field{{Name: "title", Type: "string"}}); err != nil {
+				t.Fatalf("GenerateResource: %v", err)
+			}
+			if err := p.AddRoute(ctx, "GET", "/reports/:id", "controller.ShowReport", ""); err != nil {
+				t.Fatalf("AddRoute: %v", err)
+			}
+			routes, _, err := p.Routes()
+			if err != nil {
+				t.Fatalf("Routes: %v", err)
+			}
+
+			home := frameworkPath(fw, "/")
+			want := []struct{ method, path, handler string }{
+				{"GET", home, "controller.HomeController"},
+				{"GET", "/healthz", "probes.Healthz"},
+				{"GET", "/api/v1" + home, "controller.HomeController"},
+				{"GET", "/api/v1/posts", "posts.Index"},
+				{"GET", frameworkPath(fw, "/api/v1/posts/:id"), "posts.Show"},
+				{"POST", "/api/v1/posts", "posts.Create"},
+				{"PUT", frameworkPath(fw, "/api/v1/posts/:id"), "posts.Update"},
+				{"DELETE", frameworkPath(fw, "/api/v1/posts/:id"), "posts.Delete"},
+				{"GET", frameworkPath(fw, "/reports/:id"), "controller.ShowReport"},
+			}
+		next:
+			for _, w := range want {
+				for _, r := range routes {
+					if r.Method == w.method && r.Path == w.path && r.Handler == w.handler {
+						if r.File != routerPath {
+							t.Errorf("%s %s found in %s, want %s", w.method, w.path, r.File, routerPath)
+						}
+						continue next
+					}
+				}
+				t.Errorf("%s %s %s not listed in\n%+v", w.method, w.path, w.handler, routes)
+			}
+		})
+	}
+}
+
+// TestGinLogsOnce checks that gin projects log requests with
+// middleware.RequestLogger only, rather than with gin.Logger as well.
+func TestGinLogsOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(p *Project)
+	}{
+		{"default", func(p *Project) {}},
+		{"clean", func(p *Project) { p.Layout = "clean" }},
+		{"hexagonal", func(p *Project) { p.Layout = "hexagonal" }},
+		{"di", func(p *Project) { p.DI = "app" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := createdProject(t, func(p *Project) {
+				p.Framework = "gin"
+				tt.set(p)
+			})
+			routes, _, err := p.Routes()
+			if err != nil {
+				t.Fatalf("Routes: %v", err)
+			}
+			if len(routes) == 0 {
+				t.Fatal("no routes listed")
+			}
+			for _, r := range routes {
+				if !slices.Contains(r.Middleware, "gin.Recovery()") || slices.Contains(r.Middleware, "gin.Logger()") {
+					t.Errorf("%s %s is served through %v, want gin.Recovery() without gin.Logger()", r.Method, r.Path, r.Middleware)
+				}
+			}
+		})
+	}
+}
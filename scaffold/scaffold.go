@@ -0,0 +1,1199 @@
+// Package scaffold generates and removes gomvc project structures. It is
+// the library behind the gomvc command.
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultFramework is used when Project.Framework is empty.
+const DefaultFramework = "gin"
+
+// DefaultAPIPrefix is used when Project.APIPrefix is empty.
+const DefaultAPIPrefix = "/api"
+
+// DefaultPort is used when Project.Port is empty.
+const DefaultPort = "8080"
+
+// DefaultGomvcVersion is used when Project.GomvcVersion is empty, as in
+// development builds.
+const DefaultGomvcVersion = "dev"
+
+// DefaultDirMode and DefaultFileMode are used when Project.DirMode and
+// Project.FileMode are zero.
+const (
+	DefaultDirMode  fs.FileMode = 0o755
+	DefaultFileMode fs.FileMode = 0o644
+)
+
+// grpcPort and pprofPort are the ports of the gRPC server and of the pprof
+// listener of stdlib projects, which the HTTP server cannot share.
+const (
+	grpcPort  = "50051"
+	pprofPort = "6060"
+)
+
+// StandardDirs are the top-level directories of a gomvc project with the
+// default layout.
+var StandardDirs = []string{"cmd", "controller", "models", "pkg", "config", "views", "router", "middleware"}
+
+// Project describes a gomvc project on disk.
+type Project struct {
+	// Root is the directory the project lives in.
+	Root string
+	// Module is the Go module path of the project.
+	Module string
+	// GomvcVersion is the version of gomvc generating the project, which
+	// is recorded in its manifest and README. It defaults to
+	// DefaultGomvcVersion.
+	GomvcVersion string
+	// Framework is the web framework to generate for, see Frameworks.
+	Framework string
+	// Layout arranges the project's packages, see Layouts. It defaults to
+	// DefaultLayout.
+	Layout string
+	// Mode is what the project serves, see Modes: "api" for a JSON API,
+	// "web" for HTML pages rendered from views/ and static files from
+	// static/ as well, or "htmx" for web pages updated with htmx. It
+	// defaults to DefaultMode.
+	Mode string
+	// API is the style of the API, see APIStyles: "rest" for the REST
+	// routes only, or "graphql" for a GraphQL API generated by gqlgen at
+	// /query as well. It defaults to DefaultAPI.
+	API string
+	// CSS picks how the pages of the web modes are styled, see CSSSetups.
+	// It defaults to DefaultCSS.
+	CSS string
+	// Templates, if set, overrides the built-in templates. A file is looked
+	// up by its relative path (with or without a ".tmpl" suffix) in
+	// Templates first; files that have no built-in counterpart are rendered
+	// and written as well.
+	Templates fs.FS
+	// Database, if set, adds a data layer for that database, see
+	// Databases. ORM picks the library it is accessed with and defaults to
+	// the usual one for Database.
+	Database string
+	ORM      string
+	// Auth, if set, adds an authentication slice with register and login
+	// handlers, see AuthSchemes.
+	Auth string
+	// Config picks how the generated project reads its settings, see
+	// Configs. It defaults to "env".
+	Config string
+	// APIPrefix is the path the versioned API is served below, e.g. "/api"
+	// for /api/v1. It defaults to DefaultAPIPrefix; "/" serves /v1.
+	APIPrefix string
+	// Port is the port the generated server listens on unless its config
+	// says otherwise. The Dockerfile, docker-compose.yml and README use it
+	// too. It defaults to DefaultPort.
+	Port string
+	// Swagger annotates the controllers for swag, serves the Swagger UI at
+	// /swagger/ and adds a "make docs" target that regenerates docs/.
+	Swagger bool
+	// Metrics adds pkg/metrics with Prometheus request metrics, recorded by
+	// a middleware and served at /metrics.
+	Metrics bool
+	// Tracing adds pkg/tracing, which exports OpenTelemetry traces over
+	// OTLP, and the framework's middleware starting a span per request.
+	Tracing bool
+	// WebSocket adds pkg/ws, whose hub broadcasts the messages of the
+	// clients to all of them, and a controller connecting clients at /ws.
+	WebSocket bool
+	// Worker adds internal/jobs, with a job queue in memory or Redis and a
+	// sample email job queued by a controller, and cmd/worker, which runs
+	// the jobs queued in Redis.
+	Worker bool
+	// Cache, if set, adds pkg/cache with a cache in that backend, see
+	// Caches, and one in memory the config can switch to, registers its
+	// readiness check and adds a controller caching a report.
+	Cache string
+	// Messaging, if set, adds pkg/events, which publishes events to that
+	// message broker, see MessagingBrokers, internal/consumers, subscribed
+	// from main.go, and a controller publishing an event for each order it
+	// creates.
+	Messaging string
+	// Mailer adds pkg/mailer, which sends emails over SMTP or logs them in
+	// development, and the emails of views/emails. With Auth, Register
+	// sends a welcome email.
+	Mailer bool
+	// Validation adds pkg/validate, which checks requests against the
+	// validate tags of go-playground/validator and reports the invalid
+	// fields with pkg/apierror, and a controller validating the
+	// dto.CreateUserRequest it binds.
+	Validation bool
+	// GRPC adds a sample gRPC service defined in proto/, with its generated
+	// code in gen/ and buf configs regenerating it, implemented in
+	// internal/grpcserver and served on a second port. GRPCIgnoreGen keeps
+	// gen/ out of git, so it is generated with "make proto" after each
+	// checkout instead of committed.
+	GRPC          bool
+	GRPCIgnoreGen bool
+	// RateLimit adds a middleware limiting the requests of each client IP
+	// with a token bucket, kept in memory or, with Cache, in Redis the
+	// config can switch to. RateLimitScope picks the routes it guards, see
+	// RateLimitScopes. It defaults to DefaultRateLimitScope.
+	RateLimit      bool
+	RateLimitScope string
+	// TLS adds pkg/tlsconfig, with which main.go serves HTTPS when the
+	// config names a certificate, or gets one from Let's Encrypt.
+	TLS bool
+	// VersionPkg adds pkg/version, holding the version of the application
+	// that "make build" stamps into it, and the version of gomvc that
+	// generated it.
+	VersionPkg bool
+	// DI, if set, moves the composition of the server out of main.go into
+	// internal/app, see DIModes: "app" constructs the dependencies in
+	// app.New by hand, and "wire" has Wire generate app.New from a provider
+	// set. main.go then only loads the config and runs the App.
+	DI string
+	// Middleware names middleware to generate into middleware/ and
+	// register for every route, as "gomvc generate middleware -register"
+	// does.
+	Middleware []string
+	// Docker adds a Dockerfile and a docker-compose.yml running the
+	// application with its database.
+	Docker bool
+	// CI, if set, adds a pipeline for the CI service of that name that
+	// checks the formatting, vets, lints and tests the project, see
+	// CIProviders.
+	CI string
+	// DevTools adds a .air.toml and a "make dev" target that rebuild and
+	// restart the server whenever its code changes. The minimal layout has
+	// neither and ignores it.
+	DevTools bool
+	// Git makes the new project a git repository with the generated files
+	// as its initial commit. It is skipped when git is not installed or Root
+	// is inside a repository already, and nothing is committed when Root
+	// held files before.
+	Git bool
+	// WithTests makes Create and GenerateController also write an
+	// httptest based test for each controller they generate.
+	WithTests bool
+	// IntegrationTests adds tests/, integration tests sending requests to
+	// the whole application, which internal/testutil starts with httptest
+	// on a database of its own, and a "make test-integration" target.
+	// With Postgres the database runs in a container of testcontainers-go.
+	IntegrationTests bool
+	// Mocks is the tool GenerateService generates mocks of the interfaces
+	// it writes into mocks/ with, see MockTools, or "none" for none. It
+	// defaults to DefaultMockTool.
+	Mocks string
+	// DTO makes GenerateResource write request and response types in dto/
+	// for the controller to bind and return instead of the model.
+	DTO bool
+	// Plural and Table override the plural of the name given to a
+	// generator, which the routes of resources are named after, and the
+	// table of its model, for when the inflection rules get them wrong.
+	// Table defaults to the plural, and gives the model a TableName method.
+	Plural string
+	Table  string
+	// OpenAPI, if set, is the path of an OpenAPI 3 document, in JSON or
+	// YAML, to generate the API from: a model for each component schema, a
+	// controller for each tag with a handler answering 501 for each
+	// operation, and their routes. The document is copied to api/, which
+	// Upgrade reads it from. What cannot be generated, such as callbacks,
+	// is reported as warnings.
+	OpenAPI string
+
+	// DryRun makes Create and Destroy print every step to Out instead of
+	// applying it. Create does not look for the go command then.
+	DryRun bool
+	// Verbose makes Create, Destroy and the generators print every
+	// directory they create, every file they write or remove, and the
+	// command line of every external command to Out, followed by the output
+	// of the command as it runs.
+	Verbose bool
+	// IntoExisting makes Create generate into the Go module Root is in
+	// instead of running go mod init. The packages are then imported with
+	// the module path of its go.mod, and Module may be left empty. Without
+	// it, Create refuses to run inside an existing module.
+	IntoExisting bool
+	// KeepOnFailure makes Create leave the files and directories it created
+	// in place when it fails, e.g. to debug a template, instead of removing
+	// them. They are recorded in the manifest for Destroy.
+	KeepOnFailure bool
+	// SkipVerify skips the go mod tidy and go build ./... with which Create
+	// checks that the new project builds. Tidying needs the network.
+	SkipVerify bool
+	// Offline makes Create leave out the steps that download modules, so
+	// that it runs without the network: adding the modules the project
+	// requires to go.mod, generating the GraphQL server and the injector,
+	// and the checks of SkipVerify, which it implies. The project builds
+	// once they have been run by hand.
+	Offline bool
+	// Force lets Destroy remove the standard directories and go.mod when
+	// the project has no manifest, and lets Create and the generators
+	// overwrite existing files.
+	Force bool
+	// BackupDir, if set, is the directory a tar.gz archive of the files
+	// about to be deleted or overwritten is written to first, by Destroy,
+	// by RemoveResource and RemoveFeature, and by Create and the
+	// generators when they overwrite files. Restore unpacks it. Nothing is
+	// backed up in dry runs.
+	BackupDir string
+	// Report, if set, is filled in by Create with what it did, even when it
+	// fails.
+	Report *Report
+	// ConfirmDestroy, if set, is called before Destroy removes anything.
+	// Returning an error aborts the removal. It is not called in dry runs.
+	ConfirmDestroy func(plan DestroyPlan) error
+	// ResolveConflict, if set, is called without Force when Create is about
+	// to write a file that exists with different content, and returns
+	// whether to overwrite it. Otherwise such files are skipped and listed
+	// at the end. It is not called in dry runs.
+	ResolveConflict func(c Conflict) (bool, error)
+
+	// LineEndings are those of the files Create and the generators write,
+	// see LineEndings; Create records them in the manifest for the
+	// generators. It defaults to DefaultLineEndings, those of the OS gomvc
+	// runs on. Shell scripts always get LF line endings.
+	LineEndings string
+
+	// DirMode and FileMode are the permissions of the directories and files
+	// Create and the generators make, before the umask is applied. Shell
+	// scripts also get execute permission where FileMode grants read
+	// permission.
+	DirMode  fs.FileMode
+	FileMode fs.FileMode
+
+	// FS performs all file access. It defaults to OSFS.
+	FS FS
+	// Runner executes external commands. It defaults to ExecRunner.
+	Runner Runner
+	// Out receives progress messages. It defaults to io.Discard.
+	Out io.Writer
+}
+
+// Conflict is a file Create is about to write that exists with different
+// content.
+type Conflict struct {
+	// Path is the path of the file relative to Root, with slashes.
+	Path     string
+	Existing []byte
+	New      []byte
+}
+
+// Diff returns a unified diff turning the existing content into the new
+// one.
+func (c Conflict) Diff() string {
+	return unifiedDiff(c.Path, string(c.Existing), string(c.New))
+}
+
+// DestroyPlan lists what Destroy is about to remove, relative to Root.
+type DestroyPlan struct {
+	Root  string
+	Dirs  []string
+	Files []string
+	// FromManifest is true when the plan comes from the project manifest.
+	// Directories are then only removed once empty; otherwise they are
+	// removed with everything in them.
+	FromManifest bool
+}
+
+// ValidationError reports an invalid option of a Project, or an invalid
+// argument of a generator, such as a name or field.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateFramework reports an error if name is not a supported framework.
+func ValidateFramework(name string) error {
+	if _, ok := frameworks[name]; !ok {
+		return fmt.Errorf("unknown framework %q (supported: %s)", name, strings.Join(Frameworks(), ", "))
+	}
+	return nil
+}
+
+// ValidateAPIPrefix reports an error unless prefix is an absolute URL path
+// made of plain segments, such as "/api" or "/services/billing".
+func ValidateAPIPrefix(prefix string) error {
+	return validatePath("API prefix", prefix)
+}
+
+// validatePath reports an error unless path is an absolute URL path made
+// of plain segments. kind names the path in the error.
+func validatePath(kind, path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("invalid %s %q: must start with /", kind, path)
+	}
+	rest := strings.TrimSuffix(path[1:], "/")
+	if rest == "" {
+		return nil
+	}
+	for _, seg := range strings.Split(rest, "/") {
+		if seg == "" || seg == "." || seg == ".." || strings.TrimFunc(seg, isPathRune) != "" {
+			return fmt.Errorf("invalid %s %q: segments may only hold letters, digits, '-', '_', '.' and '~'", kind, path)
+		}
+	}
+	return nil
+}
+
+// isPathRune reports whether r may appear in a path segment.
+func isPathRune(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || strings.ContainsRune("-_.~", r)
+}
+
+// ValidatePort reports an error unless port is a TCP port number.
+func ValidatePort(port string) error {
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q: must be a number from 1 to 65535", port)
+	}
+	return nil
+}
+
+// port returns the project's port, applying the default.
+func (p *Project) port() string {
+	if p.Port == "" {
+		return DefaultPort
+	}
+	return p.Port
+}
+
+// apiPrefix returns the project's API prefix without a trailing slash,
+// applying the default.
+func (p *Project) apiPrefix() string {
+	if p.APIPrefix == "" {
+		return DefaultAPIPrefix
+	}
+	return strings.TrimSuffix(p.APIPrefix, "/")
+}
+
+func (p *Project) framework() string {
+	if p.Framework == "" {
+		return DefaultFramework
+	}
+	return p.Framework
+}
+
+func (p *Project) out() io.Writer {
+	if p.Out == nil {
+		return io.Discard
+	}
+	return p.Out
+}
+
+// gomvcVersion returns the version of gomvc, applying the default.
+func (p *Project) gomvcVersion() string {
+	if p.GomvcVersion == "" {
+		return DefaultGomvcVersion
+	}
+	return p.GomvcVersion
+}
+
+// dirMode returns the permissions of new directories.
+func (p *Project) dirMode() fs.FileMode {
+	if p.DirMode == 0 {
+		return DefaultDirMode
+	}
+	return p.DirMode
+}
+
+// fileMode returns the permissions of new files.
+func (p *Project) fileMode() fs.FileMode {
+	if p.FileMode == 0 {
+		return DefaultFileMode
+	}
+	return p.FileMode
+}
+
+// fs returns the FS the project is read from.
+func (p *Project) fs() FS {
+	if p.FS == nil {
+		return OSFS{}
+	}
+	return p.FS
+}
+
+// effects returns the FS and Runner to apply changes with, wrapped to only
+// print them in dry runs.
+func (p *Project) effects() (FS, Runner) {
+	fsys := p.fs()
+	var runner Runner = ExecRunner{}
+	if p.Verbose {
+		runner = ExecRunner{Log: p.out()}
+	}
+	if p.Runner != nil {
+		runner = p.Runner
+	}
+
+	if p.DryRun {
+		fmt.Fprintln(p.out(), "Dry run: no changes will be made.")
+		d := &dryRun{FS: fsys, changeLog: changeLog{out: p.out(), root: p.Root}}
+		return d, d
+	}
+	if p.Verbose {
+		fsys = &verboseFS{FS: fsys, changeLog: changeLog{out: p.out(), root: p.Root}}
+	}
+	return fsys, runner
+}
+
+// Create generates the project below Root. Errors about the options of
+// the Project are returned as *ValidationError, before anything is
+// touched.
+func (p *Project) Create(ctx context.Context) (retErr error) {
+	validated := false
+	defer func() {
+		var validation *ValidationError
+		if retErr != nil && !validated && !errors.As(retErr, &validation) {
+			retErr = &ValidationError{Err: retErr}
+		}
+	}()
+	if err := p.CheckOptions(); err != nil {
+		return err
+	}
+	frameworkName := p.framework()
+	module, err := p.resolveModule()
+	if err != nil {
+		return err
+	}
+	layoutName := p.layout()
+	lay := layouts[layoutName]
+	if p.APIPrefix != "" {
+		if err := ValidateAPIPrefix(p.APIPrefix); err != nil {
+			return err
+		}
+	}
+	if err := ValidatePort(p.port()); err != nil {
+		return err
+	}
+	layers, requires, data, err := p.plan(module)
+	if err != nil {
+		return err
+	}
+
+	validated = true
+
+	// Without a Runner of the caller's, every step needs the go command;
+	// dry runs only print the commands, so they run none to find it
+	if p.Runner == nil && !p.DryRun {
+		goPath, goVersion, err := findGo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(p.out(), "Using %s (%s)\n", goVersion, goPath)
+	}
+
+	fsys, runner := p.effects()
+	if p.Report != nil {
+		p.Report.Module, p.Report.Framework = module, frameworkName
+		p.Report.Root, _ = filepath.Abs(p.Root)
+		runner = reportingRunner{Runner: runner, report: p.Report}
+	}
+	g := &generator{
+		ctx:       ctx,
+		root:      p.Root,
+		fs:        fsys,
+		runner:    runner,
+		manifest:  manifest{Module: module, Framework: frameworkName, Layout: layoutName, GomvcVersion: p.gomvcVersion()},
+		overwrite: p.Force,
+		dirMode:   p.dirMode(),
+		fileMode:  p.fileMode(),
+		crlf:      p.lineEndings() == "crlf",
+		bases:     make(map[string]string),
+		backup:    p.newBackup(),
+	}
+	defer g.backup.report(p.out())
+	g.manifest.Options = p.manifestOptions(data.SessionSecret)
+	if !p.DryRun {
+		g.resolve = p.ResolveConflict
+		g.baseFS = p.fs()
+	}
+	// Files generated by an earlier run stay in the manifest, but are not
+	// removed on failure
+	prev, _ := readManifest(fsys, p.Root)
+	// On failure remove whatever was created, keeping what Root held
+	// before. Otherwise, or if that fails too, record it so Destroy can
+	// clean it up.
+	var createdRoot []string
+	defer p.reportFiles(g)
+	defer func() {
+		created := len(g.manifest.Dirs) > 0 || len(g.manifest.Files) > 0
+		if retErr != nil && !p.KeepOnFailure && !p.DryRun && (created || len(createdRoot) > 0) {
+			var err error
+			if created {
+				err = p.removeManifestEntries(fsys, &g.manifest)
+			}
+			if err == nil && created && prev != nil {
+				err = writeManifest(fsys, p.Root, prev)
+			}
+			for _, dir := range createdRoot {
+				if err == nil {
+					err = p.removeIfEmpty(fsys, dir, make(map[string]bool))
+				}
+			}
+			if err == nil {
+				fmt.Fprintln(p.out(), "Removed the files and directories created before the failure.")
+				return
+			}
+			// Report the original error first, as it is the one to fix
+			retErr = fmt.Errorf("%w (removing the files created before the failure failed too: %v; run gomvc destroy to retry)", retErr, err)
+		}
+		if !created {
+			return
+		}
+		if err := g.saveManifest(prev); err != nil && retErr == nil {
+			retErr = fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}()
+
+	if createdRoot, err = p.prepareRoot(fsys); err != nil {
+		return err
+	}
+
+	// Only an empty directory is committed with Git, so that no file of
+	// the user's ends up in the initial commit
+	entries, _ := fsys.ReadDir(p.Root)
+	hadFiles := len(entries) > 0
+
+	// Initialize Go module
+	if p.IntoExisting {
+		fmt.Fprintf(p.out(), "Generating into the existing Go module, as %s\n", module)
+	} else {
+		if err := g.runGo([]string{"go.mod"}, "mod", "init", module); err != nil {
+			return fmt.Errorf("failed to initialize go module: %w", err)
+		}
+		if !p.DryRun {
+			fmt.Fprintf(p.out(), "Initialized Go module: %s\n", module)
+		}
+	}
+
+	for _, dir := range lay.dirs {
+		if err := g.createDir(dir); err != nil {
+			return err
+		}
+	}
+
+	// Shared files from the base layer, then the framework specific main.go,
+	// controller, router and middleware, then the data layer
+	files, err := p.renderFiles(layers, data)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := g.createFile(f.path, f.content); err != nil {
+			return err
+		}
+	}
+	if len(g.skipped) > 0 {
+		fmt.Fprintf(p.out(), "Kept %d existing files that differ from the generated ones (use -force to overwrite them, or -interactive to review each):\n", len(g.skipped))
+		for _, rel := range g.skipped {
+			fmt.Fprintf(p.out(), "  skipped %s (exists)\n", filepath.FromSlash(rel))
+			p.reportWarning(rel + " skipped (exists)")
+		}
+	}
+
+	if p.Offline {
+		p.skipDownloads(data)
+	} else if err := p.download(g, requires, data); err != nil {
+		return err
+	}
+	// Check that the project builds before committing it, so that broken
+	// templates are reported rather than handed over
+	if !p.SkipVerify && !p.Offline {
+		if err := g.verify(); err != nil {
+			return err
+		}
+		if !p.DryRun {
+			fmt.Fprintln(p.out(), "Verified that the project builds successfully")
+		}
+	}
+	if p.Git {
+		// Write the manifest now so it is part of the initial commit
+		if !p.DryRun {
+			if err := g.saveManifest(prev); err != nil {
+				return fmt.Errorf("failed to write manifest: %w", err)
+			}
+		}
+		if err := p.initGit(ctx, runner, hadFiles); err != nil {
+			return err
+		}
+	}
+
+	if !p.DryRun && (len(g.manifest.Dirs) > 0 || len(g.manifest.Files) > 0) {
+		name := baseName(p.Root, p.Root)
+		fmt.Fprintf(p.out(), "\nGenerated %d files:\n", len(g.manifest.Files))
+		writeTree(p.out(), name, g.manifest.Dirs, g.manifest.Files)
+	}
+	return nil
+}
+
+// download adds the modules in requires to go.mod, so the project builds
+// right away, and runs the generators that need them.
+func (p *Project) download(g *generator, requires []string, data TemplateData) error {
+	// Add the framework and database packages to go.mod so the project
+	// builds right away
+	for _, req := range requires {
+		if err := g.runGo([]string{"go.sum"}, "get", req); err != nil {
+			return fmt.Errorf("failed to add dependency %s: %w", req, err)
+		}
+	}
+	// gqlgen generates the executable schema and the input types the
+	// resolvers use, so the project compiles right away
+	if data.GraphQL {
+		if err := g.runGo(gqlgenOutput, "run", "github.com/99designs/gqlgen", "generate"); err != nil {
+			return fmt.Errorf("failed to generate the GraphQL server: %w", err)
+		}
+	}
+	// go get only records the packages it was asked for, so resolve every
+	// package the generated code imports from the versions pinned above
+	if len(requires) > 0 {
+		if err := g.runGo([]string{"go.sum"}, "get", "./..."); err != nil {
+			return fmt.Errorf("failed to resolve imports: %w", err)
+		}
+	}
+	// wire generates app.New from the injector in internal/app/wire.go, once
+	// the packages it type-checks resolve
+	if p.DI == "wire" {
+		if err := g.runGo(wireOutput, wireCommand...); err != nil {
+			return fmt.Errorf("failed to generate the injector: %w", err)
+		}
+	}
+	return nil
+}
+
+// skipDownloads reports the commands that make the project generated
+// Offline build, in place of download.
+func (p *Project) skipDownloads(data TemplateData) {
+	steps := []string{"go mod tidy"}
+	if data.GraphQL {
+		steps = append(steps, "make gqlgen")
+	}
+	if p.DI == "wire" {
+		steps = append(steps, "make wire")
+	}
+	todo := strings.Join(steps, ", then ")
+	fmt.Fprintf(p.out(), "Skipped downloading the dependencies offline: run %s once online\n", todo)
+	p.reportWarning("skipped downloading the dependencies offline: run " + todo + " once online")
+}
+
+// plan returns the template layers of the project with the given module
+// path, the modules they require and the data they are rendered with.
+func (p *Project) plan(module string) (layers, requires []string, data TemplateData, err error) {
+	frameworkName, layoutName, configName := p.framework(), p.layout(), p.config()
+	fw, lay := frameworks[frameworkName], layouts[layoutName]
+	layers = p.layoutLayers(frameworkName)
+	requires = fw.requires
+	data = newTemplateData(module, frameworkName, p.Root)
+	data.Layout, data.MainPackage = layoutName, lay.mainPackage
+	data.APIPrefix = p.apiPrefix()
+	data.Port = p.port()
+	data.GomvcVersion = p.gomvcVersion()
+	if p.Database != "" {
+		name := p.dataLayer()
+		dl := dataLayers[name]
+		layers = append(layers, "database/"+p.Database, "database/"+name, "seed/base", "seed/"+name)
+		requires = slices.Concat(fw.requires, dl.requires)
+		data.Database, data.DBImport, data.DBType = p.Database, dl.importPath, dl.handleType
+		data.DatabaseURL = strings.ReplaceAll(dl.defaultURL, "{project}", data.ProjectName)
+		if dl.urlEnv != "" {
+			data.DBEnv = dl.urlEnv
+		}
+		if dl.migrateDriver != "" {
+			layers = append(layers, "database/migrate")
+			data.Migrations, data.MigrateDriver, data.MigrateName = true, dl.migrateDriver, dl.migrateName
+		}
+	}
+	if p.Auth != "" {
+		layers = append(layers, p.authLayers()...)
+		requires = slices.Concat(requires, p.authRequires())
+		data.Auth = p.Auth
+		if p.Auth == "session" {
+			data.SessionSecret = newSecret()
+		}
+	}
+	if p.Swagger {
+		layers = append(layers, "swagger")
+		requires = slices.Concat(requires, p.swaggerRequires())
+		data.Swagger = true
+	}
+	if p.Metrics {
+		layers = append(layers, p.metricsLayers()...)
+		requires = append(requires, prometheusRequire)
+		data.Metrics = true
+	}
+	if p.Tracing {
+		layers = append(layers, p.tracingLayers()...)
+		requires = slices.Concat(requires, p.tracingRequires())
+		data.Tracing = true
+	}
+	if p.WebSocket {
+		layers = append(layers, p.websocketLayers()...)
+		requires = slices.Concat(requires, websocketRequires[frameworkName])
+		data.WebSocket = true
+	}
+	if p.Worker {
+		layers = append(layers, p.workerLayers()...)
+		requires = slices.Concat(requires, redisRequires)
+		data.Worker = true
+	}
+	if p.Cache != "" {
+		layers = append(layers, p.cacheLayers()...)
+		if !p.Worker {
+			requires = slices.Concat(requires, redisRequires)
+		}
+		data.Cache = p.Cache
+	}
+	if p.Messaging != "" {
+		layers = append(layers, p.messagingLayers()...)
+		requires = slices.Concat(requires, messagingRequires)
+		data.Messaging = p.Messaging
+	}
+	if p.Mailer {
+		layers = append(layers, p.mailerLayers()...)
+		data.Mailer = true
+	}
+	if p.Validation {
+		layers = append(layers, "validation/base", "validation/"+frameworkName)
+		requires = append(requires, validatorRequire)
+		data.Validation = true
+	}
+	if p.RateLimit {
+		layers = append(layers, p.rateLimitLayers()...)
+		requires = append(requires, timeRequire)
+		data.RateLimit = p.rateLimitScope()
+	}
+	if p.TLS {
+		layers = append(layers, "tls")
+		requires = append(requires, autocertRequire)
+		data.TLS = true
+	}
+	if err := p.validateMiddleware(); err != nil {
+		return nil, nil, data, err
+	}
+	if p.VersionPkg {
+		layers = append(layers, "version")
+		data.VersionPkg = true
+	}
+	if p.api() != DefaultAPI {
+		layers = append(layers, "graphql")
+		requires = append(requires, gqlgenRequire)
+		data.GraphQL = true
+	}
+	if p.GRPC {
+		layers = append(layers, "grpc/base")
+		requires = slices.Concat(requires, grpcRequires)
+		data.GRPC = true
+		data.ProtoPackage = protoPackage(data.ProjectName)
+		data.GRPCIgnoreGen = p.GRPCIgnoreGen
+	}
+	if p.mode() != DefaultMode {
+		layers = append(layers, p.modeLayers(frameworkName)...)
+		layers = append(layers, "css/"+p.css())
+		data.Web = true
+		data.HTMX = p.mode() == "htmx"
+		data.Tailwind = p.css() == "tailwind"
+	}
+	if p.Docker {
+		layers = append(layers, "docker")
+		data.Docker = true
+		if p.Database != "" {
+			data.ComposeDatabaseURL = p.composeDatabaseURL(data.DatabaseURL)
+		}
+	}
+	if p.DI != "" {
+		layers = append(layers, p.diLayers()...)
+		if p.DI == "wire" {
+			requires = append(requires, wireRequire)
+		}
+		data.DI = p.DI
+	}
+	if p.IntegrationTests {
+		layers = append(layers, "integration/base", "integration/"+frameworkName)
+		if p.Database != "" {
+			layers = append(layers, "integration/"+p.Database)
+		}
+		if p.Database == "postgres" {
+			requires = slices.Concat(requires, testcontainersRequires)
+		}
+		data.IntegrationTests = true
+	}
+	if p.OpenAPI != "" {
+		doc, err := p.readOpenAPI()
+		if err != nil {
+			return nil, nil, data, err
+		}
+		data.OpenAPI, data.openAPI = doc.rel, doc
+	}
+	if p.DevTools && !lay.standalone {
+		layers = append(layers, "devtools")
+		data.DevTools = true
+	}
+	if p.CI != "" {
+		layers = append(layers, "ci/"+p.CI)
+	}
+	data.Config = configName
+	if configName != defaultConfig {
+		layers = append(layers, "config/"+configName)
+		requires = slices.Concat(requires, configLoaders[configName].requires)
+	}
+
+	return layers, requires, data, nil
+}
+
+// renderFiles renders the files of the project from its template layers,
+// with the files of its middleware, gRPC service, OpenAPI document and
+// tests.
+func (p *Project) renderFiles(layers []string, data TemplateData) ([]templateFile, error) {
+	files, err := renderLayers(layers, p.Templates, data)
+	if err != nil {
+		return nil, err
+	}
+	if files, err = p.addMiddleware(files, data.Framework, data.Module); err != nil {
+		return nil, err
+	}
+	if data.GRPC {
+		service, err := grpcServiceFiles(data)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, service...)
+	}
+	if data.openAPI != nil {
+		var warnings []string
+		if files, warnings, err = p.addOpenAPI(files, data); err != nil {
+			return nil, err
+		}
+		for _, w := range warnings {
+			fmt.Fprintln(p.out(), "Warning: "+w)
+			p.reportWarning(w)
+		}
+	}
+	if p.WithTests {
+		// The test requests the home route of the versioned API
+		home := controllerData{Name: "Home", Path: data.APIPrefix + "/v1/", Handler: "HomeController"}
+		if p.Database != "" {
+			home.Handler = "NewHomeController(nil).Index"
+		}
+		test, err := renderGoTemplate("templates/generate/controller_test/"+data.Framework+".go.tmpl", home)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, templateFile{path: "controller/home_controller_test.go", content: test})
+	}
+	for i, f := range files {
+		files[i].content = formatGo(p.Root, f.path, f.content, data.Module)
+	}
+	return files, nil
+}
+
+// prepareRoot creates Root and its missing parents unless it exists, and
+// returns the directories it created, innermost first. It fails if Root is
+// not a directory, and warns if it is not empty.
+func (p *Project) prepareRoot(fsys FS) (created []string, err error) {
+	if abs, err := filepath.Abs(p.Root); err == nil {
+		fmt.Fprintf(p.out(), "Creating the project in %s\n", abs)
+	}
+	info, err := fsys.Stat(p.Root)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		for dir := filepath.Clean(p.Root); ; dir = filepath.Dir(dir) {
+			if _, err := fsys.Stat(dir); !errors.Is(err, os.ErrNotExist) || dir == filepath.Dir(dir) {
+				break
+			}
+			created = append(created, dir)
+		}
+		if err := fsys.MkdirAll(p.Root, p.dirMode()); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", p.Root, err)
+		}
+		return created, nil
+	case err != nil:
+		return nil, err
+	case !info.IsDir():
+		return nil, &ValidationError{Err: fmt.Errorf("%s exists and is not a directory", p.Root)}
+	}
+	if entries, err := fsys.ReadDir(p.Root); err == nil && len(entries) > 0 {
+		fmt.Fprintf(p.out(), "Warning: %s is not empty; the files in it are kept unless overwritten with -force\n", p.Root)
+		p.reportWarning(p.Root + " is not empty")
+	}
+	return nil, nil
+}
+
+// Destroy removes the files and directories recorded in the project's
+// manifest. Without a manifest it returns ErrNoManifest unless Force is
+// set, in which case the top-level directories and root files of Layout
+// and go.mod are removed. With BackupDir, they are backed up first.
+func (p *Project) Destroy(ctx context.Context) error {
+	m, err := readManifest(p.fs(), p.Root)
+	if err != nil && err != ErrNoManifest {
+		return err
+	}
+	if err == ErrNoManifest && !p.Force {
+		return fmt.Errorf("%w in %s", err, p.Root)
+	}
+
+	if p.ConfirmDestroy != nil && !p.DryRun {
+		plan := DestroyPlan{Root: p.Root, Dirs: p.topLevelDirs(), Files: append(slices.Clone(layouts[p.layout()].files), "go.mod")}
+		if m != nil {
+			plan = DestroyPlan{Root: p.Root, Dirs: m.Dirs, Files: m.Files, FromManifest: true}
+		}
+		if err := p.ConfirmDestroy(plan); err != nil {
+			return err
+		}
+	}
+
+	b := p.newBackup()
+	if m == nil {
+		err = b.add(append(append(p.topLevelDirs(), layouts[p.layout()].files...), "go.mod")...)
+	} else {
+		err = b.add(append(slices.Clone(m.Files), manifestFile, manifestBaseDir)...)
+	}
+	if err != nil {
+		return err
+	}
+	b.report(p.out())
+
+	fsys, _ := p.effects()
+	if m == nil {
+		return p.forceDestroy(fsys)
+	}
+	return p.removeManifestEntries(fsys, m)
+}
+
+// forceDestroy removes the top-level directories and root files of the
+// project's layout and go.mod without consulting a manifest.
+func (p *Project) forceDestroy(fsys FS) error {
+	for _, dir := range p.topLevelDirs() {
+		if err := fsys.RemoveAll(filepath.Join(p.Root, dir)); err != nil {
+			return err
+		}
+	}
+	for _, file := range layouts[p.layout()].files {
+		path := filepath.Join(p.Root, file)
+		if _, err := fsys.Stat(path); err != nil {
+			continue
+		}
+		if err := fsys.Remove(path); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", file, err)
+		}
+	}
+
+	// Remove go.mod if it exists
+	goModPath := filepath.Join(p.Root, "go.mod")
+	if _, err := fsys.Stat(goModPath); err == nil {
+		if err := fsys.Remove(goModPath); err != nil {
+			return fmt.Errorf("failed to delete go.mod: %w", err)
+		}
+		if !p.DryRun {
+			fmt.Fprintln(p.out(), "Deleted go.mod file.")
+		}
+	}
+
+	return nil
+}
+
+// generator writes a project below root and records what it creates.
+type generator struct {
+	ctx      context.Context
+	root     string
+	fs       FS
+	runner   Runner
+	manifest manifest
+	// overwrite replaces existing files instead of skipping them.
+	overwrite bool
+	// resolve, if set, decides whether to overwrite an existing file with
+	// different content instead.
+	resolve func(c Conflict) (bool, error)
+	// skipped lists the existing files that were not overwritten.
+	skipped []string
+	// sizes holds the size of every file written.
+	sizes map[string]int64
+	// changed is set once a file has been written.
+	changed bool
+	// dirMode and fileMode are the permissions of new directories and
+	// files.
+	dirMode  fs.FileMode
+	fileMode fs.FileMode
+	// crlf writes files with CRLF line endings. The hashes and bases in
+	// the manifest are those of the content with LF ones.
+	crlf bool
+	// bases, if set, receives the content of every file created, and
+	// its hash is recorded in the manifest, for Upgrade. saveManifest
+	// writes them to baseFS unless it is nil. Without bases, the hashes
+	// are recorded as Generated instead.
+	bases  map[string]string
+	baseFS FS
+	// removed holds the paths removeFile deleted, so that emptiness is
+	// judged correctly in dry runs.
+	removed map[string]bool
+	// backup, if set, receives the files before they are overwritten or
+	// removed.
+	backup *backup
+}
+
+// modeOf returns the permissions of the file rel: fileMode, plus execute
+// permission for shell scripts wherever they are readable.
+func (g *generator) modeOf(rel string) fs.FileMode {
+	if path.Ext(rel) == ".sh" {
+		return g.fileMode | g.fileMode&0o444>>2
+	}
+	return g.fileMode
+}
+
+func (g *generator) createDir(rel string) error {
+	var missing []string
+	for _, dir := range missingDirs(g.fs, g.root, rel) {
+		if !g.manifest.hasDir(dir) {
+			missing = append(missing, dir)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if err := g.fs.MkdirAll(filepath.Join(g.root, filepath.FromSlash(rel)), g.dirMode); err != nil {
+		return err
+	}
+	g.manifest.Dirs = append(g.manifest.Dirs, missing...)
+	return nil
+}
+
+// upToDate reports whether rel exists holding what createFile would write
+// for content.
+func (g *generator) upToDate(rel, content string) bool {
+	existing, err := g.fs.ReadFile(filepath.Join(g.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return false
+	}
+	return string(existing) == withLineEndings(rel, formatGo(g.root, rel, content, g.manifest.Module), g.crlf)
+}
+
+func (g *generator) createFile(rel, content string) error {
+	path := filepath.Join(g.root, filepath.FromSlash(rel))
+	content = formatGo(g.root, rel, content, g.manifest.Module)
+	data := withLineEndings(rel, content, g.crlf)
+	_, err := g.fs.Stat(path)
+	exists := !errors.Is(err, os.ErrNotExist)
+	if exists && !g.overwrite {
+		existing, err := g.fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// Files that are up to date are no conflict
+		if string(existing) == data {
+			return nil
+		}
+		overwrite := false
+		if g.resolve != nil {
+			if overwrite, err = g.resolve(Conflict{Path: rel, Existing: existing, New: []byte(data)}); err != nil {
+				return err
+			}
+		}
+		if !overwrite {
+			g.skipped = append(g.skipped, rel)
+			return nil
+		}
+	}
+	if !exists {
+		if err := g.createDir(filepath.ToSlash(filepath.Dir(rel))); err != nil {
+			return err
+		}
+	}
+	if exists {
+		if err := g.backup.add(rel); err != nil {
+			return err
+		}
+	}
+	if err := g.fs.WriteFile(path, []byte(data), g.modeOf(rel)); err != nil {
+		return err
+	}
+	if !g.manifest.hasFile(rel) {
+		g.manifest.Files = append(g.manifest.Files, rel)
+	}
+	if g.bases != nil {
+		g.bases[rel] = content
+		if g.manifest.Hashes == nil {
+			g.manifest.Hashes = make(map[string]string)
+		}
+		g.manifest.Hashes[rel] = contentHash(content)
+	} else {
+		if g.manifest.Generated == nil {
+			g.manifest.Generated = make(map[string]string)
+		}
+		g.manifest.Generated[rel] = contentHash(content)
+	}
+	if g.sizes == nil {
+		g.sizes = make(map[string]int64)
+	}
+	g.sizes[rel] = int64(len(data))
+	g.changed = true
+	return nil
+}
+
+// saveManifest writes the manifest extended with prev, the manifest of an
+// earlier run, and the generated content of the files in bases.
+func (g *generator) saveManifest(prev *manifest) error {
+	if err := writeManifest(g.fs, g.root, g.manifest.withPrevious(prev)); err != nil {
+		return err
+	}
+	if g.baseFS == nil {
+		return nil
+	}
+	return writeBases(g.baseFS, g.root, g.bases)
+}
+
+// updateFile replaces the content of an existing file. Unlike createFile
+// it does not claim the file in the manifest.
+func (g *generator) updateFile(rel, content string) error {
+	content = withLineEndings(rel, formatGo(g.root, rel, content, g.manifest.Module), g.crlf)
+	if err := g.fs.WriteFile(filepath.Join(g.root, filepath.FromSlash(rel)), []byte(content), g.modeOf(rel)); err != nil {
+		return err
+	}
+	g.changed = true
+	return nil
+}
+
+// ErrVerify is returned by Create when the generated project cannot be
+// tidied or does not build. The error carries the output of the go command.
+var ErrVerify = errors.New("verifying the generated project failed")
+
+// verify tidies go.mod and compiles every package of the project.
+func (g *generator) verify() error {
+	if err := g.runGo([]string{"go.sum"}, "mod", "tidy"); err != nil {
+		return fmt.Errorf("%w: %w", ErrVerify, err)
+	}
+	if err := g.runGo(nil, "build", "./..."); err != nil {
+		return fmt.Errorf("%w: %w", ErrVerify, err)
+	}
+	return nil
+}
+
+// runGo runs the go command with args in the project root. The files in
+// creates are produced by the command and recorded in the manifest unless
+// they existed beforehand.
+func (g *generator) runGo(creates []string, args ...string) error {
+	var missing []string
+	for _, rel := range creates {
+		if _, err := g.fs.Stat(filepath.Join(g.root, rel)); errors.Is(err, os.ErrNotExist) {
+			missing = append(missing, rel)
+		}
+	}
+
+	if err := g.runner.Run(g.ctx, g.root, "go", args...); err != nil {
+		// Claim what the command created before failing, to roll it back
+		for _, rel := range missing {
+			if _, err := g.fs.Stat(filepath.Join(g.root, rel)); err == nil {
+				g.manifest.Files = append(g.manifest.Files, rel)
+			}
+		}
+		return err
+	}
+	g.manifest.Files = append(g.manifest.Files, missing...)
+	return nil
+}
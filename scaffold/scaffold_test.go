@@ -0,0 +1,267 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDestroyKeepsUserFiles(t *testing.T) {
+	p := createdProject(t, nil)
+	m, err := readManifest(p.FS, p.Root)
+	if err != nil {
+		t.Fatalf("readManifest: %v", err)
+	}
+	mine := filepath.Join(p.Root, "controller", "mine.go")
+	if err := p.FS.WriteFile(mine, []byte("package controller\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Destroy(context.Background()); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+	for _, rel := range append(m.Files, manifestFile) {
+		if _, err := p.FS.Stat(filepath.Join(p.Root, filepath.FromSlash(rel))); err == nil {
+			t.Errorf("%s still exists", rel)
+		}
+	}
+	for _, rel := range m.Dirs {
+		if rel == "controller" {
+			continue
+		}
+		if _, err := p.FS.Stat(filepath.Join(p.Root, filepath.FromSlash(rel))); err == nil {
+			t.Errorf("directory %s still exists", rel)
+		}
+	}
+	if _, err := p.FS.ReadFile(mine); err != nil {
+		t.Errorf("user file removed: %v", err)
+	}
+	if info, err := p.FS.Stat(filepath.Dir(mine)); err != nil || !info.IsDir() {
+		t.Errorf("controller directory removed: %v", err)
+	}
+}
+
+func TestDestroyRejectsPathsOutsideProject(t *testing.T) {
+	for _, entry := range []string{"../victim", "controller/../../victim", "/victim"} {
+		t.Run(entry, func(t *testing.T) {
+			p := createdProject(t, nil)
+			victim := filepath.Join(filepath.Dir(p.Root), "victim")
+			if err := p.FS.MkdirAll(victim, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			m, err := readManifest(p.FS, p.Root)
+			if err != nil {
+				t.Fatalf("readManifest: %v", err)
+			}
+			m.Dirs = append(m.Dirs, entry)
+			data, err := json.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.FS.WriteFile(filepath.Join(p.Root, manifestFile), data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := p.Destroy(context.Background()); err == nil || !strings.Contains(err.Error(), "outside the project") {
+				t.Errorf("Destroy = %v, want an error for %s", err, entry)
+			}
+			if _, err := p.FS.Stat(victim); err != nil {
+				t.Errorf("directory outside the project removed: %v", err)
+			}
+			if _, err := p.FS.Stat(filepath.Join(p.Root, "go.mod")); err != nil {
+				t.Errorf("project files removed: %v", err)
+			}
+		})
+	}
+}
+
+// TestCreateDryRunWithoutGo checks that a dry run neither looks for the go
+// command nor runs it, so it works where Go is not installed.
+func TestCreateDryRunWithoutGo(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	p := memProject()
+	p.Runner, p.DryRun = nil, true
+	var out bytes.Buffer
+	p.Out = &out
+	if err := p.Create(context.Background()); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if strings.Contains(out.String(), "Using go") {
+		t.Errorf("dry run looked for the go command:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "run     go mod init example.com/app") {
+		t.Errorf("dry run does not print go mod init:\n%s", out.String())
+	}
+	if files := p.FS.(*memFS).files; len(files) != 0 {
+		t.Errorf("dry run wrote %d files", len(files))
+	}
+}
+
+// TestCreateOffline checks that Offline runs no go command that downloads
+// modules.
+func TestCreateOffline(t *testing.T) {
+	var out bytes.Buffer
+	p := createdProject(t, func(p *Project) {
+		p.Database, p.API, p.DI = "postgres", "graphql", "wire"
+		p.SkipVerify, p.Offline = false, true
+		p.Out = &out
+	})
+	runner := p.Runner.(*fakeRunner)
+	if want := []string{"go mod init example.com/app"}; !slices.Equal(runner.commands, want) {
+		t.Errorf("ran %q, want %q", runner.commands, want)
+	}
+	if want := "run go mod tidy, then make gqlgen, then make wire once online"; !strings.Contains(out.String(), want) {
+		t.Errorf("output lacks %q:\n%s", want, out.String())
+	}
+}
+
+func TestCreateRootIsFile(t *testing.T) {
+	p := memProject()
+	if err := p.FS.MkdirAll(filepath.Dir(p.Root), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.FS.WriteFile(p.Root, []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := p.Create(context.Background())
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("Create = %v, want a *ValidationError", err)
+	}
+	if want := p.Root + " exists and is not a directory"; err.Error() != want {
+		t.Errorf("Create = %q, want %q", err, want)
+	}
+	if data, err := p.FS.ReadFile(p.Root); err != nil || string(data) != "notes" {
+		t.Errorf("the file was changed: %q, %v", data, err)
+	}
+}
+
+func TestCreateRootNotEmpty(t *testing.T) {
+	p := memProject()
+	var out bytes.Buffer
+	p.Out = &out
+	notes := filepath.Join(p.Root, "NOTES.md")
+	readme := filepath.Join(p.Root, "README.md")
+	if err := p.FS.MkdirAll(p.Root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{notes, readme} {
+		if err := p.FS.WriteFile(path, []byte("mine"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Create(context.Background()); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if want := "Warning: " + p.Root + " is not empty"; !strings.Contains(out.String(), want) {
+		t.Errorf("output lacks %q:\n%s", want, out.String())
+	}
+	if !strings.Contains(out.String(), "skipped README.md (exists)") {
+		t.Errorf("README.md is not listed as skipped:\n%s", out.String())
+	}
+	for _, path := range []string{notes, readme} {
+		if data, err := p.FS.ReadFile(path); err != nil || string(data) != "mine" {
+			t.Errorf("%s was changed: %q, %v", path, data, err)
+		}
+	}
+	if _, err := p.FS.Stat(filepath.Join(p.Root, "go.mod")); err != nil {
+		t.Errorf("the project was not generated: %v", err)
+	}
+}
+
+// umask returns the umask of the process, probing it with a file in dir.
+func umask(t *testing.T, dir string) fs.FileMode {
+	t.Helper()
+	probe := filepath.Join(dir, "probe")
+	if err := os.WriteFile(probe, nil, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(probe)
+	info, err := os.Stat(probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0o777 &^ info.Mode().Perm()
+}
+
+func TestCreateModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no Unix permissions")
+	}
+	dir := t.TempDir()
+	mask := umask(t, dir)
+	p := &Project{
+		Root:       filepath.Join(dir, "app"),
+		Module:     "example.com/app",
+		SkipVerify: true,
+		DirMode:    0o750,
+		FileMode:   0o640,
+		Runner:     &fakeRunner{fs: OSFS{}},
+	}
+	if err := p.Create(context.Background()); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	files := 0
+	err := filepath.WalkDir(p.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(p.Root, path)
+		// The manifest keeps the default modes, and go mod init writes
+		// go.mod
+		if rel == ".gomvc" {
+			return filepath.SkipDir
+		}
+		if rel == "go.mod" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		want := p.FileMode &^ mask
+		if d.IsDir() {
+			want = p.DirMode &^ mask
+		} else {
+			files++
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s has mode %v, want %v", rel, got, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files == 0 {
+		t.Error("no files were created")
+	}
+}
+
+func TestModeOf(t *testing.T) {
+	tests := []struct {
+		fileMode fs.FileMode
+		rel      string
+		want     fs.FileMode
+	}{
+		{0o644, "main.go", 0o644},
+		{0o644, "scripts/setup.sh", 0o755},
+		{0o640, "scripts/setup.sh", 0o750},
+		{0o600, "scripts/setup.sh", 0o700},
+		{0o600, "Makefile", 0o600},
+	}
+	for _, tt := range tests {
+		g := &generator{fileMode: tt.fileMode}
+		if got := g.modeOf(tt.rel); got != tt.want {
+			t.Errorf("modeOf(%q) with %v = %v, want %v", tt.rel, tt.fileMode, got, tt.want)
+		}
+	}
+}
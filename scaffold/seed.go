@@ -0,0 +1,188 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// seedDir holds the seeders cmd/seed runs, generated with the database.
+const seedDir = "internal/seed"
+
+// seedRunner declares the Seeder interface, and seedList the seeders run.
+const (
+	seedRunner = seedDir + "/seed.go"
+	seedList   = seedDir + "/seeders.go"
+)
+
+// seederData is passed to the seeder template.
+type seederData struct {
+	// Name is the seeder's type name without the Seeder suffix, and Key the
+	// name cmd/seed selects it by.
+	Name, Key string
+	// DBImport and DBType are the package and type of the database handle
+	// passed to Seed, as declared by the Seeder interface.
+	DBImport, DBType string
+	// StdImport is set when DBImport is a package of the standard library.
+	StdImport bool
+}
+
+// GenerateSeeder writes internal/seed/<name>_seeder.go with a seeder
+// inserting nothing yet, and appends it to the seeders of
+// internal/seed/seeders.go, which cmd/seed runs. The seed package is
+// generated with the database, so projects without one are refused. In dry
+// runs the change to seeders.go is printed as a diff.
+func (p *Project) GenerateSeeder(ctx context.Context, name string) error {
+	if err := validateName("seeder", name); err != nil {
+		return err
+	}
+	data := seederData{Name: strings.TrimSuffix(camelCase(name), "Seeder")}
+	if data.Name == "" {
+		return fmt.Errorf("invalid seeder name %q: it needs more than the Seeder suffix", name)
+	}
+	data.Key = snakeCase(data.Name)
+	rel := seedDir + "/" + data.Key + "_seeder.go"
+	typeName := data.Name + "Seeder"
+
+	fsys := p.fs()
+	runner, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(seedRunner)))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s not found: seeders need the seed package generated with -db", seedRunner)
+	} else if err != nil {
+		return err
+	}
+	if data.DBImport, data.DBType, err = seederHandle(runner); err != nil {
+		return err
+	}
+	data.StdImport = !strings.Contains(strings.Split(data.DBImport, "/")[0], ".")
+
+	dir := filepath.Join(p.Root, filepath.FromSlash(seedDir))
+	if file, ok := declaredIn(fsys, dir, typeName); ok && (file != path.Base(rel) || !p.Force) {
+		return &ValidationError{Err: fmt.Errorf("%s is already declared in %s/%s", typeName, seedDir, file)}
+	}
+	seeder, err := renderGoTemplate("templates/generate/seeder.go.tmpl", data)
+	if err != nil {
+		return err
+	}
+	src, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(seedList)))
+	if err != nil {
+		return err
+	}
+	list, err := addSeeder(src, typeName)
+	if err != nil {
+		return err
+	}
+
+	return p.generate(ctx, func(g *generator) error {
+		if err := p.generateFile(g, rel, seeder); err != nil {
+			return err
+		}
+		if list == nil {
+			return nil
+		}
+		if p.DryRun {
+			fmt.Fprint(p.out(), unifiedDiff(seedList, string(src), string(list)))
+		}
+		if err := g.updateFile(seedList, string(list)); err != nil {
+			return err
+		}
+		if !p.DryRun {
+			fmt.Fprintln(p.out(), "Listed "+typeName+" in "+seedList)
+		}
+		return nil
+	})
+}
+
+// seederHandle returns the import path and type of the database handle the
+// Seed method of the Seeder interface in seed.go takes.
+func seederHandle(src []byte) (importPath, typ string, err error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, seedRunner, src, parser.SkipObjectResolution)
+	if err != nil {
+		return "", "", err
+	}
+	var param ast.Expr
+	ast.Inspect(file, func(n ast.Node) bool {
+		ts, ok := n.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != "Seeder" {
+			return param == nil
+		}
+		if iface, ok := ts.Type.(*ast.InterfaceType); ok {
+			for _, m := range iface.Methods.List {
+				fn, ok := m.Type.(*ast.FuncType)
+				if ok && len(m.Names) == 1 && m.Names[0].Name == "Seed" && len(fn.Params.List) == 2 {
+					param = fn.Params.List[1].Type
+				}
+			}
+		}
+		return false
+	})
+	if param == nil {
+		return "", "", fmt.Errorf("%s does not declare a Seeder interface with a Seed(ctx, db) method", seedRunner)
+	}
+	typ = types.ExprString(param)
+
+	// The handle is a pointer to a type of an imported package
+	pkg, _, ok := strings.Cut(strings.TrimPrefix(typ, "*"), ".")
+	if !ok {
+		return "", "", fmt.Errorf("%s: the database handle %s is not a type of an imported package", seedRunner, typ)
+	}
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if (imp.Name != nil && imp.Name.Name == pkg) || (imp.Name == nil && path.Base(p) == pkg) {
+			return p, typ, nil
+		}
+	}
+	return "", "", fmt.Errorf("%s does not import the package of %s", seedRunner, typ)
+}
+
+// addSeeder returns the source of seeders.go with a seeder of type
+// typeName appended to the seeders list, or nil if it is listed already.
+func addSeeder(src []byte, typeName string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, seedList, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var list *ast.CompositeLit
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, id := range vs.Names {
+				if id.Name == "seeders" && i < len(vs.Values) {
+					list, _ = vs.Values[i].(*ast.CompositeLit)
+				}
+			}
+		}
+	}
+	if list == nil {
+		return nil, fmt.Errorf("no seeders list found in %s", seedList)
+	}
+
+	for _, elt := range list.Elts {
+		if lit, ok := elt.(*ast.CompositeLit); ok && types.ExprString(lit.Type) == typeName {
+			return nil, nil
+		}
+	}
+	entry := "\t" + typeName + "{},\n"
+	if len(list.Elts) == 0 {
+		entry = "\n" + entry
+	}
+	return applyEdits(seedList, src, []textEdit{{fset.Position(list.Rbrace).Offset, entry}})
+}
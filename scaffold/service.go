@@ -0,0 +1,503 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// serviceDir and repositoryDir hold the packages GenerateService writes.
+const (
+	serviceDir    = "internal/service"
+	repositoryDir = "internal/repository"
+)
+
+// serviceIDTypes are the types the ID field of an entity may have: the
+// in-memory repository draws random hex strings, or counts up from 1.
+var serviceIDTypes = []string{"string", "int", "int32", "int64", "uint", "uint32", "uint64"}
+
+// layerData is passed to the service templates.
+type layerData struct {
+	// Name is the entity's Go name, e.g. "OrderItem", and Var the name of
+	// the service's implementation type, e.g. "orderItem".
+	Name, Var string
+	// Label and Labels name the entity in comments and errors, e.g.
+	// "order item" and "order items".
+	Label, Labels string
+	Module        string
+	// EntityDir is the package declaring the entity, models or
+	// internal/domain, Entity its qualified name, e.g. "models.OrderItem",
+	// and IDType the type of its ID field.
+	EntityDir, Entity, IDType string
+	// ErrPkg is the package declaring Err<Name>NotFound, domain or
+	// repository, NotFound its qualified name and RepositoryNotFound its
+	// name in package repository.
+	ErrPkg, NotFound, RepositoryNotFound string
+	// RepositoryDoc and ServiceDoc are set when the packages are new, to
+	// document them.
+	RepositoryDoc, ServiceDoc bool
+	// RepositoryMock and ServiceMock are the go:generate directives writing
+	// the mocks of the interfaces, unless Project.Mocks is "none".
+	RepositoryMock, ServiceMock string
+}
+
+// GenerateService writes a service and repository layer for the entity
+// name: internal/repository/<name>_repository.go with a <Name>Repository
+// interface and its in-memory implementation, and
+// internal/service/<name>_service.go with a <Name>Service interface and
+// its implementation, tested with the in-memory repository.
+//
+// In the default layout the entity is models.<Name>, which is written with
+// an ID field if the project lacks it and given a string ID if it has
+// none, and the controller of name receives the service through
+// NewNameController(svc), which is written as a CRUD controller if it does
+// not exist yet. Its calls in the router and the tests are given a service
+// storing the entities in memory. In projects composed by internal/app,
+// see Project.DI, the service is added to the Services struct instead,
+// constructed in internal/app, and the router passes the controller its
+// field. In the clean layout the entity is domain.<Name>, and
+// internal/handler/<name>_handler.go receives the service through
+// NewNameHandler(svc).
+//
+// Unless Mocks is "none", both interfaces get a go:generate directive
+// writing their mock into mocks/, which is run, and the Makefile a mocks
+// target. A new controller's Show then gets the entity from the service
+// and is tested with its mock. In dry runs the changes to existing files
+// are printed as diffs.
+func (p *Project) GenerateService(ctx context.Context, name string) error {
+	name = strings.TrimSuffix(name, "Service")
+	if err := validateName("service", name); err != nil {
+		return err
+	}
+	if err := p.validateNaming(); err != nil {
+		return err
+	}
+	layout := p.layout()
+	if layout != DefaultLayout && layout != "clean" {
+		return fmt.Errorf("gomvc generate service supports the %s and clean layouts, not %s", DefaultLayout, layout)
+	}
+
+	fsys := p.fs()
+	names := p.names(name)
+	label := strings.ReplaceAll(names.File, "_", " ")
+	data := layerData{
+		Name:      names.Model,
+		Var:       names.Var,
+		Label:     label,
+		Labels:    strings.ReplaceAll(names.Plural, "_", " "),
+		Module:    p.Module,
+		EntityDir: "models",
+		ErrPkg:    "repository",
+	}
+	if layout == "clean" {
+		data.EntityDir = "internal/domain"
+	}
+	pkg := filepath.Base(data.EntityDir)
+	data.Entity = pkg + "." + data.Name
+	for _, d := range []struct{ dir, decl string }{
+		{repositoryDir, data.Name + "Repository"},
+		{serviceDir, data.Name + "Service"},
+	} {
+		dir := filepath.Join(p.Root, filepath.FromSlash(d.dir))
+		if file, ok := declaredIn(fsys, dir, d.decl); ok && (file != snakeCase(name)+"_"+filepath.Base(d.dir)+".go" || !p.Force) {
+			return &ValidationError{Err: fmt.Errorf("%s is already declared in %s/%s", d.decl, d.dir, file)}
+		}
+	}
+	mocks := p.mockTool()
+	if err := ValidateMockTool(mocks); err != nil {
+		return &ValidationError{Err: err}
+	}
+	data.RepositoryMock = mockDirective(mocks, data.Name, "Repository")
+	data.ServiceMock = mockDirective(mocks, data.Name, "Service")
+	data.RepositoryDoc = !hasGoFiles(fsys, filepath.Join(p.Root, filepath.FromSlash(repositoryDir)))
+	data.ServiceDoc = !hasGoFiles(fsys, filepath.Join(p.Root, filepath.FromSlash(serviceDir)))
+
+	// The entity is written unless the project declares it, with an ID
+	var files []templateFile
+	var updates []fileUpdate
+	entityDir := filepath.Join(p.Root, filepath.FromSlash(data.EntityDir))
+	if file, ok := declaredIn(fsys, entityDir, data.Name); ok {
+		rel := data.EntityDir + "/" + file
+		src, err := fsys.ReadFile(filepath.Join(entityDir, file))
+		if err != nil {
+			return err
+		}
+		idType, withID, err := entityID(rel, src, data.Name)
+		if err != nil {
+			return err
+		}
+		if withID != nil {
+			updates = append(updates, fileUpdate{rel, src, withID})
+		}
+		if !slices.Contains(serviceIDTypes, idType) {
+			return fmt.Errorf("the ID field of %s is a %s: it must be one of %s", data.Entity, idType, strings.Join(serviceIDTypes, ", "))
+		}
+		data.IDType = idType
+		if _, ok := declaredIn(fsys, entityDir, "Err"+data.Name+"NotFound"); ok {
+			data.ErrPkg = pkg
+		}
+	} else {
+		data.IDType = "string"
+		rel := data.EntityDir + "/" + snakeCase(name) + ".go"
+		var content string
+		var err error
+		if layout == "clean" {
+			content, err = renderGoTemplate("templates/generate/service/entity.go.tmpl", data)
+			data.ErrPkg = pkg
+		} else {
+			content, err = p.renderModel(name, []Field{{Name: "id", Type: "string"}})
+		}
+		if err != nil {
+			return err
+		}
+		files = append(files, templateFile{rel, content})
+	}
+	data.NotFound = data.ErrPkg + ".Err" + data.Name + "NotFound"
+	data.RepositoryNotFound = strings.TrimPrefix(data.NotFound, "repository.")
+
+	for _, f := range []struct{ tmpl, rel string }{
+		{"repository.go.tmpl", repositoryDir + "/" + snakeCase(name) + "_repository.go"},
+		{"service.go.tmpl", serviceDir + "/" + snakeCase(name) + "_service.go"},
+		{"service_test.go.tmpl", serviceDir + "/" + snakeCase(name) + "_service_test.go"},
+	} {
+		content, err := renderGoTemplate("templates/generate/service/"+f.tmpl, data)
+		if err != nil {
+			return err
+		}
+		files = append(files, templateFile{f.rel, content})
+	}
+
+	di := p.detectDI()
+	if layout == "clean" {
+		rel := "internal/handler/" + snakeCase(name) + "_handler.go"
+		if _, ok := declaredIn(fsys, filepath.Join(p.Root, "internal", "handler"), data.Name+"Handler"); ok {
+			return &ValidationError{Err: fmt.Errorf("%sHandler is already declared in internal/handler: pass it New%sService(repository.NewMemory%sRepository()) yourself", data.Name, data.Name, data.Name)}
+		}
+		content, err := renderGoTemplate("templates/generate/service/handler.go.tmpl", data)
+		if err != nil {
+			return err
+		}
+		files = append(files, templateFile{rel, content})
+	} else {
+		var injected []fileUpdate
+		var err error
+		if files, injected, err = p.injectService(data, files, di); err != nil {
+			return err
+		}
+		updates = append(updates, injected...)
+		if di != "" {
+			registered, err := p.registerService(data, di)
+			if err != nil {
+				return err
+			}
+			updates = append(updates, registered...)
+		}
+	}
+	// make mocks regenerates the mocks from the go:generate directives
+	if mocks != "none" {
+		if src, err := fsys.ReadFile(filepath.Join(p.Root, "Makefile")); err == nil {
+			if withTarget := addMocksTarget(src); withTarget != nil {
+				updates = append(updates, fileUpdate{"Makefile", src, withTarget})
+			}
+		}
+	}
+	for _, f := range files {
+		if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(f.path))); err == nil && !p.Force {
+			return &ValidationError{Err: fmt.Errorf("%s already exists (use -force to overwrite it)", f.path)}
+		}
+	}
+
+	return p.generateIn(ctx, func(g *generator) error {
+		for _, f := range files {
+			if err := p.generateFile(g, f.path, f.content); err != nil {
+				return err
+			}
+		}
+		for _, u := range updates {
+			if p.DryRun {
+				fmt.Fprint(p.out(), unifiedDiff(u.rel, string(u.before), string(u.after)))
+			}
+			if err := g.updateFile(u.rel, string(u.after)); err != nil {
+				return err
+			}
+			if !p.DryRun {
+				fmt.Fprintf(p.out(), "Updated %s\n", u.rel)
+			}
+		}
+		// wire constructs the new service in app.New
+		if di == "wire" {
+			if err := g.runGo(nil, wireCommand...); err != nil {
+				return fmt.Errorf("failed to generate the injector: %w", err)
+			}
+		}
+		if mocks != "none" {
+			if err := p.generateMocks(g, data.Name, mocks); err != nil {
+				return fmt.Errorf("failed to generate the mocks: %w", err)
+			}
+		}
+		if layout == "clean" && !p.DryRun {
+			fmt.Fprintf(p.out(), "Note: construct the handler in cmd/api/main.go with handler.New%sHandler(service.New%sService(repository.NewMemory%sRepository())) and register its routes in internal/handler/router.go.\n", data.Name, data.Name, data.Name)
+		}
+		return nil
+	})
+}
+
+// injectService adds the controller of the service in data to files, or
+// the changes making it receive the service to the updates it returns if
+// it exists, along with the changes passing it a service in memory to its
+// callers in the router and package controller. With a DI mode the router
+// passes it the field of its Services instead.
+func (p *Project) injectService(data layerData, files []templateFile, di string) ([]templateFile, []fileUpdate, error) {
+	fsys := p.fs()
+	ctrl := "controller/" + snakeCase(data.Name) + "_controller.go"
+	svcImport := p.Module + "/" + serviceDir
+	src, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(ctrl)))
+	if err != nil {
+		// A new CRUD controller gets the service the same way, and Show
+		// calls it
+		cd := controllerData{
+			Name:     data.Name,
+			CRUD:     true,
+			Var:      data.Var,
+			Module:   p.Module,
+			Model:    true,
+			Path:     "/" + p.names(data.Name).Plural,
+			Service:  true,
+			IDType:   data.IDType,
+			NotFound: data.NotFound,
+			Mocks:    p.mockTool(),
+		}
+		_, cd.APIErrors = declaredIn(fsys, filepath.Join(p.Root, "middleware"), "ErrorHandler")
+		if cd.IDType != "string" {
+			cd.IDParse = "ParseInt"
+			if strings.HasPrefix(cd.IDType, "u") {
+				cd.IDParse = "ParseUint"
+			}
+			if cd.IDBits = strings.TrimPrefix(strings.TrimPrefix(cd.IDType, "u"), "int"); cd.IDBits == "" {
+				cd.IDBits = "0"
+			}
+		}
+		if data.ErrPkg == "repository" {
+			cd.ErrImport = p.Module + "/" + repositoryDir
+		}
+		content, err := renderGoTemplate("templates/generate/controller/"+p.framework()+".go.tmpl", cd)
+		if err != nil {
+			return nil, nil, err
+		}
+		injected, err := serviceController(ctrl, []byte(content), data.Name, svcImport)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, templateFile{ctrl, string(injected)})
+		// Its test gives Show a mock service
+		if cd.Mocks != "none" {
+			test, err := renderGoTemplate("templates/generate/service/controller_test/"+p.framework()+".go.tmpl", cd)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, templateFile{strings.TrimSuffix(ctrl, ".go") + "_test.go", test})
+		}
+		pagination, err := p.paginationFiles()
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(files, pagination...), nil, nil
+	}
+	injected, err := serviceController(ctrl, src, data.Name, svcImport)
+	if err != nil {
+		return nil, nil, err
+	}
+	updates := []fileUpdate{{ctrl, src, injected}}
+
+	// Construct the controller with the service wherever it is
+	arg := "service.New" + data.Name + "Service(repository.NewMemory" + data.Name + "Repository())"
+	imports := []string{svcImport, p.Module + "/" + repositoryDir}
+	constructor := "New" + data.Name + "Controller"
+	callers := []string{routerPath}
+	if entries, err := fsys.ReadDir(filepath.Join(p.Root, "controller")); err == nil {
+		for _, e := range entries {
+			if rel := "controller/" + e.Name(); strings.HasSuffix(rel, ".go") && rel != ctrl {
+				callers = append(callers, rel)
+			}
+		}
+	}
+	for _, rel := range callers {
+		src, err := fsys.ReadFile(filepath.Join(p.Root, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		pkg, arg, imports := "controller", arg, imports
+		if rel != routerPath {
+			pkg = ""
+		} else if di != "" {
+			// The router receives the Services internal/app constructs
+			arg, imports = "services."+data.Name, nil
+		}
+		wired, err := passArgument(rel, src, pkg, constructor, arg, imports)
+		if err != nil {
+			return nil, nil, err
+		}
+		if wired != nil {
+			updates = append(updates, fileUpdate{rel, src, wired})
+		}
+	}
+	return files, updates, nil
+}
+
+// serviceController returns the source of the controller file rel with the
+// <name>Controller struct holding a <name>Service and its constructor
+// taking it as svc.
+func serviceController(rel string, src []byte, name, svcImport string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	typeName, constructor := name+"Controller", "New"+name+"Controller"
+	var edits []textEdit
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || ts.Name.Name != typeName {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					return nil, fmt.Errorf("%s in %s is not a struct: only CRUD controllers can receive a service", typeName, rel)
+				}
+				for _, f := range st.Fields.List {
+					if types.ExprString(f.Type) == "service."+name+"Service" {
+						return nil, fmt.Errorf("%s in %s holds a %sService already", typeName, rel, name)
+					}
+				}
+				edits = append(edits, textEdit{fset.Position(st.Fields.Closing).Offset, "\n\tsvc service." + name + "Service\n"})
+			}
+		case *ast.FuncDecl:
+			if decl.Recv != nil || decl.Name.Name != constructor {
+				continue
+			}
+			if len(decl.Type.Params.List) > 0 {
+				return nil, fmt.Errorf("%s in %s takes parameters already: pass it a %sService yourself", constructor, rel, name)
+			}
+			edits = append(edits, textEdit{fset.Position(decl.Type.Params.Closing).Offset, "svc service." + name + "Service"})
+			ast.Inspect(decl.Body, func(n ast.Node) bool {
+				lit, ok := n.(*ast.CompositeLit)
+				if !ok {
+					return true
+				}
+				if id, ok := lit.Type.(*ast.Ident); ok && id.Name == typeName {
+					text := "svc: svc"
+					if len(lit.Elts) > 0 {
+						text += ", "
+					}
+					edits = append(edits, textEdit{fset.Position(lit.Lbrace).Offset + 1, text})
+				}
+				return true
+			})
+		}
+	}
+	if len(edits) < 3 {
+		return nil, fmt.Errorf("%s does not declare a %s struct returned by %s(): only CRUD controllers can receive a service", rel, typeName, constructor)
+	}
+	if missing := missingImports(file, []string{svcImport}); len(missing) > 0 {
+		edits = append(edits, importEdit(fset, file, missing))
+	}
+	return applyEdits(rel, src, edits)
+}
+
+// passArgument returns the source of the Go file rel with arg passed to
+// the calls of function without arguments, pkg.function if pkg is set,
+// and imports added where missing, or nil if it calls none.
+func passArgument(rel string, src []byte, pkg, function, arg string, imports []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	var edits []textEdit
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) > 0 {
+			return true
+		}
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			ok = pkg == "" && fun.Name == function
+		case *ast.SelectorExpr:
+			id, isIdent := fun.X.(*ast.Ident)
+			ok = isIdent && pkg != "" && id.Name == pkg && fun.Sel.Name == function
+		default:
+			ok = false
+		}
+		if ok {
+			edits = append(edits, textEdit{fset.Position(call.Rparen).Offset, arg})
+		}
+		return true
+	})
+	if len(edits) == 0 {
+		return nil, nil
+	}
+	if missing := missingImports(file, imports); len(missing) > 0 {
+		edits = append(edits, importEdit(fset, file, missing))
+	}
+	return applyEdits(rel, src, edits)
+}
+
+// entityID returns the type of the ID field of the struct name declared in
+// the Go file rel. If the struct has none, the ID is a string and the
+// source of rel with the field added first is returned too.
+func entityID(rel string, src []byte, name string) (string, []byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok || ts.Name.Name != name {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				return "", nil, fmt.Errorf("%s in %s is not a struct", name, rel)
+			}
+			for _, f := range st.Fields.List {
+				for _, id := range f.Names {
+					if id.Name == "ID" {
+						return types.ExprString(f.Type), nil, nil
+					}
+				}
+			}
+			withID, err := applyEdits(rel, src, []textEdit{{fset.Position(st.Fields.Opening).Offset + 1, "\n\tID string `json:\"id\"`"}})
+			return "string", withID, err
+		}
+	}
+	return "", nil, fmt.Errorf("%s is not declared in %s", name, rel)
+}
+
+// hasGoFiles reports whether dir holds Go files.
+func hasGoFiles(fsys FS, dir string) bool {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
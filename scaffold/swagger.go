@@ -0,0 +1,22 @@
+package scaffold
+
+import "slices"
+
+// swagRequire provides the runtime of the docs package generated by
+// swag init.
+const swagRequire = "github.com/swaggo/swag@v1.16.3"
+
+// swaggerUIRequires lists the modules serving the Swagger UI with each
+// framework.
+var swaggerUIRequires = map[string][]string{
+	"gin":    {"github.com/swaggo/gin-swagger@v1.6.0", "github.com/swaggo/files@v1.0.1"},
+	"chi":    {"github.com/swaggo/http-swagger/v2@v2.0.2"},
+	"echo":   {"github.com/swaggo/echo-swagger@v1.4.1"},
+	"fiber":  {"github.com/gofiber/swagger@v1.1.0"},
+	"stdlib": {"github.com/swaggo/http-swagger/v2@v2.0.2"},
+}
+
+// swaggerRequires returns the modules the project needs for -swagger.
+func (p *Project) swaggerRequires() []string {
+	return slices.Concat([]string{swagRequire}, swaggerUIRequires[p.framework()])
+}
@@ -0,0 +1,285 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templates holds one directory per layer: "base" with the files shared by
+// every project, one per framework, under "layout" the packages of each
+// layout, shared and per framework, and under "database" one per database,
+// one per database and ORM combination and "migrate" with the migrations
+// package and cmd/migrate of those with migrations. The layers under "auth",
+// "metrics", "tracing", "websocket", "worker", "cache", "messaging",
+// "mailer", "ratelimit", "tls", "version", "grpc" and "config" add the
+// optional authentication slice, Prometheus instrumentation, OpenTelemetry
+// tracing, WebSocket hub, background jobs, cache, event publishing and
+// consumers, mailer, rate limiter, TLS setup, version package, gRPC server
+// and config loader, "graphql" the gqlgen schema and resolvers of -api
+// graphql, those under "web" and "htmx" the views, static files and page
+// controller of -mode web and htmx, those under "css" their stylesheets,
+// "swagger" the docs package placeholder of -swagger, "docker" the
+// Dockerfile and docker-compose.yml of -docker, those under "ci" the
+// pipeline of each -ci provider, "di" and "di/wire" the internal/app
+// package composing the server of -di, and "devtools" the live reload
+// config.
+// Each file is a text/template named after the generated path plus a
+// ".tmpl" suffix. The "generate" directory holds the templates of the
+// generate commands.
+//
+//go:embed all:templates
+var templates embed.FS
+
+// templateSuffix marks template files; it is stripped from generated paths.
+const templateSuffix = ".tmpl"
+
+// TemplateData is passed to every template.
+type TemplateData struct {
+	// Module is the Go module path, e.g. "github.com/username/project".
+	Module string
+	// ProjectName is the last element of Module.
+	ProjectName string
+	// Framework is the framework the project is generated for.
+	Framework string
+	// Port is the port the generated server listens on.
+	Port string
+	// APIPrefix is the path the versioned API groups are served below,
+	// without a trailing slash, e.g. "/api" for /api/v1.
+	APIPrefix string
+	// Layout is the arrangement of the project's packages, see Layouts.
+	Layout string
+	// MainPackage is the server's main package as an argument of the go
+	// command, e.g. "./cmd/api".
+	MainPackage string
+	// Root is the absolute path of the directory the project is created in.
+	Root string
+	// Database is the database the project uses, or empty for none.
+	Database string
+	// DBImport and DBType are the import path and type of the database
+	// handle, e.g. "gorm.io/gorm" and "*gorm.DB".
+	DBImport string
+	DBType   string
+	// DBEnv is the environment variable holding the database URL.
+	DBEnv string
+	// DatabaseURL is the database URL used in development.
+	DatabaseURL string
+	// Migrations is set when the project applies golang-migrate migrations
+	// with cmd/migrate.
+	Migrations bool
+	// MigrateDriver and MigrateName are the golang-migrate database driver
+	// applying them, e.g. "pgx/v5" and "pgx5".
+	MigrateDriver string
+	MigrateName   string
+	// Auth is the authentication scheme, or empty for none.
+	Auth string
+	// SessionSecret is a random key for signing session cookies, generated
+	// for each project that uses session auth.
+	SessionSecret string
+	// Config is the way the project reads its settings: "env" or "viper".
+	Config string
+	// Swagger is set when the project serves Swagger UI docs.
+	Swagger bool
+	// Metrics is set when the project serves Prometheus metrics.
+	Metrics bool
+	// Tracing is set when the project exports OpenTelemetry traces.
+	Tracing bool
+	// WebSocket is set when the project serves WebSocket clients at /ws.
+	WebSocket bool
+	// GRPC is set when the project serves a gRPC API, whose services are
+	// in the protobuf package ProtoPackage plus a version, e.g. "app.v1".
+	// GRPCIgnoreGen is set when the code generated in gen/ is not
+	// committed.
+	GRPC          bool
+	ProtoPackage  string
+	GRPCIgnoreGen bool
+	// GraphQL is set when the project serves a GraphQL API at /query.
+	GraphQL bool
+	// Worker is set when the project runs background jobs from
+	// internal/jobs, with cmd/worker running those queued in Redis.
+	Worker bool
+	// Cache is the backend of the shared cache in pkg/cache, or empty for
+	// none.
+	Cache string
+	// Messaging is the message broker pkg/events publishes to, or empty
+	// for none.
+	Messaging string
+	// Mailer is set when the project sends emails with pkg/mailer.
+	Mailer bool
+	// Validation is set when the project validates requests with
+	// pkg/validate.
+	Validation bool
+	// RateLimit is the scope of the rate limiting middleware, see
+	// RateLimitScopes, or empty for none.
+	RateLimit string
+	// TLS is set when the server can serve HTTPS with pkg/tlsconfig.
+	TLS bool
+	// Web is set when the project serves HTML pages and static files, see
+	// Modes. HTMX is set as well when the pages use htmx, and Tailwind
+	// when static/css/style.css is built with Tailwind CSS.
+	Web      bool
+	HTMX     bool
+	Tailwind bool
+	// Docker is set when the project has a Dockerfile. ComposeDatabaseURL
+	// is the database URL of the app container in docker-compose.yml.
+	Docker             bool
+	ComposeDatabaseURL string
+	// DI is how the server is composed in internal/app, see DIModes, or
+	// empty if main.go composes it.
+	DI string
+	// IntegrationTests is set when the project has integration tests in
+	// tests/.
+	IntegrationTests bool
+	// OpenAPI is the path of the OpenAPI document the project was generated
+	// from, e.g. "api/openapi.yaml", or empty. openAPI is the parsed
+	// document.
+	OpenAPI string
+	openAPI *openAPIDoc
+	// DevTools is set when the project has a .air.toml for live reloading.
+	DevTools bool
+	// VersionPkg is set when the project has pkg/version, whose version
+	// "make build" stamps.
+	VersionPkg bool
+	// GoVersion is the Go release the Dockerfile and CI pipelines build
+	// with, e.g. "1.23", see toolchainGoVersion.
+	GoVersion string
+	// GomvcVersion is the version of gomvc generating the project.
+	GomvcVersion string
+}
+
+// templateFile is a rendered file, relative to the project root.
+type templateFile struct {
+	path    string
+	content string
+}
+
+// templateSource locates a template file.
+type templateSource struct {
+	fsys fs.FS
+	name string
+}
+
+func newTemplateData(module, framework, root string) TemplateData {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return TemplateData{
+		Module:      module,
+		ProjectName: path.Base(module),
+		Framework:   framework,
+		Port:        DefaultPort,
+		Layout:      DefaultLayout,
+		MainPackage: layouts[DefaultLayout].mainPackage,
+		Root:        root,
+		DBEnv:       "DATABASE_URL",
+		Config:      "env",
+		GoVersion:   toolchainGoVersion(),
+	}
+}
+
+// toolchainGoVersion returns the major and minor version of the Go
+// toolchain gomvc was built with, e.g. "1.23", so images and CI get the
+// latest patch release. It is "1", the latest release, for development
+// toolchains.
+func toolchainGoVersion() string {
+	version, _, _ := strings.Cut(strings.TrimPrefix(runtime.Version(), "go"), " ")
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 || parts[0] != "1" || strings.Trim(parts[1], "0123456789") != "" {
+		return "1"
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// renderLayers renders every template in the given layers, in path order.
+// A file in a later layer replaces the file with the same path in an
+// earlier one. Every file in override, if set, replaces or adds to the
+// layered templates; a ".tmpl" suffix is optional there.
+func renderLayers(layers []string, override fs.FS, data TemplateData) ([]templateFile, error) {
+	sources := make(map[string]templateSource)
+	for _, layer := range layers {
+		root := path.Join("templates", layer)
+		// Layers may have no files of their own, like database/sqlite
+		if _, err := fs.Stat(templates, root); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		err := fs.WalkDir(templates, root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(name, templateSuffix) {
+				return err
+			}
+			rel := strings.TrimSuffix(strings.TrimPrefix(name, root+"/"), templateSuffix)
+			sources[rel] = templateSource{templates, name}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if override != nil {
+		err := fs.WalkDir(override, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			sources[strings.TrimSuffix(name, templateSuffix)] = templateSource{override, name}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("read templates: %w", err)
+		}
+	}
+
+	paths := make([]string, 0, len(sources))
+	for rel := range sources {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+
+	files := make([]templateFile, 0, len(paths))
+	for _, rel := range paths {
+		src := sources[rel]
+		content, err := renderTemplate(src.fsys, src.name, data)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, templateFile{path: rel, content: content})
+	}
+	return files, nil
+}
+
+// rendered, if set, is called with the name of every template
+// renderTemplate executes, so tests can check that they cover all of them.
+var rendered func(name string)
+
+// renderTemplate executes the template stored at name in fsys.
+func renderTemplate(fsys fs.FS, name string, data any) (string, error) {
+	if rendered != nil {
+		rendered(name)
+	}
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(name).Parse(string(src))
+	if err != nil {
+		return "", fmt.Errorf("parse template %s: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render template %s: %v", name, err)
+	}
+	return buf.String(), nil
+}
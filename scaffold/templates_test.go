@@ -0,0 +1,305 @@
+package scaffold
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+)
+
+// optionSetters set each option of the registry but the ORM, which is
+// combined with each database instead.
+var optionSetters = map[string]func(p *Project, v string){
+	"framework":       func(p *Project, v string) { p.Framework = v },
+	"layout":          func(p *Project, v string) { p.Layout = v },
+	"mode":            func(p *Project, v string) { p.Mode = v },
+	"api":             func(p *Project, v string) { p.API = v },
+	"css":             func(p *Project, v string) { p.CSS = v },
+	"db":              func(p *Project, v string) { p.Database = v },
+	"auth":            func(p *Project, v string) { p.Auth = v },
+	"config":          func(p *Project, v string) { p.Config = v },
+	"cache":           func(p *Project, v string) { p.Cache = v },
+	"messaging":       func(p *Project, v string) { p.Messaging = v },
+	"ratelimit-scope": func(p *Project, v string) { p.RateLimitScope = v },
+	"line-endings":    func(p *Project, v string) { p.LineEndings = v },
+	"di":              func(p *Project, v string) { p.DI = v },
+	"ci":              func(p *Project, v string) { p.CI = v },
+}
+
+// withFeatures turns on every feature of p that is not an option of the
+// registry, for its framework.
+func withFeatures(p *Project) {
+	p.Swagger, p.Metrics, p.Tracing, p.WebSocket, p.Worker = true, true, true, true, true
+	p.Mailer, p.Validation, p.GRPC, p.RateLimit, p.TLS = true, true, true, true, true
+	p.VersionPkg, p.Docker, p.DevTools, p.WithTests, p.IntegrationTests = true, true, true, true, true
+	if _, ok := middlewareUse[p.framework()]; ok {
+		p.Middleware = []string{"Audit"}
+	}
+}
+
+// projectCombo is a Project generated into a memFS by the tests, named
+// after its options.
+type projectCombo struct {
+	name string
+	p    *Project
+}
+
+// projectCombos returns, for every framework, a project with each value
+// of each option, and each ORM of each database, with every other feature
+// that can be combined with it, or else with the fewest options it needs.
+func projectCombos(t *testing.T) []projectCombo {
+	for _, o := range Options() {
+		if _, ok := optionSetters[o.Name]; !ok && o.Name != "orm" {
+			t.Fatalf("no setter for option %s", o.Name)
+		}
+	}
+	type variant struct {
+		name string
+		set  func(p *Project)
+	}
+	var variants []variant
+	for _, o := range Options() {
+		if o.Name == "framework" || o.Name == "orm" {
+			continue
+		}
+		for _, c := range o.Choices {
+			set, v := optionSetters[o.Name], c.Name
+			variants = append(variants, variant{o.Name + "=" + v, func(p *Project) { set(p, v) }})
+		}
+	}
+	// With authentication, whose user store depends on both
+	for _, db := range Databases() {
+		for _, orm := range ORMs(db) {
+			variants = append(variants, variant{"db=" + db + ",orm=" + orm, func(p *Project) { p.Database, p.ORM, p.Auth = db, orm, "jwt" }})
+		}
+		// Without Docker, whose compose file replaces that of the database
+		variants = append(variants, variant{"db=" + db + ",docker=false", func(p *Project) { p.Database, p.Docker = db, false }})
+	}
+	// What a variant may need besides the option it sets
+	needs := []func(p *Project){
+		withFeatures,
+		func(*Project) {},
+		func(p *Project) { p.Mode = "web" },
+		func(p *Project) { p.RateLimit = true },
+	}
+
+	var combos []projectCombo
+	for _, fw := range Frameworks() {
+		for _, v := range variants {
+			var valid *Project
+			for _, need := range needs {
+				p := memProject()
+				p.Framework = fw
+				need(p)
+				v.set(p)
+				if p.CheckOptions() == nil {
+					valid = p
+					break
+				}
+			}
+			if valid == nil {
+				t.Fatalf("no valid project for %s with %s", fw, v.name)
+			}
+			combos = append(combos, projectCombo{fw + "/" + v.name, valid})
+		}
+	}
+	return combos
+}
+
+// openAPISample is an OpenAPI document with a schema and a tagged
+// operation.
+const openAPISample = `openapi: 3.0.3
+info:
+  title: Pets
+  version: "1.0"
+paths:
+  /pets/{id}:
+    get:
+      tags: [pets]
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema: {type: integer}
+      responses:
+        "200":
+          description: The pet
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+        born: {type: string, format: date-time}
+        tags: {type: array, items: {type: string}}
+`
+
+// runGenerators runs every generator on the project p created.
+func runGenerators(t *testing.T, p *Project) {
+	t.Helper()
+	ctx := context.Background()
+	fields, err := ParseFields([]string{"name:string", "price:float64", "created:time.Time"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"model", func() error { return p.GenerateModel(ctx, "Tag", fields) }},
+		{"controller", func() error { return p.GenerateController(ctx, "Order", true) }},
+		{"resource", func() error { return p.GenerateResource(ctx, "Product", fields) }},
+		{"middleware", func() error {
+			_, register := middlewareUse[p.framework()]
+			return p.GenerateMiddleware(ctx, "Timer", register)
+		}},
+		{"sse", func() error { return p.GenerateSSE(ctx, "Events", "/events") }},
+		{"dto", func() error { return p.GenerateDTO(ctx, "Signup", fields) }},
+		{"seeder", func() error { return p.GenerateSeeder(ctx, "Product") }},
+		{"service", func() error { return p.GenerateService(ctx, "Invoice") }},
+		{"upload", func() error { return p.GenerateUpload(ctx, "Avatar", UploadOptions{}) }},
+		{"cron", func() error { return p.GenerateCron(ctx, "Cleanup", "@daily") }},
+		{"route", func() error { return p.AddRoute(ctx, "GET", "/ping", "controller.Ping", "") }},
+		{"json model", func() error {
+			return p.GenerateModelFromJSON(ctx, "Profile", []byte(`{"name": "a", "age": 1, "tags": ["b"]}`), true)
+		}},
+	}
+	for _, s := range steps {
+		if err := s.run(); err != nil {
+			t.Fatalf("generate %s: %v", s.name, err)
+		}
+	}
+}
+
+// goFiles returns the Go files of the project in p's memFS by their path
+// relative to its root.
+func goFiles(p *Project) map[string][]byte {
+	m := p.FS.(*memFS)
+	files := make(map[string][]byte)
+	for path, f := range m.files {
+		if rel, err := filepath.Rel(p.Root, path); err == nil && filepath.Ext(path) == ".go" {
+			files[filepath.ToSlash(rel)] = f.data
+		}
+	}
+	return files
+}
+
+func TestTemplatesParse(t *testing.T) {
+	err := fs.WalkDir(templates, "templates", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(name, templateSuffix) {
+			return err
+		}
+		src, err := fs.ReadFile(templates, name)
+		if err != nil {
+			return err
+		}
+		if _, err := template.New(name).Parse(string(src)); err != nil {
+			t.Errorf("parse %s: %v", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTemplatesRender creates every project of projectCombos, runs every
+// generator on the feature-rich project of each framework, and checks that
+// every Go file they write parses and that every template is rendered.
+func TestTemplatesRender(t *testing.T) {
+	if testing.Short() {
+		t.Skip("creates hundreds of projects")
+	}
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	rendered = func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[name] = true
+	}
+	defer func() { rendered = nil }()
+
+	check := func(t *testing.T, p *Project) {
+		t.Helper()
+		for rel, src := range goFiles(p) {
+			if _, err := parser.ParseFile(token.NewFileSet(), rel, src, parser.AllErrors); err != nil {
+				t.Errorf("%v\n%s", err, src)
+			}
+		}
+	}
+	for _, c := range projectCombos(t) {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.p.Create(context.Background()); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			check(t, c.p)
+		})
+	}
+	for _, fw := range Frameworks() {
+		t.Run(fw+"/generators", func(t *testing.T) {
+			p := memProject()
+			p.Framework, p.Database = fw, "postgres"
+			withFeatures(p)
+			p.OpenAPI = filepath.Join(filepath.Dir(p.Root), "openapi.yaml")
+			if err := p.FS.MkdirAll(filepath.Dir(p.OpenAPI), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.FS.WriteFile(p.OpenAPI, []byte(openAPISample), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Create(context.Background()); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			runGenerators(t, p)
+			check(t, p)
+		})
+		t.Run(fw+"/clean/service", func(t *testing.T) {
+			p := createdProject(t, func(p *Project) { p.Framework, p.Layout = fw, "clean" })
+			if err := p.GenerateService(context.Background(), "Invoice"); err != nil {
+				t.Fatalf("GenerateService: %v", err)
+			}
+			check(t, p)
+		})
+	}
+	// Models are generated from a live database only
+	model := dbModelData{
+		Name: "Account", Table: "accounts", Imports: []string{"time"}, TableMethod: true,
+		Fields: []dbField{
+			{Name: "ID", Type: "int64", Tag: `db:"id" json:"id"`, Comment: "primary key"},
+			{Name: "CreatedAt", Type: "*time.Time", Tag: `db:"created_at" json:"created_at"`},
+		},
+	}
+	src, err := renderGoTemplate("templates/generate/dbmodel.go.tmpl", model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "account.go", src, 0); err != nil {
+		t.Errorf("dbmodel.go.tmpl: %v", err)
+	}
+
+	var missed []string
+	err = fs.WalkDir(templates, "templates", func(name string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(name, templateSuffix) && !seen[name] {
+			missed = append(missed, name)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(missed)
+	if len(missed) > 0 {
+		t.Errorf("templates never rendered:\n%s", strings.Join(missed, "\n"))
+	}
+}
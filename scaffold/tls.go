@@ -0,0 +1,5 @@
+package scaffold
+
+// autocertRequire provides acme/autocert, which gets the certificates of
+// -tls from Let's Encrypt.
+const autocertRequire = "golang.org/x/crypto@v0.28.0"
@@ -0,0 +1,40 @@
+package scaffold
+
+import (
+	"io/fs"
+	"slices"
+)
+
+// otelRequires provides the OpenTelemetry SDK and the OTLP exporter of
+// pkg/tracing.
+var otelRequires = []string{
+	"go.opentelemetry.io/otel@v1.31.0",
+	"go.opentelemetry.io/otel/sdk@v1.31.0",
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp@v1.31.0",
+}
+
+// otelMiddlewareRequires lists the module providing the OpenTelemetry
+// middleware of each framework.
+var otelMiddlewareRequires = map[string]string{
+	"gin":    "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin@v0.56.0",
+	"chi":    "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp@v0.56.0",
+	"echo":   "go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho@v0.56.0",
+	"fiber":  "github.com/gofiber/contrib/otelfiber@v1.0.10",
+	"stdlib": "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp@v0.56.0",
+}
+
+// tracingLayers returns the template layers of -otel: pkg/tracing and, for
+// the frameworks whose middleware is not used directly in router.go, a
+// middleware naming spans after the matched route.
+func (p *Project) tracingLayers() []string {
+	layers := []string{"tracing/base"}
+	if _, err := fs.Stat(templates, "templates/tracing/"+p.framework()); err == nil {
+		layers = append(layers, "tracing/"+p.framework())
+	}
+	return layers
+}
+
+// tracingRequires returns the modules the project needs for -otel.
+func (p *Project) tracingRequires() []string {
+	return slices.Concat(otelRequires, []string{otelMiddlewareRequires[p.framework()]})
+}
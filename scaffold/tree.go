@@ -0,0 +1,71 @@
+package scaffold
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// treeNode is a directory or file of the tree printed by writeTree.
+type treeNode struct {
+	name     string
+	dir      bool
+	children map[string]*treeNode
+}
+
+// writeTree prints dirs and files, relative paths with slashes, as a tree
+// below name, like the tree command does. Entries are listed in name
+// order, directories with a trailing slash.
+func writeTree(w io.Writer, name string, dirs, files []string) {
+	root := &treeNode{name: name}
+	for _, d := range dirs {
+		root.add(d).dir = true
+	}
+	for _, f := range files {
+		root.add(f)
+	}
+	fmt.Fprintln(w, root.name)
+	root.writeChildren(w, "")
+}
+
+// add returns the node of the path rel below n, adding it and its parents
+// if missing.
+func (n *treeNode) add(rel string) *treeNode {
+	for _, part := range strings.Split(rel, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		if n.children == nil {
+			n.children = make(map[string]*treeNode)
+		}
+		child, ok := n.children[part]
+		if !ok {
+			child = &treeNode{name: part}
+			n.children[part] = child
+		}
+		n.dir = true
+		n = child
+	}
+	return n
+}
+
+func (n *treeNode) writeChildren(w io.Writer, indent string) {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		branch, next := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, next = "└── ", "    "
+		}
+		child := n.children[name]
+		if child.dir {
+			name += "/"
+		}
+		fmt.Fprintln(w, indent+branch+name)
+		child.writeChildren(w, indent+next)
+	}
+}
@@ -0,0 +1,211 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// UpgradeSuffix is appended to the path of a file changed since it was
+// generated, for the new version of it Upgrade could not merge.
+const UpgradeSuffix = ".gomvc-new"
+
+// ErrNoUpgradeOptions is returned by Upgrade for projects whose manifest
+// was written before the options of projects were recorded in it.
+var ErrNoUpgradeOptions = errors.New("the manifest does not record the options of the project")
+
+// Upgrade renders the templates of the project at Root again, with the
+// options recorded in its manifest, and brings its files up to date:
+//
+//   - files unchanged since they were generated are replaced;
+//   - files changed since get the changes of the templates merged into
+//     them, or, when both changed the same lines or the generated content
+//     is unknown, the new version written next to them with UpgradeSuffix;
+//   - files the templates now have are created, and files deleted since
+//     they were generated are left deleted.
+//
+// A diff of every change is printed to Out, and the files replaced are
+// backed up into BackupDir first. DryRun only prints them.
+// Projects already generated by this version of gomvc are left alone
+// unless Force is set. The options of p other than Templates are ignored.
+func (p *Project) Upgrade(ctx context.Context) error {
+	m, err := readManifest(p.fs(), p.Root)
+	if err != nil {
+		return err
+	}
+	if m.Options == nil {
+		version := m.GomvcVersion
+		if version == "" {
+			version = "an older version"
+		}
+		return fmt.Errorf("%w: it was created by gomvc %s, before upgrades were supported", ErrNoUpgradeOptions, version)
+	}
+	from, to := m.GomvcVersion, p.gomvcVersion()
+	if from == "" {
+		from = "an unknown version"
+	}
+	if from == to && to != DefaultGomvcVersion && !p.Force {
+		fmt.Fprintf(p.out(), "%s was generated by gomvc %s already; nothing to upgrade (use -force to compare its files anyway).\n", p.Root, to)
+		return nil
+	}
+
+	q := m.Options.project(p.Root, m)
+	q.Templates, q.GomvcVersion = p.Templates, p.GomvcVersion
+	if err := q.CheckOptions(); err != nil {
+		return &ValidationError{Err: fmt.Errorf("the options recorded in the manifest: %w", err)}
+	}
+	layers, requires, data, err := q.plan(m.Module)
+	if err != nil {
+		return &ValidationError{Err: fmt.Errorf("the options recorded in the manifest: %w", err)}
+	}
+	data.SessionSecret = m.Options.SessionSecret
+	files, err := q.renderFiles(layers, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(p.out(), "Upgrading %s from gomvc %s to %s\n", p.Root, from, to)
+	fsys, runner := p.effects()
+	g := &generator{
+		ctx:      ctx,
+		root:     p.Root,
+		fs:       fsys,
+		runner:   runner,
+		manifest: *m,
+		dirMode:  p.dirMode(),
+		fileMode: p.fileMode(),
+		crlf:     m.Options.lineEndings() == "crlf",
+		bases:    make(map[string]string),
+		backup:   p.newBackup(),
+	}
+	defer g.backup.report(p.out())
+	g.manifest.GomvcVersion = to
+	g.manifest.Hashes = make(map[string]string, len(m.Hashes))
+	for rel, hash := range m.Hashes {
+		g.manifest.Hashes[rel] = hash
+	}
+	if !p.DryRun {
+		g.baseFS = p.fs()
+	}
+
+	var updated, merged, conflicts, created int
+	rendered := make(map[string]bool)
+	for _, f := range files {
+		rendered[f.path] = true
+		path := filepath.Join(p.Root, filepath.FromSlash(f.path))
+		current, err := fsys.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			if m.hasFile(f.path) {
+				fmt.Fprintf(p.out(), "  kept      %s: deleted since it was generated\n", filepath.FromSlash(f.path))
+				continue
+			}
+			fmt.Fprintf(p.out(), "  create    %s\n", filepath.FromSlash(f.path))
+			fmt.Fprint(p.out(), unifiedDiff(f.path, "", f.content))
+			if err := g.createFile(f.path, f.content); err != nil {
+				return err
+			}
+			created++
+			continue
+		case err != nil:
+			return err
+		}
+		current = []byte(toLF(string(current)))
+
+		hash, generated := m.Hashes[f.path]
+		switch {
+		case string(current) == f.content:
+			// Up to date, possibly by a change of the user's
+			g.track(f.path, f.content)
+		case generated && contentHash(string(current)) == hash:
+			fmt.Fprintf(p.out(), "  update    %s\n", filepath.FromSlash(f.path))
+			fmt.Fprint(p.out(), unifiedDiff(f.path, string(current), f.content))
+			if err := g.backup.add(f.path); err != nil {
+				return err
+			}
+			if err := g.updateFile(f.path, f.content); err != nil {
+				return err
+			}
+			g.track(f.path, f.content)
+			updated++
+		default:
+			base, ok := readBase(p.fs(), p.Root, f.path, hash)
+			var content string
+			if ok && generated {
+				content, ok = merge3(base, string(current), f.content)
+			}
+			if ok {
+				fmt.Fprintf(p.out(), "  merge     %s\n", filepath.FromSlash(f.path))
+				fmt.Fprint(p.out(), unifiedDiff(f.path, string(current), content))
+				if err := g.backup.add(f.path); err != nil {
+					return err
+				}
+				if err := g.updateFile(f.path, content); err != nil {
+					return err
+				}
+				g.track(f.path, f.content)
+				merged++
+				continue
+			}
+			fmt.Fprintf(p.out(), "  conflict  %s: changed since it was generated; the new version is in %s\n", filepath.FromSlash(f.path), filepath.FromSlash(f.path+UpgradeSuffix))
+			fmt.Fprint(p.out(), unifiedDiff(f.path, string(current), f.content))
+			// A new version left by an earlier upgrade may hold merges
+			if err := g.backup.add(f.path + UpgradeSuffix); err != nil {
+				return err
+			}
+			if err := g.updateFile(f.path+UpgradeSuffix, f.content); err != nil {
+				return err
+			}
+			conflicts++
+		}
+	}
+
+	var stale []string
+	for rel := range m.Hashes {
+		if !rendered[rel] && m.hasFile(rel) {
+			stale = append(stale, rel)
+		}
+	}
+	sort.Strings(stale)
+	for _, rel := range stale {
+		fmt.Fprintf(p.out(), "  kept      %s: no longer generated\n", filepath.FromSlash(rel))
+	}
+
+	if updated+merged+created > 0 {
+		for _, req := range requires {
+			if err := g.runGo([]string{"go.sum"}, "get", req); err != nil {
+				return fmt.Errorf("failed to add dependency %s: %w", req, err)
+			}
+		}
+		if !p.SkipVerify && conflicts == 0 {
+			if err := g.verify(); err != nil {
+				return err
+			}
+		}
+	}
+	if !p.DryRun {
+		if err := g.saveManifest(nil); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+
+	verb := "Upgraded"
+	if p.DryRun {
+		verb = "Would upgrade"
+	}
+	fmt.Fprintf(p.out(), "%s %s: %d updated, %d merged, %d created, %d conflicts.\n", verb, p.Root, updated, merged, created, conflicts)
+	if conflicts > 0 {
+		fmt.Fprintf(p.out(), "Merge the %s files into the files next to them by hand, then delete them.\n", UpgradeSuffix)
+	}
+	return nil
+}
+
+// track records content as the generated content of rel, which Upgrade
+// wrote or found already in place.
+func (g *generator) track(rel, content string) {
+	g.bases[rel] = content
+	g.manifest.Hashes[rel] = contentHash(content)
+}
@@ -0,0 +1,135 @@
+package scaffold
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// upgradedProject returns a project created into a memFS by gomvc v1.0.0,
+// with a notes.txt rendered from the template old.
+func upgradedProject(t *testing.T, old string) *Project {
+	t.Helper()
+	return createdProject(t, func(p *Project) {
+		p.GomvcVersion = "v1.0.0"
+		p.Templates = fstest.MapFS{"notes.txt.tmpl": {Data: []byte(old)}}
+	})
+}
+
+func TestUpgrade(t *testing.T) {
+	const (
+		old      = "a\nb\nc\nd\ne\n"
+		template = "a\nB\nc\nd\ne\n"
+	)
+	tests := []struct {
+		name string
+		// edited is the content the user gave notes.txt, if any.
+		edited       string
+		dryRun       bool
+		want         string
+		wantConflict bool
+	}{
+		{name: "unchanged", want: template},
+		{name: "changed elsewhere", edited: "a\nb\nc\nd\nE\n", want: "a\nB\nc\nd\nE\n"},
+		{name: "changed the same way", edited: template, want: template},
+		{name: "conflict", edited: "a\nX\nc\nd\ne\n", want: "a\nX\nc\nd\ne\n", wantConflict: true},
+		{name: "dry run", dryRun: true, want: old},
+		{name: "dry run conflict", edited: "a\nX\nc\nd\ne\n", dryRun: true, want: "a\nX\nc\nd\ne\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := upgradedProject(t, old)
+			notes := filepath.Join(p.Root, "notes.txt")
+			if tt.edited != "" {
+				if err := p.FS.WriteFile(notes, []byte(tt.edited), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			p.GomvcVersion, p.DryRun = "v2.0.0", tt.dryRun
+			p.Templates = fstest.MapFS{"notes.txt.tmpl": {Data: []byte(template)}}
+			if err := p.Upgrade(context.Background()); err != nil {
+				t.Fatalf("Upgrade: %v", err)
+			}
+			if got, _ := p.FS.ReadFile(notes); string(got) != tt.want {
+				t.Errorf("notes.txt = %q, want %q", got, tt.want)
+			}
+			got, err := p.FS.ReadFile(notes + UpgradeSuffix)
+			if tt.wantConflict {
+				if string(got) != template {
+					t.Errorf("notes.txt%s = %q, %v; want the new version %q", UpgradeSuffix, got, err, template)
+				}
+			} else if err == nil {
+				t.Errorf("notes.txt%s written without a conflict", UpgradeSuffix)
+			}
+
+			m, err := readManifest(p.FS, p.Root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantVersion := "v2.0.0"
+			if tt.dryRun {
+				wantVersion = "v1.0.0"
+			}
+			if m.GomvcVersion != wantVersion {
+				t.Errorf("manifest records gomvc %s, want %s", m.GomvcVersion, wantVersion)
+			}
+		})
+	}
+}
+
+// TestUpgradeSameVersion checks that a project generated by the version
+// upgrading it is only compared with Force.
+func TestUpgradeSameVersion(t *testing.T) {
+	const old, template = "a\n", "b\n"
+	for _, force := range []bool{false, true} {
+		p := upgradedProject(t, old)
+		p.Force = force
+		p.Templates = fstest.MapFS{"notes.txt.tmpl": {Data: []byte(template)}}
+		if err := p.Upgrade(context.Background()); err != nil {
+			t.Fatalf("Upgrade: %v", err)
+		}
+		want := old
+		if force {
+			want = template
+		}
+		if got, _ := p.FS.ReadFile(filepath.Join(p.Root, "notes.txt")); string(got) != want {
+			t.Errorf("with force %v, notes.txt = %q, want %q", force, got, want)
+		}
+	}
+}
+
+// TestUpgradeBacksUp checks that the files Upgrade replaces, merged or
+// not, are backed up first, and that dry runs back up nothing.
+func TestUpgradeBacksUp(t *testing.T) {
+	const old, edited = "a\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n"
+	for _, dryRun := range []bool{false, true} {
+		p := upgradedProject(t, old)
+		notes := filepath.Join(p.Root, "notes.txt")
+		if err := p.FS.WriteFile(notes, []byte(edited), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		p.GomvcVersion, p.DryRun, p.BackupDir = "v2.0.0", dryRun, "/backups"
+		p.Templates = fstest.MapFS{"notes.txt.tmpl": {Data: []byte("a\nB\nc\nd\ne\n")}}
+		if err := p.Upgrade(context.Background()); err != nil {
+			t.Fatalf("Upgrade: %v", err)
+		}
+		entries, _ := p.FS.ReadDir("/backups")
+		if dryRun {
+			if len(entries) != 0 {
+				t.Errorf("dry run backed up to %s", entries[0].Name())
+			}
+			continue
+		}
+		if len(entries) != 1 {
+			t.Fatalf("%d backups, want 1", len(entries))
+		}
+		files := backupFiles(t, p.FS, filepath.Join("/backups", entries[0].Name()))
+		// README.md names the gomvc version, so it is updated too
+		if len(files) != 2 || files["notes.txt"] != edited || !strings.Contains(files["README.md"], "v1.0.0") {
+			t.Errorf("backup holds %q, want notes.txt as edited and README.md of v1.0.0", files)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// uploadRequires are the modules of the S3 storage of pkg/storage.
+var uploadRequires = []string{
+	"github.com/aws/aws-sdk-go-v2@v1.47.1",
+	"github.com/aws/aws-sdk-go-v2/config@v1.33.6",
+	"github.com/aws/aws-sdk-go-v2/service/s3@v1.113.4",
+}
+
+// uploadStorage is the storage package shared by every upload controller.
+const uploadStorage = "pkg/storage/storage.go"
+
+// uploadDir is the directory the local storage keeps files in by default.
+const uploadDir = "uploads"
+
+// DefaultUploadMaxSize is the size of the largest file upload controllers
+// accept unless told otherwise, in bytes.
+const DefaultUploadMaxSize = 10 << 20
+
+// DefaultUploadTypes are the content types upload controllers accept
+// unless told otherwise: the images browsers display.
+var DefaultUploadTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// uploadGitignore keeps the files of the local storage out of git, but
+// not the directory.
+const uploadGitignore = "# Files uploaded to the local storage\n/" + uploadDir + "/*\n!/" + uploadDir + "/.gitkeep\n"
+
+// uploadEnv documents the settings of pkg/storage in .env.example.
+const uploadEnv = `# Uploaded files are stored in STORAGE_DIR with local, and in the S3_BUCKET
+# bucket with s3, using the AWS_* credentials or those of ~/.aws.
+# S3_ENDPOINT points at an S3-compatible server such as MinIO instead.
+STORAGE_DRIVER=local
+STORAGE_DIR=uploads
+# S3_BUCKET=
+# S3_PREFIX=
+# S3_ENDPOINT=
+`
+
+// uploadData is passed to the upload templates.
+type uploadData struct {
+	// Name is the controller's Go name without the UploadController
+	// suffix.
+	Name string
+	// Var prefixes the names the controller's test and routes declare.
+	Var string
+	// Module is the project's module path, for importing pkg/storage.
+	Module string
+	// Path is the route files are uploaded to, e.g. "/uploads/avatar".
+	Path string
+	// Prefix starts the keys of the stored files, e.g. "avatar".
+	Prefix string
+	// MaxSize is the size of the largest file accepted, in bytes.
+	MaxSize int64
+	// Types are the accepted content types.
+	Types []string
+}
+
+// MaxSizeText returns MaxSize for people, e.g. "10 MB".
+func (d uploadData) MaxSizeText() string {
+	return formatSize(d.MaxSize)
+}
+
+// UploadOptions configures GenerateUpload. The zero value uses the
+// defaults.
+type UploadOptions struct {
+	// Path is the route files are uploaded to, "/uploads/<name>" by
+	// default.
+	Path string
+	// MaxSize is the size of the largest file accepted, in bytes,
+	// DefaultUploadMaxSize by default.
+	MaxSize int64
+	// Types are the accepted content types, DefaultUploadTypes by
+	// default.
+	Types []string
+}
+
+// GenerateUpload writes controller/<name>_upload_controller.go, whose
+// <Name>UploadController stores the files of multipart uploads through
+// pkg/storage after checking their size and sniffing their content type,
+// and a test for it, and registers its Upload handler for POST path in
+// InitializeRoutes. The first upload controller also writes pkg/storage,
+// with its local-disk and S3 implementations, adds the AWS SDK to go.mod,
+// creates the uploads directory and ignores its files in .gitignore. A
+// route that is already registered is left alone. In dry runs the router
+// change is printed as a diff.
+func (p *Project) GenerateUpload(ctx context.Context, name string, opts UploadOptions) error {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "Controller"), "Upload")
+	if err := validateName("controller", name); err != nil {
+		return err
+	}
+	if err := ValidateFramework(p.framework()); err != nil {
+		return err
+	}
+	if opts.Path == "" {
+		opts.Path = "/uploads/" + snakeCase(name)
+	}
+	if err := validatePath("path", opts.Path); err != nil {
+		return err
+	}
+	path := strings.TrimSuffix(opts.Path, "/")
+	if path == "" {
+		return fmt.Errorf("files cannot be uploaded to /, which serves the home page")
+	}
+	if opts.MaxSize == 0 {
+		opts.MaxSize = DefaultUploadMaxSize
+	}
+	if opts.MaxSize < 0 {
+		return fmt.Errorf("invalid maximum size %d: must be positive", opts.MaxSize)
+	}
+	if len(opts.Types) == 0 {
+		opts.Types = DefaultUploadTypes
+	}
+	for _, t := range opts.Types {
+		if err := validateContentType(t); err != nil {
+			return err
+		}
+	}
+
+	data := uploadData{
+		Name:    camelCase(name),
+		Var:     lowerCamelCase(name),
+		Module:  p.Module,
+		Path:    path,
+		Prefix:  snakeCase(name),
+		MaxSize: opts.MaxSize,
+		Types:   opts.Types,
+	}
+	base := "controller/" + snakeCase(name) + "_upload_controller"
+	files := []templateFile{}
+	for _, f := range []struct{ rel, tmpl string }{
+		{base + ".go", "controller/"},
+		{base + "_test.go", "controller_test/"},
+	} {
+		content, err := renderGoTemplate("templates/generate/upload/"+f.tmpl+p.framework()+".go.tmpl", data)
+		if err != nil {
+			return err
+		}
+		files = append(files, templateFile{f.rel, content})
+	}
+
+	fsys := p.fs()
+	if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(uploadStorage))); err != nil || p.Force {
+		for _, name := range []string{"storage.go", "local.go", "s3.go", "storage_test.go"} {
+			content, err := renderGoTemplate("templates/generate/upload/"+name+".tmpl", data)
+			if err != nil {
+				return err
+			}
+			files = append(files, templateFile{"pkg/storage/" + name, content})
+		}
+	}
+	for _, f := range files[:2] {
+		if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(f.path))); err == nil && !p.Force {
+			return &ValidationError{Err: fmt.Errorf("%s already exists (use -force to overwrite it)", f.path)}
+		}
+	}
+	keep := uploadDir + "/.gitkeep"
+	if _, err := fsys.Stat(filepath.Join(p.Root, filepath.FromSlash(keep))); errors.Is(err, os.ErrNotExist) {
+		files = append(files, templateFile{keep, ""})
+	}
+
+	rf, err := parseRouter(fsys, p.Root)
+	if err != nil {
+		return err
+	}
+	router, err := rf.routerVar(rf.setup)
+	if err != nil {
+		return err
+	}
+	var routes []byte
+	if rf.hasRoute(rf.setup, path) {
+		fmt.Fprintf(p.out(), "A route for %s already exists in %s, skipping.\n", path, routerPath)
+	} else {
+		rd := routesData{Name: data.Name, Var: data.Var, Path: path, Router: router}
+		_, rd.RequestID = declaredIn(fsys, filepath.Join(p.Root, "middleware"), "RequestID")
+		stmts, err := renderTemplate(templates, "templates/generate/upload/routes/"+p.framework()+".go.tmpl", rd)
+		if err != nil {
+			return err
+		}
+		imports := []string{"context", "log", p.Module + "/controller", p.Module + "/pkg/storage"}
+		if p.framework() == "stdlib" {
+			imports = append(imports, "net/http", p.Module+"/middleware")
+		}
+		if routes, err = rf.appendTo(rf.setup, stmts, imports...); err != nil {
+			return err
+		}
+	}
+
+	// Ignore the uploaded files, document the storage settings and let the
+	// server of the Docker image write to the uploads directory, once
+	var updates []fileUpdate
+	for _, a := range []struct {
+		rel, marker string
+		edit        func(string) string
+	}{
+		{".gitignore", "/" + uploadDir + "/", appendText(uploadGitignore)},
+		{".env.example", "STORAGE_DRIVER=", appendText(uploadEnv)},
+		{"Dockerfile", "/app/" + uploadDir, func(s string) string {
+			return strings.Replace(s, dockerAddUser, dockerAddUser+" && mkdir -p /app/"+uploadDir+" && chown app /app/"+uploadDir, 1)
+		}},
+	} {
+		src, err := fsys.ReadFile(filepath.Join(p.Root, a.rel))
+		if errors.Is(err, os.ErrNotExist) || strings.Contains(string(src), a.marker) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if after := a.edit(string(src)); after != string(src) {
+			updates = append(updates, fileUpdate{a.rel, src, []byte(after)})
+		}
+	}
+
+	gomod, err := fsys.ReadFile(filepath.Join(p.Root, "go.mod"))
+	if err != nil {
+		return err
+	}
+	module, _, _ := strings.Cut(uploadRequires[len(uploadRequires)-1], "@")
+	hasRequire := strings.Contains(string(gomod), module+" ")
+
+	return p.generate(ctx, func(g *generator) error {
+		for _, f := range files {
+			if err := p.generateFile(g, f.path, f.content); err != nil {
+				return err
+			}
+		}
+		for _, u := range updates {
+			if p.DryRun {
+				fmt.Fprint(p.out(), unifiedDiff(u.rel, string(u.before), string(u.after)))
+			}
+			if err := g.updateFile(u.rel, string(u.after)); err != nil {
+				return err
+			}
+			if !p.DryRun {
+				fmt.Fprintf(p.out(), "Updated %s\n", u.rel)
+			}
+		}
+		if routes != nil {
+			if p.DryRun {
+				fmt.Fprint(p.out(), unifiedDiff(routerPath, string(rf.src), string(routes)))
+			}
+			if err := g.updateFile(routerPath, string(routes)); err != nil {
+				return err
+			}
+			if !p.DryRun {
+				fmt.Fprintf(p.out(), "Registered POST %s in %s\n", path, routerPath)
+			}
+		}
+		if hasRequire {
+			return nil
+		}
+		// The second go get records the SDK modules as direct dependencies
+		if err := g.runGo([]string{"go.sum"}, append([]string{"get"}, uploadRequires...)...); err != nil {
+			return err
+		}
+		return g.runGo(nil, "get", "./...")
+	})
+}
+
+// dockerAddUser creates the user the server of the Docker image runs as.
+const dockerAddUser = "RUN adduser -D -H -u 10001 app"
+
+// appendText returns an edit appending text to a file, on a line of its
+// own.
+func appendText(text string) func(string) string {
+	return func(s string) string {
+		if s != "" && !strings.HasSuffix(s, "\n") {
+			s += "\n"
+		}
+		return s + text
+	}
+}
+
+// fileUpdate is a change to an existing file of the project.
+type fileUpdate struct {
+	rel           string
+	before, after []byte
+}
+
+// validateContentType checks that t is a media type without parameters,
+// such as "image/png".
+func validateContentType(t string) error {
+	mediaType, params, err := mime.ParseMediaType(t)
+	if err != nil || len(params) > 0 || mediaType != t || !strings.Contains(t, "/") {
+		return fmt.Errorf("invalid content type %q: use a media type such as image/png", t)
+	}
+	return nil
+}
+
+// sizeUnits are the suffixes ParseSize accepts, largest first.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a size in bytes such as "512KB", "10MB" or "1048576".
+// The units are powers of 1024 and case-insensitive.
+func ParseSize(s string) (int64, error) {
+	number, unit := strings.TrimSpace(s), int64(1)
+	for _, u := range sizeUnits {
+		if rest, ok := strings.CutSuffix(strings.ToUpper(number), u.suffix); ok {
+			number, unit = strings.TrimSpace(rest), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 || n > (1<<62)/unit {
+		return 0, fmt.Errorf("invalid size %q: use a positive number of bytes, optionally followed by KB, MB or GB", s)
+	}
+	return n * unit, nil
+}
+
+// formatSize returns n bytes in the largest unit dividing it, e.g.
+// "10 MB".
+func formatSize(n int64) string {
+	for _, u := range sizeUnits {
+		if n >= u.bytes && n%u.bytes == 0 {
+			if u.bytes == 1 {
+				return fmt.Sprintf("%d bytes", n)
+			}
+			return fmt.Sprintf("%d %s", n/u.bytes, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,4 @@
+package scaffold
+
+// validatorRequire checks the validate tags of requests in pkg/validate.
+const validatorRequire = "github.com/go-playground/validator/v10@v10.30.5"
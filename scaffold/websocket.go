@@ -0,0 +1,18 @@
+package scaffold
+
+// websocketRequires lists the module each framework upgrades WebSocket
+// connections with. Fiber runs on fasthttp, which gorilla/websocket does
+// not support, so it uses the fasthttp fork through Fiber's middleware.
+var websocketRequires = map[string][]string{
+	"gin":    {"github.com/gorilla/websocket@v1.5.3"},
+	"chi":    {"github.com/gorilla/websocket@v1.5.3"},
+	"echo":   {"github.com/gorilla/websocket@v1.5.3"},
+	"fiber":  {"github.com/gofiber/contrib/websocket@v1.3.2", "github.com/fasthttp/websocket@v1.5.8"},
+	"stdlib": {"github.com/gorilla/websocket@v1.5.3"},
+}
+
+// websocketLayers returns the template layers of -ws: the hub in pkg/ws
+// and the framework's controller.
+func (p *Project) websocketLayers() []string {
+	return []string{"websocket/base", "websocket/" + p.framework()}
+}
@@ -0,0 +1,12 @@
+package scaffold
+
+// redisRequires lists the modules of -worker and -cache redis: go-redis
+// for the Redis queue and cache, and miniredis, which their tests run them
+// against.
+var redisRequires = []string{"github.com/redis/go-redis/v9@v9.22.0", "github.com/alicebob/miniredis/v2@v2.39.0"}
+
+// workerLayers returns the template layers of -worker: internal/jobs with
+// cmd/worker, and the framework's controller queueing a job.
+func (p *Project) workerLayers() []string {
+	return []string{"worker/base", "worker/" + p.framework()}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+// upgradeOptions holds the flags of 'gomvc upgrade'.
+type upgradeOptions struct {
+	dryRun       bool
+	force        bool
+	skipVerify   bool
+	templatesDir string
+	verbose      bool
+	backup       backupOptions
+}
+
+// upgradeFlags returns the flags of 'gomvc upgrade', parsed into opts.
+func upgradeFlags(opts *upgradeOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the changes the upgrade would make without touching the filesystem")
+	fs.BoolVar(&opts.force, "force", false, "Compare the files even if the project was generated by this version of gomvc")
+	fs.BoolVar(&opts.skipVerify, "skip-verify", false, "Skip running go mod tidy and go build after upgrading")
+	fs.StringVar(&opts.templatesDir, "templates", "", "Directory of templates the project was created with, if any")
+	fs.BoolVar(&opts.verbose, "v", false, "Print every file written and command run")
+	addBackupFlags(fs, &opts.backup)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintln(w, "Usage: gomvc upgrade <path> [options]")
+		fmt.Fprintln(w, "\nRenders the templates of this version of gomvc with the options the project")
+		fmt.Fprintln(w, "was created with, and updates the files that were not changed since they")
+		fmt.Fprintln(w, "were generated. Changed files get the new version merged into them, or")
+		fmt.Fprintln(w, "written next to them as <file>"+scaffold.UpgradeSuffix+" when that fails. A diff of")
+		fmt.Fprintln(w, "every change is printed, and the files replaced are backed up first.")
+		fmt.Fprintln(w, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func upgradeCommand(args []string) {
+	var opts upgradeOptions
+	fs := upgradeFlags(&opts)
+	paths := parseArgs(fs, args)
+	if len(paths) != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	gomvcVersion, _, _ := buildVersion()
+	project := &scaffold.Project{
+		Root:         paths[0],
+		GomvcVersion: gomvcVersion,
+		DryRun:       opts.dryRun,
+		Force:        opts.force,
+		SkipVerify:   opts.skipVerify,
+		Verbose:      opts.verbose,
+		BackupDir:    opts.backup.backupDir(),
+		Out:          os.Stdout,
+	}
+	if opts.templatesDir != "" {
+		if info, err := os.Stat(opts.templatesDir); err != nil || !info.IsDir() {
+			fail("Error upgrading the project", &scaffold.ValidationError{Err: fmt.Errorf("invalid templates directory: %s is not a directory", opts.templatesDir)})
+		}
+		project.Templates = os.DirFS(opts.templatesDir)
+	}
+	err := project.Upgrade(context.Background())
+	if errors.Is(err, scaffold.ErrNoManifest) {
+		err = fmt.Errorf("%w in %s: only projects created by gomvc new can be upgraded", err, paths[0])
+	}
+	if err != nil {
+		fail("Error upgrading the project", err)
+	}
+	if opts.dryRun {
+		fmt.Println("Dry run complete, nothing was changed.")
+	}
+}
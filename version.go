@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// version, commit and date describe the build of gomvc. Releases set them
+// with:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.commit=$(git rev-parse --short HEAD) -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Otherwise they are read from the build info, which go install records.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// buildVersion returns the version, commit and build date of gomvc, each
+// from -ldflags or else from the build info. The version is "dev" and the
+// others are empty when neither knows them.
+func buildVersion() (v, c, d string) {
+	v, c, d = version, commit, date
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch {
+			case s.Key == "vcs.revision" && c == "":
+				c = s.Value
+				if len(c) > 12 {
+					c = c[:12]
+				}
+			case s.Key == "vcs.time" && d == "":
+				d = s.Value
+			}
+		}
+	}
+	if v == "" {
+		v = "dev"
+	}
+	return v, c, d
+}
+
+// versionString returns the version of gomvc with its commit and build
+// date, e.g. "v1.2.3 (commit 0123abcd, built 2024-05-01T10:00:00Z)".
+func versionString() string {
+	v, c, d := buildVersion()
+	switch {
+	case c != "" && d != "":
+		return fmt.Sprintf("%s (commit %s, built %s)", v, c, d)
+	case c != "":
+		return fmt.Sprintf("%s (commit %s)", v, c)
+	case d != "":
+		return fmt.Sprintf("%s (built %s)", v, d)
+	}
+	return v
+}
+
+func versionCommand() {
+	fmt.Println("gomvc " + versionString())
+}
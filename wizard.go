@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/AlexCrominus/gomvc/scaffold"
+)
+
+// errAborted is returned by the wizard when the user stops answering,
+// with Ctrl+D, or declines to generate the project.
+var errAborted = errors.New("aborted")
+
+// wizard asks the questions of 'gomvc new' on a terminal, storing each
+// answer in the flag of the same question, so that everything it asks can
+// be passed as flags too.
+type wizard struct {
+	fs  *flag.FlagSet
+	in  *bufio.Reader
+	out io.Writer
+	// set holds the flags given on the command line, whose questions are
+	// not asked.
+	set map[string]bool
+}
+
+// runWizard asks for the path of the project and the options of fs not
+// given on the command line, shows a summary and asks to confirm it. It
+// returns the path, with the answers parsed into the options of fs.
+func runWizard(fs *flag.FlagSet, opts *createOptions) (string, error) {
+	// Nothing is written until the summary is confirmed, so Ctrl+C needs
+	// no cleanup, only a message saying so.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer func() {
+		signal.Stop(interrupt)
+		close(interrupt)
+	}()
+	go func() {
+		if _, ok := <-interrupt; ok {
+			fmt.Fprintln(os.Stderr, "\nAborted; nothing was written.")
+			os.Exit(exitInterrupted)
+		}
+	}()
+
+	w := &wizard{fs: fs, in: bufio.NewReader(os.Stdin), out: os.Stdout, set: make(map[string]bool)}
+	fs.Visit(func(f *flag.Flag) { w.set[f.Name] = true })
+	fmt.Fprintln(w.out, "Let's set up a new project. Press Enter to accept the default in brackets, or Ctrl+C to quit.")
+
+	path, err := w.ask("Where should the project be created?", "myapp", func(s string) error {
+		if s == "" {
+			return errors.New("enter a path")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if goMod := scaffold.FindGoMod(path); goMod != "" && !opts.intoExisting {
+		ok, err := w.confirm(fmt.Sprintf("%s is inside the Go module declared in %s. Add the project to that module?", path, goMod), false)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", errAborted
+		}
+		w.setFlag("into-existing", "true")
+	}
+	if !opts.intoExisting {
+		err := w.askFlag("module", "Go module path, e.g. github.com/username/project", scaffold.DefaultModulePath(path), func(s string) error {
+			return scaffold.ValidateModulePath(scaffold.CleanModulePath(s))
+		})
+		if err != nil {
+			return "", err
+		}
+		opts.module = scaffold.CleanModulePath(opts.module)
+	}
+	if err := w.chooseFlag("framework", "Web framework", scaffold.Frameworks()); err != nil {
+		return "", err
+	}
+	if err := w.chooseFlag("db", "Database", append([]string{"none"}, scaffold.Databases()...)); err != nil {
+		return "", err
+	}
+	if opts.database != "" {
+		if err := w.chooseFlag("orm", "Library to access "+opts.database+" with", scaffold.ORMs(opts.database)); err != nil {
+			return "", err
+		}
+	}
+	if opts.layout == "" || opts.layout == scaffold.DefaultLayout {
+		// The other layouts support neither
+		if err := w.chooseFlag("auth", "Authentication", append([]string{"none"}, scaffold.AuthSchemes()...)); err != nil {
+			return "", err
+		}
+		if err := w.toggleFlag("swagger", "Serve Swagger UI documentation of the API?"); err != nil {
+			return "", err
+		}
+	}
+	if err := w.toggleFlag("docker", "Add a Dockerfile and docker-compose.yml?"); err != nil {
+		return "", err
+	}
+
+	fmt.Fprintln(w.out, "\nThe project will be generated with:")
+	fmt.Fprintf(w.out, "  gomvc new %s\n", shellWord(path)+flagArgs(fs))
+	ok, err := w.confirm("Generate it?", true)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errAborted
+	}
+	return path, nil
+}
+
+// ask asks question until the answer passes validate, returning def for
+// an empty answer.
+func (w *wizard) ask(question, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(w.out, "%s: ", question)
+		}
+		answer, err := w.readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid answer: %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// askFlag asks question for the flag called name unless it was given,
+// suggesting def if the flag has no value yet.
+func (w *wizard) askFlag(name, question, def string, validate func(string) error) error {
+	if w.set[name] {
+		return nil
+	}
+	if v := w.fs.Lookup(name).Value.String(); v != "" {
+		def = v
+	}
+	answer, err := w.ask(question, def, validate)
+	if err != nil {
+		return err
+	}
+	w.setFlag(name, answer)
+	return nil
+}
+
+// chooseFlag asks for one of choices, by number or name, as the value of
+// the flag called name unless it was given. The value of the flag, or the
+// first choice, is the default. Choosing "none" leaves the flag unset.
+func (w *wizard) chooseFlag(name, question string, choices []string) error {
+	if w.set[name] {
+		return nil
+	}
+	def := choices[0]
+	if v := w.fs.Lookup(name).Value.String(); v != "" {
+		def = v
+	}
+	fmt.Fprintf(w.out, "%s:\n", question)
+	for i, c := range choices {
+		fmt.Fprintf(w.out, "  %d) %s\n", i+1, c)
+	}
+	answer, err := w.ask("Choose", def, func(s string) error {
+		if n, err := strconv.Atoi(s); err == nil && n >= 1 && n <= len(choices) {
+			return nil
+		}
+		for _, c := range choices {
+			if s == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("enter a number from 1 to %d or one of %s", len(choices), strings.Join(choices, ", "))
+	})
+	if err != nil {
+		return err
+	}
+	if n, err := strconv.Atoi(answer); err == nil {
+		answer = choices[n-1]
+	}
+	if answer != "none" {
+		w.setFlag(name, answer)
+	}
+	return nil
+}
+
+// toggleFlag asks question as a yes or no question for the boolean flag
+// called name unless it was given.
+func (w *wizard) toggleFlag(name, question string) error {
+	if w.set[name] {
+		return nil
+	}
+	on, err := w.confirm(question, w.fs.Lookup(name).Value.String() == "true")
+	if err != nil {
+		return err
+	}
+	if on {
+		w.setFlag(name, "true")
+	}
+	return nil
+}
+
+// confirm asks a yes or no question, returning def for an empty answer.
+func (w *wizard) confirm(question string, def bool) (bool, error) {
+	choices := "y/N"
+	if def {
+		choices = "Y/n"
+	}
+	for {
+		fmt.Fprintf(w.out, "%s [%s] ", question, choices)
+		answer, err := w.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// setFlag sets the flag called name to value, as if it had been given on
+// the command line.
+func (w *wizard) setFlag(name, value string) {
+	// Every value set is one of the choices or has been validated
+	_ = w.fs.Set(name, value)
+}
+
+// readLine reads an answer, returning errAborted at the end of the input.
+func (w *wizard) readLine() (string, error) {
+	line, err := w.in.ReadString('\n')
+	if errors.Is(err, io.EOF) {
+		fmt.Fprintln(w.out)
+		return "", errAborted
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// flagArgs returns the flags set on fs as command line arguments, each
+// with a leading space.
+func flagArgs(fs *flag.FlagSet) string {
+	var b strings.Builder
+	fs.Visit(func(f *flag.Flag) {
+		b.WriteString(" -" + f.Name)
+		if isBoolFlag(f) {
+			if f.Value.String() != "true" {
+				b.WriteString("=" + f.Value.String())
+			}
+			return
+		}
+		b.WriteString(" " + shellWord(f.Value.String()))
+	})
+	return b.String()
+}
+
+// shellWord quotes s for a shell unless it is made of characters that
+// need no quoting.
+func shellWord(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@,+=") == "" {
+		return s
+	}
+	return shellQuote(s)
+}